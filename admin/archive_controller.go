@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/service"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Archive renders one page of all games, filtered by table, state and player.
+func Archive(gameService *service.GameSer, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		filter := repository.GameFilter{
+			Table:  r.URL.Query().Get("table"),
+			State:  repository.GameState(r.URL.Query().Get("state")),
+			Player: strings.TrimSpace(r.URL.Query().Get("player")),
+		}
+
+		page, err := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+
+		if err != nil || page < 1 {
+			page = 1
+		}
+
+		archiveTemplate, err := renderArchiveTemplate(gameService, tableService, filter, page)
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the archive template %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("content-type", "text/html")
+		w.WriteHeader(200)
+
+		_, err = w.Write(archiveTemplate.Bytes())
+
+		if err != nil {
+			log.Printf("writing archive template to output writer failed %s\n", err)
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the archive template %s", err), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func renderArchiveTemplate(gameService *service.GameSer, tableService *service.TableSer, filter repository.GameFilter, page int64) (*bytes.Buffer, error) {
+
+	var output bytes.Buffer
+
+	tmpl, err := mainTemplate()
+
+	if err != nil {
+		log.Printf("can not render archive template %s\n", err)
+		return nil, err
+	}
+
+	games, err := gameService.GetGames(filter, page)
+
+	if err != nil {
+		log.Printf("get games failed: %s\n", err)
+		return nil, err
+	}
+
+	err = tmpl.ExecuteTemplate(&output, "archive-table-content", templateContent{
+		ArchiveEntries: games,
+		ArchivePaging:  calculatePages(gameService.CountGames(filter), page),
+		ArchiveFilter:  filter,
+		ArchiveTables:  tableService.GetAll(),
+		GameStates:     repository.GameStates(),
+	})
+
+	if err != nil {
+		log.Printf("generate archive template failed %s\n", err)
+		return nil, err
+	}
+
+	return &output, nil
+}
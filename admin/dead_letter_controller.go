@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"louie-web-administrator/service"
+	"net/http"
+)
+
+func DeadLetters(deadLetterService *service.DeadLetterSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeDeadLetterTemplate(w, deadLetterService)
+	}
+}
+
+func ReplayDeadLetter(deadLetterService *service.DeadLetterSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		err := deadLetterService.Replay(r.Context(), r.Header.Get("Hx-Trigger"))
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("replay of dead letter failed %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		writeDeadLetterTemplate(w, deadLetterService)
+	}
+}
+
+func DiscardDeadLetter(deadLetterService *service.DeadLetterSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		err := deadLetterService.Discard(r.Header.Get("Hx-Trigger"))
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("discard of dead letter failed %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		writeDeadLetterTemplate(w, deadLetterService)
+	}
+}
+
+func writeDeadLetterTemplate(w http.ResponseWriter, deadLetterService *service.DeadLetterSer) {
+
+	deadLetterTemplate, err := renderDeadLetterTemplate(deadLetterService)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("something goes wrong during rendering the dead letter template %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
+	w.WriteHeader(200)
+
+	_, err = w.Write(deadLetterTemplate.Bytes())
+
+	if err != nil {
+		log.Printf("writing dead letter template to output writer failed %s\n", err)
+		http.Error(w, fmt.Sprintf("something goes wrong during rendering the dead letter template %s", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+func renderDeadLetterTemplate(deadLetterService *service.DeadLetterSer) (*bytes.Buffer, error) {
+
+	var output bytes.Buffer
+
+	tmpl, err := mainTemplate()
+
+	if err != nil {
+		log.Printf("can not render dead letter template %s\n", err)
+		return nil, err
+	}
+
+	openEntries, err := deadLetterService.GetOpen()
+
+	if err != nil {
+		log.Printf("get open dead letters failed: %s\n", err)
+		return nil, err
+	}
+
+	err = tmpl.ExecuteTemplate(&output, "dead-letter-table-content", templateContent{
+		DeadLetterEntries: openEntries,
+		DeadLetterCount:   deadLetterService.CountOpen(),
+	})
+
+	if err != nil {
+		log.Printf("generate dead letter template failed %s\n", err)
+		return nil, err
+	}
+
+	return &output, nil
+}
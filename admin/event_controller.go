@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/service"
+	"net/http"
+	"strings"
+)
+
+func Events(eventStoreService service.EventStoreService) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		filter := repository.EventFilter{
+			GameId: strings.TrimSpace(r.URL.Query().Get("game")),
+			Event:  r.URL.Query().Get("event"),
+		}
+
+		eventTemplate, err := renderEventTemplate(eventStoreService, filter)
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the event template %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("content-type", "text/html")
+		w.WriteHeader(200)
+
+		_, err = w.Write(eventTemplate.Bytes())
+
+		if err != nil {
+			log.Printf("writing event template to output writer failed %s\n", err)
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the event template %s", err), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func renderEventTemplate(eventStoreService service.EventStoreService, filter repository.EventFilter) (*bytes.Buffer, error) {
+
+	var output bytes.Buffer
+
+	tmpl, err := mainTemplate()
+
+	if err != nil {
+		log.Printf("can not render event template %s\n", err)
+		return nil, err
+	}
+
+	eventEntries, err := eventStoreService.GetEvents(filter)
+
+	if err != nil {
+		log.Printf("get events failed: %s\n", err)
+		return nil, err
+	}
+
+	err = tmpl.ExecuteTemplate(&output, "events-table-content", templateContent{
+		EventEntries:    eventEntries,
+		EventGameFilter: filter.GameId,
+		EventTypeFilter: filter.Event,
+		EventTypes:      louie_kafka.EventTypes(),
+	})
+
+	if err != nil {
+		log.Printf("generate event template failed %s\n", err)
+		return nil, err
+	}
+
+	return &output, nil
+}
@@ -7,7 +7,7 @@ import (
 	"net/http"
 )
 
-func Filter(userService *service.UserSer) func(w http.ResponseWriter, r *http.Request) {
+func Filter(userService *service.UserSer, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		if _, ok := parseForm(w, r); ok {
@@ -17,9 +17,8 @@ func Filter(userService *service.UserSer) func(w http.ResponseWriter, r *http.Re
 		nameFilterValue := readFilterValueFromRequest(r.Form)
 
 		pagedUsers := userService.GetUsers(1, nameFilterValue)
-		activeUsersCount := userService.CountActiveUsersWithoutKiUser()
 
-		usersTemplate, err := renderRegisteredUsersTemplate(pagedUsers, calculatePages(userService.CountAllWithoutKiUser(nameFilterValue), 1), activeUsersCount, nameFilterValue)
+		usersTemplate, err := renderRegisteredUsersTemplate(pagedUsers, calculatePages(userService.CountAllWithoutKiUser(nameFilterValue), 1), tableService.GetAll(), fullTables(userService, tableService), nameFilterValue)
 
 		if err != nil {
 			http.Error(w, fmt.Sprintf("something goes wrong during rendering the registered users template %s", err), http.StatusInternalServerError)
@@ -2,16 +2,26 @@ package admin
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"log"
+	"louie-web-administrator/repository"
 	"louie-web-administrator/service"
 	"louie-web-administrator/websocket"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
-func RemoveGame(userService *service.UserSer, gameService *service.GameSer, gameDashboardSocket *websocket.GameDashboardSocket) func(w http.ResponseWriter, r *http.Request) {
+func RemoveGame(gameService *service.GameSer, tableService *service.TableSer, gameDashboardSocket *websocket.GameDashboardSocket) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 
+		table, ok := readTableFromRequest(w, r, tableService)
+
+		if !ok {
+			return
+		}
+
 		_, err := gameService.RemoveGame(r.Header.Get("Hx-Trigger"))
 
 		if err != nil {
@@ -19,9 +29,164 @@ func RemoveGame(userService *service.UserSer, gameService *service.GameSer, game
 			return
 		}
 
-		gameDashboardSocket.RemoveGameFromDashboard()
+		gameDashboardSocket.RemoveGameFromDashboard(table.Sender)
+
+		gamesTemplate, err := renderGameTemplate(gameService, table)
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the games template %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("content-type", "text/html")
+		w.WriteHeader(200)
+
+		_, err = w.Write(gamesTemplate.Bytes())
+
+		if err != nil {
+			log.Printf("writing games template to output writer failed %s\n", err)
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the games template %s", err), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func ResetGame(gameService *service.GameSer, tableService *service.TableSer, gameDashboardSocket *websocket.GameDashboardSocket) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		table, ok := readTableFromRequest(w, r, tableService)
+
+		if !ok {
+			return
+		}
+
+		_, err := gameService.ResetGame(strings.TrimPrefix(r.Header.Get("Hx-Trigger"), "reset-"))
+
+		if errors.Is(err, service.ErrGameNotResettable) {
+			http.Error(w, fmt.Sprintf("reset game failed %s", err), http.StatusConflict)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("reset game failed %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		gameDashboardSocket.RemoveGameFromDashboard(table.Sender)
+
+		gamesTemplate, err := renderGameTemplate(gameService, table)
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the games template %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("content-type", "text/html")
+		w.WriteHeader(200)
+
+		_, err = w.Write(gamesTemplate.Bytes())
+
+		if err != nil {
+			log.Printf("writing games template to output writer failed %s\n", err)
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the games template %s", err), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func AbortGame(gameService *service.GameSer, tableService *service.TableSer, gameDashboardSocket *websocket.GameDashboardSocket) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		table, ok := readTableFromRequest(w, r, tableService)
+
+		if !ok {
+			return
+		}
+
+		_, err := gameService.AbortGame(strings.TrimPrefix(r.Header.Get("Hx-Trigger"), "abort-"), r.FormValue("reason"))
+
+		if errors.Is(err, service.ErrAbortReasonMissing) {
+			http.Error(w, fmt.Sprintf("abort game failed %s", err), http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, service.ErrGameNotAbortable) {
+			http.Error(w, fmt.Sprintf("abort game failed %s", err), http.StatusConflict)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("abort game failed %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		if dashboardState, err := gameService.GetCurrentDashboardState(table.Sender); err == nil {
+			gameDashboardSocket.SendToDashboard(dashboardState)
+		}
+
+		gamesTemplate, err := renderGameTemplate(gameService, table)
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the games template %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("content-type", "text/html")
+		w.WriteHeader(200)
+
+		_, err = w.Write(gamesTemplate.Bytes())
+
+		if err != nil {
+			log.Printf("writing games template to output writer failed %s\n", err)
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the games template %s", err), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func PauseGame(gameService *service.GameSer, tableService *service.TableSer, gameDashboardSocket *websocket.GameDashboardSocket) func(w http.ResponseWriter, r *http.Request) {
+	return changeGameState(gameService, tableService, gameDashboardSocket, "pause-", gameService.PauseGame, service.ErrGameNotPausable)
+}
+
+func ResumeGame(gameService *service.GameSer, tableService *service.TableSer, gameDashboardSocket *websocket.GameDashboardSocket) func(w http.ResponseWriter, r *http.Request) {
+	return changeGameState(gameService, tableService, gameDashboardSocket, "resume-", gameService.ResumeGame, service.ErrGameNotPaused)
+}
+
+// changeGameState changes the state of the game, whose id is part of the id of the button, and sends the changed
+// game to the dashboard of the table.
+func changeGameState(
+	gameService *service.GameSer,
+	tableService *service.TableSer,
+	gameDashboardSocket *websocket.GameDashboardSocket,
+	buttonPrefix string,
+	change func(gameId string) (*service.GameEntry, error),
+	errNotAllowed error,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		table, ok := readTableFromRequest(w, r, tableService)
+
+		if !ok {
+			return
+		}
+
+		_, err := change(strings.TrimPrefix(r.Header.Get("Hx-Trigger"), buttonPrefix))
+
+		if errors.Is(err, errNotAllowed) {
+			http.Error(w, fmt.Sprintf("change of game state failed %s", err), http.StatusConflict)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("change of game state failed %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		if dashboardState, err := gameService.GetCurrentDashboardState(table.Sender); err == nil {
+			gameDashboardSocket.SendToDashboard(dashboardState)
+		}
 
-		gamesTemplate, err := renderGameTemplate(userService, gameService)
+		gamesTemplate, err := renderGameTemplate(gameService, table)
 
 		if err != nil {
 			http.Error(w, fmt.Sprintf("something goes wrong during rendering the games template %s", err), http.StatusInternalServerError)
@@ -41,24 +206,47 @@ func RemoveGame(userService *service.UserSer, gameService *service.GameSer, game
 	}
 }
 
-func AnnounceGame(userService *service.UserSer, gameService *service.GameSer) func(w http.ResponseWriter, r *http.Request) {
+func AnnounceGame(userService *service.UserSer, gameService *service.GameSer, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		activeUsers, err := userService.GetAllActive()
+		table, ok := readTableFromRequest(w, r, tableService)
+
+		if !ok {
+			return
+		}
+
+		activeUsers, err := userService.GetAllActive(table.Sender)
 
 		if err != nil {
 			http.Error(w, fmt.Sprintf("can not find active registered users %s", err), http.StatusInternalServerError)
 			return
 		}
 
-		_, err = gameService.CreateGame(activeUsers)
+		settings, err := readGameSettingsFromRequest(r, gameService.DefaultSettings())
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("announcing game failed %s", err), http.StatusBadRequest)
+			return
+		}
+
+		_, err = gameService.CreateGame(table, settings, activeUsers)
+
+		if errors.Is(err, service.ErrInvalidGameSettings) {
+			http.Error(w, fmt.Sprintf("announcing game failed %s", err), http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, service.ErrLouieOffline) || errors.Is(err, service.ErrGameRunning) {
+			http.Error(w, fmt.Sprintf("announcing game failed %s", err), http.StatusConflict)
+			return
+		}
 
 		if err != nil {
 			http.Error(w, fmt.Sprintf("announcing game failed %s", err), http.StatusInternalServerError)
 			return
 		}
 
-		gamesTemplate, err := renderGameTemplate(userService, gameService)
+		gamesTemplate, err := renderGameTemplate(gameService, table)
 
 		if err != nil {
 			http.Error(w, fmt.Sprintf("something goes wrong during rendering the games template %s", err), http.StatusInternalServerError)
@@ -77,7 +265,122 @@ func AnnounceGame(userService *service.UserSer, gameService *service.GameSer) fu
 		}
 	}
 }
-func renderGameTemplate(userService *service.UserSer, gameService *service.GameSer) (*bytes.Buffer, error) {
+
+// GameTable renders the current game of the table, e.g. after the next match was announced automatically.
+func GameTable(gameService *service.GameSer, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		table, ok := readTableFromRequest(w, r, tableService)
+
+		if !ok {
+			return
+		}
+
+		gamesTemplate, err := renderGameTemplate(gameService, table)
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the games template %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("content-type", "text/html")
+		w.WriteHeader(200)
+
+		if _, err := w.Write(gamesTemplate.Bytes()); err != nil {
+			log.Printf("writing games template to output writer failed %s\n", err)
+		}
+	}
+}
+
+// GameHistory renders the state transitions of the current game of the table.
+func GameHistory(gameService *service.GameSer, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		table, ok := readTableFromRequest(w, r, tableService)
+
+		if !ok {
+			return
+		}
+
+		var output bytes.Buffer
+
+		tmpl, err := mainTemplate()
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the game history %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		content, err := newTableContent(gameService, table)
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("get current game failed %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		if err := tmpl.ExecuteTemplate(&output, "game-history", content); err != nil {
+			log.Printf("generate game history template failed %s\n", err)
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the game history %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("content-type", "text/html")
+		w.WriteHeader(200)
+
+		if _, err := w.Write(output.Bytes()); err != nil {
+			log.Printf("writing game history template to output writer failed %s\n", err)
+		}
+	}
+}
+
+// readTableFromRequest reads the sender of the table, the game buttons of the admin ui send with hx-vals.
+func readTableFromRequest(w http.ResponseWriter, r *http.Request, tableService *service.TableSer) (service.Table, bool) {
+
+	table, err := tableService.Get(r.FormValue("table"))
+
+	if err != nil {
+		log.Printf("reading table of request failed: %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return service.Table{}, false
+	}
+
+	return table, true
+}
+
+// readGameSettingsFromRequest reads the seats, the starting coins and the ki toggle of the announce form. Missing
+// values fall back to the defaults.
+func readGameSettingsFromRequest(r *http.Request, defaults repository.GameSettings) (repository.GameSettings, error) {
+
+	settings := defaults
+
+	if seats := r.FormValue("seats"); seats != "" {
+		value, err := strconv.Atoi(seats)
+
+		if err != nil {
+			return settings, fmt.Errorf("seats \"%s\" are not a number", seats)
+		}
+
+		settings.Seats = value
+	}
+
+	if coins := r.FormValue("coins"); coins != "" {
+		value, err := strconv.Atoi(coins)
+
+		if err != nil {
+			return settings, fmt.Errorf("starting coins \"%s\" are not a number", coins)
+		}
+
+		settings.StartingCoins = value
+	}
+
+	if r.FormValue("withoutKi") == "on" {
+		settings.WithoutKi = true
+	}
+
+	return settings, nil
+}
+
+func renderGameTemplate(gameService *service.GameSer, table service.Table) (*bytes.Buffer, error) {
 
 	var output bytes.Buffer
 
@@ -88,17 +391,14 @@ func renderGameTemplate(userService *service.UserSer, gameService *service.GameS
 		return nil, err
 	}
 
-	game, err := gameService.GetCurrentGame()
+	content, err := newTableContent(gameService, table)
+
 	if err != nil {
 		log.Printf("get games failed: %s\n", err)
 		return nil, err
 	}
 
-	if game == nil {
-		err = tmpl.ExecuteTemplate(&output, "games-table-content", templateContent{GameEntries: []service.GameEntry{}, ActiveUsersCount: userService.CountActiveUsersWithoutKiUser()})
-	} else {
-		err = tmpl.ExecuteTemplate(&output, "games-table-content", templateContent{GameEntries: []service.GameEntry{*game}, ActiveUsersCount: userService.CountActiveUsersWithoutKiUser()})
-	}
+	err = tmpl.ExecuteTemplate(&output, "games-table-content", content)
 
 	if err != nil {
 		log.Printf("generate games template failed %s\n", err)
@@ -107,3 +407,27 @@ func renderGameTemplate(userService *service.UserSer, gameService *service.GameS
 
 	return &output, nil
 }
+
+func newTableContent(gameService *service.GameSer, table service.Table) (tableContent, error) {
+
+	game, err := gameService.GetCurrentGame(table.Sender)
+
+	if err != nil {
+		return tableContent{}, err
+	}
+
+	content := tableContent{
+		Table:        table,
+		GameEntries:  []service.GameEntry{},
+		LouieOffline: gameService.IsTableOffline(table.Sender),
+		AbortReasons: service.AbortReasons,
+		Settings:     gameService.DefaultSettings(),
+		SeatOptions:  service.SeatOptions(),
+	}
+
+	if game != nil {
+		content.GameEntries = []service.GameEntry{*game}
+	}
+
+	return content, nil
+}
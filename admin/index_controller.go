@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"html/template"
 	"log"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/repository"
 	"louie-web-administrator/service"
 	"math"
 	"net/http"
@@ -15,18 +17,53 @@ import (
 var templates embed.FS
 
 const (
-	MainTemplate   = "index.gohtml"
-	UserTemplate   = "user.gohtml"
-	GameTemplate   = "game.gohtml"
-	PagingTemplate = "paging.gohtml"
+	MainTemplate       = "index.gohtml"
+	UserTemplate       = "user.gohtml"
+	GameTemplate       = "game.gohtml"
+	PagingTemplate     = "paging.gohtml"
+	OutboxTemplate     = "outbox.gohtml"
+	DeadLetterTemplate = "dead_letter.gohtml"
+	EventTemplate      = "event.gohtml"
+	ArchiveTemplate    = "archive.gohtml"
+	MatchTemplate      = "match.gohtml"
 )
 
 type templateContent struct {
-	UserEntries      []service.UserEntry
-	GameEntries      []service.GameEntry
-	Paging           paging
-	ActiveUsersCount int
-	NameFilter       string
+	UserEntries       []service.UserEntry
+	Tables            []tableContent
+	Paging            paging
+	FullTables        map[string]bool
+	NameFilter        string
+	OutboxEntries     []service.OutboxEntry
+	OutboxPending     int64
+	OutboxFailed      int64
+	DeadLetterEntries []service.DeadLetterEntry
+	DeadLetterCount   int64
+	Presences         []service.DevicePresence
+	Connections       []service.BrokerConnection
+	Queues            []louie_kafka.QueueStats
+	EventEntries      []service.EventEntry
+	EventGameFilter   string
+	EventTypeFilter   string
+	EventTypes        []louie_kafka.EventType
+	ArchiveEntries    []service.GameEntry
+	ArchivePaging     paging
+	ArchiveFilter     repository.GameFilter
+	ArchiveTables     []service.Table
+	GameStates        []repository.GameState
+}
+
+// tableContent is the game of one louie table.
+type tableContent struct {
+	Table        service.Table
+	GameEntries  []service.GameEntry
+	LouieOffline bool
+	AbortReasons []string
+	// Settings are preselected in the announce form, SeatOptions are the selectable seats of the table.
+	Settings    repository.GameSettings
+	SeatOptions []int
+	// Matches are the queued matches of the table, the next match first.
+	Matches []service.MatchEntry
 }
 
 type paging struct {
@@ -38,14 +75,14 @@ type page struct {
 	Active bool
 }
 
-func Main(userService *service.UserSer, gameService *service.GameSer) func(w http.ResponseWriter, r *http.Request) {
+func Main(userService *service.UserSer, gameService *service.GameSer, tableService *service.TableSer, outboxService *service.OutboxSer, deadLetterService *service.DeadLetterSer, presenceService *service.PresenceSer, connectionService *service.ConnectionSer, queueService *service.QueueSer) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		writeMainTemplate(w, userService, gameService)
+		writeMainTemplate(w, userService, gameService, tableService, outboxService, deadLetterService, presenceService, connectionService, queueService)
 	}
 }
 
-func writeMainTemplate(w http.ResponseWriter, userService *service.UserSer, gameService *service.GameSer) {
-	mainTemplateContent, err := renderMainTemplate(userService, gameService)
+func writeMainTemplate(w http.ResponseWriter, userService *service.UserSer, gameService *service.GameSer, tableService *service.TableSer, outboxService *service.OutboxSer, deadLetterService *service.DeadLetterSer, presenceService *service.PresenceSer, connectionService *service.ConnectionSer, queueService *service.QueueSer) {
+	mainTemplateContent, err := renderMainTemplate(userService, gameService, tableService, outboxService, deadLetterService, presenceService, connectionService, queueService)
 
 	if err != nil {
 		http.Error(w, fmt.Sprintf("something goes wrong during rendering the admin main template %s", err), http.StatusInternalServerError)
@@ -64,7 +101,7 @@ func writeMainTemplate(w http.ResponseWriter, userService *service.UserSer, game
 	}
 }
 
-func renderMainTemplate(userService *service.UserSer, gameService *service.GameSer) (*bytes.Buffer, error) {
+func renderMainTemplate(userService *service.UserSer, gameService *service.GameSer, tableService *service.TableSer, outboxService *service.OutboxSer, deadLetterService *service.DeadLetterSer, presenceService *service.PresenceSer, connectionService *service.ConnectionSer, queueService *service.QueueSer) (*bytes.Buffer, error) {
 
 	var output bytes.Buffer
 
@@ -76,25 +113,44 @@ func renderMainTemplate(userService *service.UserSer, gameService *service.GameS
 	}
 
 	pagedUsers := userService.GetUsers(1, "")
-	activeUsersCount := userService.CountActiveUsersWithoutKiUser()
-	game, err := gameService.GetCurrentGame()
+	tables := make([]tableContent, 0, len(tableService.GetAll()))
+
+	for _, table := range tableService.GetAll() {
+		content, err := newTableContent(gameService, table)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tables = append(tables, content)
+	}
+
+	failedOutboxEntries, err := outboxService.GetFailed()
 
 	if err != nil {
 		return nil, err
 	}
 
-	templateContent := templateContent{
-		UserEntries:      pagedUsers,
-		GameEntries:      []service.GameEntry{},
-		Paging:           calculatePages(userService.CountAllWithoutKiUser(""), 1),
-		ActiveUsersCount: activeUsersCount,
-		NameFilter:       "",
+	openDeadLetters, err := deadLetterService.GetOpen()
+
+	if err != nil {
+		return nil, err
 	}
 
-	if game == nil {
-		templateContent.GameEntries = []service.GameEntry{}
-	} else {
-		templateContent.GameEntries = []service.GameEntry{*game}
+	templateContent := templateContent{
+		UserEntries:       pagedUsers,
+		Tables:            tables,
+		Paging:            calculatePages(userService.CountAllWithoutKiUser(""), 1),
+		FullTables:        fullTables(userService, tableService),
+		NameFilter:        "",
+		OutboxEntries:     failedOutboxEntries,
+		OutboxPending:     outboxService.CountPending(),
+		OutboxFailed:      outboxService.CountFailed(),
+		DeadLetterEntries: openDeadLetters,
+		DeadLetterCount:   deadLetterService.CountOpen(),
+		Presences:         presenceService.GetPresences(),
+		Connections:       connectionService.GetConnections(),
+		Queues:            queueService.GetQueues(),
 	}
 
 	err = tmpl.Execute(&output, templateContent)
@@ -107,8 +163,20 @@ func renderMainTemplate(userService *service.UserSer, gameService *service.GameS
 	return &output, nil
 }
 
+// fullTables tells per table, whether the maximum of active users is reached. Four players need a game without ki.
+func fullTables(userService *service.UserSer, tableService *service.TableSer) map[string]bool {
+
+	full := make(map[string]bool)
+
+	for _, table := range tableService.GetAll() {
+		full[table.Sender] = userService.CountActiveUsersWithoutKiUser(table.Sender) >= repository.MaxSeats
+	}
+
+	return full
+}
+
 func mainTemplate() (*template.Template, error) {
-	tmpl, err := template.ParseFS(templates, MainTemplate, UserTemplate, GameTemplate, PagingTemplate)
+	tmpl, err := template.ParseFS(templates, MainTemplate, UserTemplate, GameTemplate, PagingTemplate, OutboxTemplate, DeadLetterTemplate, EventTemplate, ArchiveTemplate, MatchTemplate)
 
 	return tmpl, err
 }
@@ -0,0 +1,237 @@
+package admin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"louie-web-administrator/service"
+	"net/http"
+	"strings"
+)
+
+// MatchQueue renders the queued matches of the table.
+func MatchQueue(matchQueueService *service.MatchQueueSer, gameService *service.GameSer, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		table, ok := readTableFromRequest(w, r, tableService)
+
+		if !ok {
+			return
+		}
+
+		writeMatchQueueTemplate(w, matchQueueService, gameService, table)
+	}
+}
+
+// EnqueueMatch adds a match with the players of the form to the end of the queue.
+func EnqueueMatch(matchQueueService *service.MatchQueueSer, gameService *service.GameSer, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		table, ok := readTableFromRequest(w, r, tableService)
+
+		if !ok {
+			return
+		}
+
+		settings, err := readGameSettingsFromRequest(r, gameService.DefaultSettings())
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("adding match failed %s", err), http.StatusBadRequest)
+			return
+		}
+
+		if _, err := matchQueueService.Enqueue(table, readPlayersFromRequest(r), settings); err != nil {
+			writeMatchError(w, "adding match failed", err)
+			return
+		}
+
+		writeMatchQueueTemplate(w, matchQueueService, gameService, table)
+	}
+}
+
+// EditMatch replaces the players and the settings of the queued match.
+func EditMatch(matchQueueService *service.MatchQueueSer, gameService *service.GameSer, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		table, ok := readTableFromRequest(w, r, tableService)
+
+		if !ok {
+			return
+		}
+
+		settings, err := readGameSettingsFromRequest(r, gameService.DefaultSettings())
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("editing match failed %s", err), http.StatusBadRequest)
+			return
+		}
+
+		matchId := strings.TrimPrefix(r.Header.Get("Hx-Trigger"), "match-edit-")
+
+		if err := matchQueueService.Edit(matchId, readPlayersFromRequest(r), settings); err != nil {
+			writeMatchError(w, "editing match failed", err)
+			return
+		}
+
+		writeMatchQueueTemplate(w, matchQueueService, gameService, table)
+	}
+}
+
+// MoveMatch moves the queued match one place to the front (-1) or to the back (1) of the queue.
+func MoveMatch(matchQueueService *service.MatchQueueSer, gameService *service.GameSer, tableService *service.TableSer, offset int) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		table, ok := readTableFromRequest(w, r, tableService)
+
+		if !ok {
+			return
+		}
+
+		trigger := r.Header.Get("Hx-Trigger")
+		matchId := strings.TrimPrefix(strings.TrimPrefix(trigger, "match-up-"), "match-down-")
+
+		if err := matchQueueService.Move(matchId, offset); err != nil {
+			writeMatchError(w, "moving match failed", err)
+			return
+		}
+
+		writeMatchQueueTemplate(w, matchQueueService, gameService, table)
+	}
+}
+
+// SkipMatch removes the match from the queue.
+func SkipMatch(matchQueueService *service.MatchQueueSer, gameService *service.GameSer, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		table, ok := readTableFromRequest(w, r, tableService)
+
+		if !ok {
+			return
+		}
+
+		if err := matchQueueService.Skip(strings.TrimPrefix(r.Header.Get("Hx-Trigger"), "match-skip-")); err != nil {
+			writeMatchError(w, "skipping match failed", err)
+			return
+		}
+
+		writeMatchQueueTemplate(w, matchQueueService, gameService, table)
+	}
+}
+
+// AnnounceNextMatch announces the next match without waiting for the cool-down and renders the new game.
+func AnnounceNextMatch(matchQueueService *service.MatchQueueSer, gameService *service.GameSer, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		table, ok := readTableFromRequest(w, r, tableService)
+
+		if !ok {
+			return
+		}
+
+		if _, err := matchQueueService.AnnounceNext(table); err != nil {
+			writeMatchError(w, "announcing next match failed", err)
+			return
+		}
+
+		gamesTemplate, err := renderGameTemplate(gameService, table)
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the games template %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("content-type", "text/html")
+		w.WriteHeader(200)
+
+		_, err = w.Write(gamesTemplate.Bytes())
+
+		if err != nil {
+			log.Printf("writing games template to output writer failed %s\n", err)
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the games template %s", err), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// readPlayersFromRequest reads the comma separated display names of the match form.
+func readPlayersFromRequest(r *http.Request) []string {
+
+	players := make([]string, 0)
+
+	for _, player := range strings.Split(r.FormValue("players"), ",") {
+		if player = strings.TrimSpace(player); player != "" {
+			players = append(players, player)
+		}
+	}
+
+	return players
+}
+
+func writeMatchError(w http.ResponseWriter, message string, err error) {
+
+	status := http.StatusInternalServerError
+
+	switch {
+	case errors.Is(err, service.ErrInvalidMatch), errors.Is(err, service.ErrInvalidGameSettings):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrMatchNotQueued), errors.Is(err, service.ErrQueueEmpty),
+		errors.Is(err, service.ErrGameRunning), errors.Is(err, service.ErrLouieOffline), errors.Is(err, service.ErrPlayerBusy):
+		status = http.StatusConflict
+	}
+
+	http.Error(w, fmt.Sprintf("%s %s", message, err), status)
+}
+
+func writeMatchQueueTemplate(w http.ResponseWriter, matchQueueService *service.MatchQueueSer, gameService *service.GameSer, table service.Table) {
+
+	matchQueueTemplate, err := renderMatchQueueTemplate(matchQueueService, gameService, table)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("something goes wrong during rendering the match queue template %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
+	w.WriteHeader(200)
+
+	_, err = w.Write(matchQueueTemplate.Bytes())
+
+	if err != nil {
+		log.Printf("writing match queue template to output writer failed %s\n", err)
+		http.Error(w, fmt.Sprintf("something goes wrong during rendering the match queue template %s", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+func renderMatchQueueTemplate(matchQueueService *service.MatchQueueSer, gameService *service.GameSer, table service.Table) (*bytes.Buffer, error) {
+
+	var output bytes.Buffer
+
+	tmpl, err := mainTemplate()
+
+	if err != nil {
+		log.Printf("can not render match queue template %s\n", err)
+		return nil, err
+	}
+
+	matches, err := matchQueueService.GetQueued(table.Sender)
+
+	if err != nil {
+		log.Printf("get queued matches failed: %s\n", err)
+		return nil, err
+	}
+
+	err = tmpl.ExecuteTemplate(&output, "match-queue-content", tableContent{
+		Table:    table,
+		Settings: gameService.DefaultSettings(),
+		Matches:  matches,
+	})
+
+	if err != nil {
+		log.Printf("generate match queue template failed %s\n", err)
+		return nil, err
+	}
+
+	return &output, nil
+}
@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"louie-web-administrator/service"
+	"net/http"
+)
+
+func RetryOutboxEntry(outboxService *service.OutboxSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		err := outboxService.Retry(r.Header.Get("Hx-Trigger"))
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("retry of outbox entry failed %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		writeOutboxTemplate(w, outboxService)
+	}
+}
+
+func DiscardOutboxEntry(outboxService *service.OutboxSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		err := outboxService.Discard(r.Header.Get("Hx-Trigger"))
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("discard of outbox entry failed %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		writeOutboxTemplate(w, outboxService)
+	}
+}
+
+func writeOutboxTemplate(w http.ResponseWriter, outboxService *service.OutboxSer) {
+
+	outboxTemplate, err := renderOutboxTemplate(outboxService)
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("something goes wrong during rendering the outbox template %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
+	w.WriteHeader(200)
+
+	_, err = w.Write(outboxTemplate.Bytes())
+
+	if err != nil {
+		log.Printf("writing outbox template to output writer failed %s\n", err)
+		http.Error(w, fmt.Sprintf("something goes wrong during rendering the outbox template %s", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+func renderOutboxTemplate(outboxService *service.OutboxSer) (*bytes.Buffer, error) {
+
+	var output bytes.Buffer
+
+	tmpl, err := mainTemplate()
+
+	if err != nil {
+		log.Printf("can not render outbox template %s\n", err)
+		return nil, err
+	}
+
+	failedEntries, err := outboxService.GetFailed()
+
+	if err != nil {
+		log.Printf("get failed outbox entries failed: %s\n", err)
+		return nil, err
+	}
+
+	err = tmpl.ExecuteTemplate(&output, "outbox-table-content", templateContent{
+		OutboxEntries: failedEntries,
+		OutboxPending: outboxService.CountPending(),
+		OutboxFailed:  outboxService.CountFailed(),
+	})
+
+	if err != nil {
+		log.Printf("generate outbox template failed %s\n", err)
+		return nil, err
+	}
+
+	return &output, nil
+}
@@ -1,18 +1,34 @@
 package admin
 
 import (
+	"fmt"
+	"html"
 	"louie-web-administrator/service"
 	"net/http"
 )
 
-func ConfirmSideChange(technicalEventHandler *service.TechnicalEventHandler) func(w http.ResponseWriter, r *http.Request) {
+func ConfirmSideChange(technicalEventHandler *service.TechnicalEventHandler, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		technicalEventHandler.SendConfirmedChangeSideEvent()
+		table, ok := readTableFromRequest(w, r, tableService)
+
+		if !ok {
+			return
+		}
+
+		err := technicalEventHandler.SendConfirmedChangeSideEvent(table.Sender)
 
 		w.Header().Set("content-type", "text/html")
 		w.WriteHeader(200)
 
-		_, _ = w.Write([]byte("<div hx-swap-oob=\"replace:#confirm-change-side\"></div>"))
+		if err != nil {
+			_, _ = w.Write([]byte(fmt.Sprintf("<div hx-swap-oob=\"replace:#confirm-change-side-%s\">"+
+				"<button class=\"btn btn-secondary\" hx-post=\"/confirm\" hx-vals='{\"table\": \"%s\"}'>Confirm side change</button>"+
+				"<p class=\"alert alert-danger\">sending confirmed side change to louie failed: %s</p>"+
+				"</div>", table.Sender, table.Sender, html.EscapeString(err.Error()))))
+			return
+		}
+
+		_, _ = w.Write([]byte(fmt.Sprintf("<div hx-swap-oob=\"replace:#confirm-change-side-%s\"></div>", table.Sender)))
 	}
 }
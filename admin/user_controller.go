@@ -10,7 +10,7 @@ import (
 	"strconv"
 )
 
-func Page(userService *service.UserSer) func(w http.ResponseWriter, r *http.Request) {
+func Page(userService *service.UserSer, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		params := mux.Vars(r)
@@ -23,7 +23,7 @@ func Page(userService *service.UserSer) func(w http.ResponseWriter, r *http.Requ
 			return
 		}
 
-		usersTemplate, err := generateUserTemplateContent(userService, page, nameFilter)
+		usersTemplate, err := generateUserTemplateContent(userService, tableService, page, nameFilter)
 
 		if err != nil {
 			http.Error(w, fmt.Sprintf("something goes wrong during rendering the registered users template %s", err), http.StatusInternalServerError)
@@ -42,7 +42,7 @@ func Page(userService *service.UserSer) func(w http.ResponseWriter, r *http.Requ
 		}
 	}
 }
-func Position(userService *service.UserSer) func(w http.ResponseWriter, r *http.Request) {
+func Position(userService *service.UserSer, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		if _, ok := parseForm(w, r); ok {
@@ -69,7 +69,7 @@ func Position(userService *service.UserSer) func(w http.ResponseWriter, r *http.
 			}
 		}
 
-		usersTemplate, err := generateUserTemplateContent(userService, pageNumber, nameFilter)
+		usersTemplate, err := generateUserTemplateContent(userService, tableService, pageNumber, nameFilter)
 
 		if err != nil {
 			http.Error(w, fmt.Sprintf("something goes wrong during rendering the registered users template %s", err), http.StatusInternalServerError)
@@ -89,7 +89,8 @@ func Position(userService *service.UserSer) func(w http.ResponseWriter, r *http.
 	}
 }
 
-func Shuffle(userService *service.UserSer) func(w http.ResponseWriter, r *http.Request) {
+// Shuffle hands out random positions within the seats of the request, by default the seats of GAME_SEATS.
+func Shuffle(userService *service.UserSer, gameService *service.GameSer, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		if _, ok := parseForm(w, r); ok {
@@ -99,9 +100,18 @@ func Shuffle(userService *service.UserSer) func(w http.ResponseWriter, r *http.R
 		pageNumber := readPageNumberFromRequest(r.Form)
 		nameFilter := readFilterValueFromRequest(r.Form)
 
-		userService.ShufflePositionsForActiveUsers()
+		settings, err := readGameSettingsFromRequest(r, gameService.DefaultSettings())
 
-		usersTemplate, err := generateUserTemplateContent(userService, pageNumber, nameFilter)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("shuffling positions failed %s", err), http.StatusBadRequest)
+			return
+		}
+
+		for _, table := range tableService.GetAll() {
+			userService.ShufflePositionsForActiveUsers(table.Sender, settings.Seats)
+		}
+
+		usersTemplate, err := generateUserTemplateContent(userService, tableService, pageNumber, nameFilter)
 
 		if err != nil {
 			http.Error(w, fmt.Sprintf("something goes wrong during rendering the registered users template %s", err), http.StatusInternalServerError)
@@ -121,7 +131,7 @@ func Shuffle(userService *service.UserSer) func(w http.ResponseWriter, r *http.R
 	}
 }
 
-func State(userService *service.UserSer, adminEventService *service.AdminEventService) func(w http.ResponseWriter, r *http.Request) {
+func State(userService *service.UserSer, tableService *service.TableSer, adminEventService *service.AdminEventService) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		if _, ok := parseForm(w, r); ok {
@@ -150,7 +160,7 @@ func State(userService *service.UserSer, adminEventService *service.AdminEventSe
 
 		adminEventService.CheckActiveUsersAndEnableOrDisableGameButton()
 
-		usersTemplate, err := generateUserTemplateContent(userService, pageNumber, nameFilter)
+		usersTemplate, err := generateUserTemplateContent(userService, tableService, pageNumber, nameFilter)
 
 		if err != nil {
 			http.Error(w, fmt.Sprintf("something goes wrong during rendering the registered users template %s", err), http.StatusInternalServerError)
@@ -170,7 +180,64 @@ func State(userService *service.UserSer, adminEventService *service.AdminEventSe
 	}
 }
 
-func Wait(userService *service.UserSer, adminEventService *service.AdminEventService) func(w http.ResponseWriter, r *http.Request) {
+// Table moves users to another louie table. The users keep their state.
+func Table(userService *service.UserSer, tableService *service.TableSer, adminEventService *service.AdminEventService) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if _, ok := parseForm(w, r); ok {
+			return
+		}
+
+		userIdsToTables, err := userService.MapUserIdsToTables(r.Form)
+		pageNumber := readPageNumberFromRequest(r.Form)
+		nameFilter := readFilterValueFromRequest(r.Form)
+
+		if err != nil {
+			log.Printf("parsing of user ids to tables failed: %s\n", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		for _, userIdToTable := range userIdsToTables {
+
+			if _, err := tableService.Get(userIdToTable.Value); err != nil {
+				log.Printf("moving user %s failed: %s\n", userIdToTable.Key, err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			_, err := userService.UpdateTable(userIdToTable.Key, userIdToTable.Value)
+
+			if err != nil {
+				log.Printf("updating user %s table failed: %s\n", userIdToTable.Value, err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		adminEventService.CheckActiveUsersAndEnableOrDisableGameButton()
+
+		usersTemplate, err := generateUserTemplateContent(userService, tableService, pageNumber, nameFilter)
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the registered users template %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("content-type", "text/html")
+		w.WriteHeader(200)
+
+		_, err = w.Write(usersTemplate.Bytes())
+
+		if err != nil {
+			log.Printf("writing registered users template to output writer failed %s\n", err)
+			http.Error(w, fmt.Sprintf("something goes wrong during rendering the registered users template %s", err), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func Wait(userService *service.UserSer, tableService *service.TableSer, adminEventService *service.AdminEventService) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		if _, ok := parseForm(w, r); ok {
@@ -186,7 +253,7 @@ func Wait(userService *service.UserSer, adminEventService *service.AdminEventSer
 			adminEventService.CheckActiveUsersAndEnableOrDisableGameButton()
 		}
 
-		usersTemplate, err := generateUserTemplateContent(userService, pageNumber, nameFilter)
+		usersTemplate, err := generateUserTemplateContent(userService, tableService, pageNumber, nameFilter)
 
 		if err != nil {
 			http.Error(w, fmt.Sprintf("something goes wrong during rendering the registered users template %s", err), http.StatusInternalServerError)
@@ -206,11 +273,10 @@ func Wait(userService *service.UserSer, adminEventService *service.AdminEventSer
 	}
 }
 
-func generateUserTemplateContent(userService *service.UserSer, page int64, nameFilter string) (*bytes.Buffer, error) {
+func generateUserTemplateContent(userService *service.UserSer, tableService *service.TableSer, page int64, nameFilter string) (*bytes.Buffer, error) {
 
 	pagedUsers := userService.GetUsers(page, nameFilter)
-	activeUsersCount := userService.CountActiveUsersWithoutKiUser()
-	usersTemplate, err := renderRegisteredUsersTemplate(pagedUsers, calculatePages(userService.CountAllWithoutKiUser(nameFilter), page), activeUsersCount, nameFilter)
+	usersTemplate, err := renderRegisteredUsersTemplate(pagedUsers, calculatePages(userService.CountAllWithoutKiUser(nameFilter), page), tableService.GetAll(), fullTables(userService, tableService), nameFilter)
 
 	return usersTemplate, err
 }
@@ -238,7 +304,7 @@ func readPageNumberFromRequest(formParameters map[string][]string) int64 {
 	return defaultPageNumber
 }
 
-func renderRegisteredUsersTemplate(users []service.UserEntry, paging paging, activeUsersCount int, nameFilterValue string) (*bytes.Buffer, error) {
+func renderRegisteredUsersTemplate(users []service.UserEntry, paging paging, tables []service.Table, fullTables map[string]bool, nameFilterValue string) (*bytes.Buffer, error) {
 
 	var output bytes.Buffer
 
@@ -249,11 +315,18 @@ func renderRegisteredUsersTemplate(users []service.UserEntry, paging paging, act
 		return nil, err
 	}
 
+	tableContents := make([]tableContent, 0, len(tables))
+
+	for _, table := range tables {
+		tableContents = append(tableContents, tableContent{Table: table})
+	}
+
 	err = tmpl.ExecuteTemplate(&output, "users-table", templateContent{
-		UserEntries:      users,
-		Paging:           paging,
-		ActiveUsersCount: activeUsersCount,
-		NameFilter:       nameFilterValue,
+		UserEntries: users,
+		Tables:      tableContents,
+		Paging:      paging,
+		FullTables:  fullTables,
+		NameFilter:  nameFilterValue,
 	})
 
 	if err != nil {
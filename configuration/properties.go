@@ -1,5 +1,12 @@
 package configuration
 
+import "time"
+
+const (
+	KafkaMessageBus = "kafka"
+	MqttMessageBus  = "mqtt"
+)
+
 type Config struct {
 	Database struct {
 		DatabaseServer   string `envconfig:"DB_SERVER" default:"localhost" required:"true"`
@@ -8,9 +15,96 @@ type Config struct {
 		DatabasePassword string `envconfig:"DB_PASSWORD" default:"superuser" required:"true"`
 		DatabasePort     int    `envconfig:"DB_PORT" default:"27017" required:"true"`
 	}
+	MessageBus struct {
+		Transport string `envconfig:"MESSAGE_BUS" default:"kafka" required:"true"`
+	}
 	Kafka struct {
 		Server          string `envconfig:"KAFKA_SERVER" default:"localhost" required:"true"`
 		Port            string `envconfig:"KAFKA_PORT" default:"9093" required:"true"`
 		LouieEventTopic string `envconfig:"LOUIE_EVENT_TOPIC" default:"LOUIE_EVENT" required:"true"`
+		ConsumerGroup   string `envconfig:"KAFKA_CONSUMER_GROUP" default:"louie-web-administrator" required:"true"`
+		StartFromTail   bool   `envconfig:"KAFKA_START_FROM_TAIL" default:"false"`
+		DeadLetterTopic string `envconfig:"KAFKA_DEAD_LETTER_TOPIC"`
+		// ReconnectBackoffMin is the first waiting time after a lost broker. It is doubled with every failed
+		// attempt up to ReconnectBackoffMax.
+		ReconnectBackoffMin time.Duration `envconfig:"KAFKA_RECONNECT_BACKOFF_MIN" default:"1s"`
+		ReconnectBackoffMax time.Duration `envconfig:"KAFKA_RECONNECT_BACKOFF_MAX" default:"30s"`
+		HealthCheckInterval time.Duration `envconfig:"KAFKA_HEALTH_CHECK_INTERVAL" default:"10s"`
+	}
+	// Topics separate the directions of the louie events. Empty topics fall back to LOUIE_EVENT_TOPIC.
+	Topics struct {
+		Inbound   string `envconfig:"LOUIE_INBOUND_TOPIC"`
+		Outbound  string `envconfig:"LOUIE_OUTBOUND_TOPIC"`
+		Technical string `envconfig:"LOUIE_TECHNICAL_TOPIC"`
+	}
+	Mqtt struct {
+		BrokerUrl string `envconfig:"MQTT_BROKER_URL" default:"tcp://localhost:1883"`
+		ClientId  string `envconfig:"MQTT_CLIENT_ID" default:"louie-web-administrator"`
+		Qos       byte   `envconfig:"MQTT_QOS" default:"1"`
+	}
+	Events struct {
+		Sender       string `envconfig:"LOUIE_SENDER" default:"louie-web-administrator" required:"true"`
+		SendEnvelope bool   `envconfig:"LOUIE_SEND_ENVELOPE" default:"false"`
+		// RequireGameId rejects events without game id. Without it, only events of another game are rejected.
+		RequireGameId bool `envconfig:"LOUIE_REQUIRE_GAME_ID" default:"false"`
+	}
+	// Tables are the louie machines in the format "sender:name[:ki name[:ki sender]]". The first table is the default
+	// table, which gets the events without sender. Its ki sender defaults to PRESENCE_KI_SENDER. With more than one
+	// table, the events of unknown senders are stored as dead letters.
+	Tables []string `envconfig:"LOUIE_TABLES" default:"c-library:Louie"`
+	// Presence defines the devices shown in the admin ui. A device is stale, if no event was received within
+	// PRESENCE_STALE_AFTER, and offline after PRESENCE_OFFLINE_AFTER.
+	Presence struct {
+		KiSender      string        `envconfig:"PRESENCE_KI_SENDER" default:"jan-ki-magic"`
+		StaleAfter    time.Duration `envconfig:"PRESENCE_STALE_AFTER" default:"15s"`
+		OfflineAfter  time.Duration `envconfig:"PRESENCE_OFFLINE_AFTER" default:"60s"`
+		CheckInterval time.Duration `envconfig:"PRESENCE_CHECK_INTERVAL" default:"5s"`
+	}
+	Outbox struct {
+		RelayInterval time.Duration `envconfig:"OUTBOX_RELAY_INTERVAL" default:"2s"`
+		MaxAttempts   int           `envconfig:"OUTBOX_MAX_ATTEMPTS" default:"10"`
+	}
+	// Queues buffer the consumed events in front of the game and the technical event handler. A full queue applies
+	// its overflow policy: block, drop_oldest_coin_drop or reject. The block policy rejects the event after
+	// QUEUE_BLOCK_TIMEOUT, so the consumer never stalls for good. The game queue must not block.
+	Queues struct {
+		GameCapacity      int           `envconfig:"QUEUE_GAME_CAPACITY" default:"100"`
+		GameOverflow      string        `envconfig:"QUEUE_GAME_OVERFLOW" default:"drop_oldest_coin_drop"`
+		TechnicalCapacity int           `envconfig:"QUEUE_TECHNICAL_CAPACITY" default:"100"`
+		TechnicalOverflow string        `envconfig:"QUEUE_TECHNICAL_OVERFLOW" default:"block"`
+		BlockTimeout      time.Duration `envconfig:"QUEUE_BLOCK_TIMEOUT" default:"5s"`
+		MonitorInterval   time.Duration `envconfig:"QUEUE_MONITOR_INTERVAL" default:"2s"`
+	}
+	// Watchdog checks the current games with every WATCHDOG_INTERVAL. A game, which stays longer than the timeout of
+	// its state without change, is reported to the admin ui and the action of the state is done: alert, prompt,
+	// resend_players_ready or abort. A timeout of 0 disables the watchdog for the state.
+	Watchdog struct {
+		Interval         time.Duration `envconfig:"WATCHDOG_INTERVAL" default:"30s"`
+		AnnouncedTimeout time.Duration `envconfig:"WATCHDOG_ANNOUNCED_TIMEOUT" default:"10m"`
+		AnnouncedAction  string        `envconfig:"WATCHDOG_ANNOUNCED_ACTION" default:"prompt"`
+		ReadyTimeout     time.Duration `envconfig:"WATCHDOG_READY_TIMEOUT" default:"2m"`
+		ReadyAction      string        `envconfig:"WATCHDOG_READY_ACTION" default:"resend_players_ready"`
+		ActiveTimeout    time.Duration `envconfig:"WATCHDOG_ACTIVE_TIMEOUT" default:"30m"`
+		ActiveAction     string        `envconfig:"WATCHDOG_ACTIVE_ACTION" default:"alert"`
+		PausedTimeout    time.Duration `envconfig:"WATCHDOG_PAUSED_TIMEOUT" default:"30m"`
+		PausedAction     string        `envconfig:"WATCHDOG_PAUSED_ACTION" default:"prompt"`
+	}
+	// Game defines the seats and starting coins, which the announce form offers by default. Both can be changed per
+	// game.
+	Game struct {
+		Seats         int `envconfig:"GAME_SEATS" default:"3"`
+		StartingCoins int `envconfig:"GAME_STARTING_COINS" default:"3"`
+	}
+	// Matches are announced from the match queue of a table MATCH_COOL_DOWN after the last game is finished. A
+	// negative cool-down disables the automatic announcement.
+	Matches struct {
+		CoolDown time.Duration `envconfig:"MATCH_COOL_DOWN" default:"60s"`
+	}
+	// EventStore keeps the consumed and published events for EVENT_RETENTION. A retention of 0 keeps the
+	// events forever. Heartbeats are kept for EVENT_HEARTBEAT_RETENTION, with 0 the heartbeats are not stored.
+	EventStore struct {
+		Retention              time.Duration `envconfig:"EVENT_RETENTION" default:"720h"`
+		HeartbeatRetention     time.Duration `envconfig:"EVENT_HEARTBEAT_RETENTION" default:"24h"`
+		RetentionCheckInterval time.Duration `envconfig:"EVENT_RETENTION_CHECK_INTERVAL" default:"1h"`
 	}
 }
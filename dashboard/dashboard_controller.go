@@ -22,9 +22,21 @@ type dashboardUser struct {
 	LastName           string `json:"lastName"`
 }
 
-func Post(userService *service.UserSer) func(w http.ResponseWriter, r *http.Request) {
+// Post registers a user at the table of the dashboard (query parameter "table", default table without it).
+func Post(userService *service.UserSer, tableService *service.TableSer) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 
+		table := tableService.Default()
+
+		if sender := r.URL.Query().Get("table"); sender != "" {
+			var err error
+
+			if table, err = tableService.Get(sender); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
 		var request dashboardUserRequest
 
 		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -48,6 +60,7 @@ func Post(userService *service.UserSer) func(w http.ResponseWriter, r *http.Requ
 			Email:              strings.ToLower(strings.TrimSpace(request.User.Email)),
 			FirstName:          strings.ToLower(strings.TrimSpace(request.User.FirstName)),
 			LastName:           strings.ToLower(strings.TrimSpace(request.User.LastName)),
+			Table:              table.Sender,
 		})
 
 		if err != nil {
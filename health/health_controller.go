@@ -0,0 +1,105 @@
+package health
+
+import (
+	"encoding/json"
+	"log"
+	"louie-web-administrator/service"
+	"net/http"
+)
+
+type healthResponse struct {
+	Status      string               `json:"status"`
+	Connections []connectionResponse `json:"connections,omitempty"`
+}
+
+type connectionResponse struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+	Since string `json:"since"`
+}
+
+// Live reports, that the administrator is running. It is meant as liveness probe.
+func Live() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeHealthResponse(w, http.StatusOK, healthResponse{Status: "up"})
+	}
+}
+
+// Ready reports, whether all connections of the message bus are established. It is meant as readiness probe
+// and answers with 503, while a consumer reconnects.
+func Ready(connectionService *service.ConnectionSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		connections := connectionService.GetConnections()
+		response := healthResponse{
+			Status:      "up",
+			Connections: make([]connectionResponse, 0, len(connections)),
+		}
+
+		for _, connection := range connections {
+			response.Connections = append(response.Connections, connectionResponse{
+				Name:  connection.Name,
+				State: connection.State.String(),
+				Error: connection.Error,
+				Since: connection.Since,
+			})
+		}
+
+		if !connectionService.IsConnected() {
+			response.Status = "down"
+			writeHealthResponse(w, http.StatusServiceUnavailable, response)
+			return
+		}
+
+		writeHealthResponse(w, http.StatusOK, response)
+	}
+}
+
+type queueResponse struct {
+	Name          string `json:"name"`
+	Capacity      int    `json:"capacity"`
+	Policy        string `json:"policy"`
+	Depth         int    `json:"depth"`
+	HighWatermark int    `json:"high_watermark"`
+	Dropped       int64  `json:"dropped"`
+	Rejected      int64  `json:"rejected"`
+}
+
+// Queues returns the depth and the overflow counters of the event queues.
+func Queues(queueService *service.QueueSer) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		stats := queueService.GetQueues()
+		response := make([]queueResponse, 0, len(stats))
+
+		for _, queue := range stats {
+			response = append(response, queueResponse{
+				Name:          queue.Name,
+				Capacity:      queue.Capacity,
+				Policy:        queue.Policy.String(),
+				Depth:         queue.Depth,
+				HighWatermark: queue.HighWatermark,
+				Dropped:       queue.Dropped,
+				Rejected:      queue.Rejected,
+			})
+		}
+
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("writing queue metrics failed %s\n", err)
+		}
+	}
+}
+
+func writeHealthResponse(w http.ResponseWriter, status int, response healthResponse) {
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("writing health response failed %s\n", err)
+	}
+}
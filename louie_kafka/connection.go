@@ -3,21 +3,30 @@ package louie_kafka
 import (
 	"context"
 	"github.com/segmentio/kafka-go"
-	"log"
 	"time"
 )
 
-func newKafkaConnection(ctx context.Context, serverAddress string, topic string) *kafka.Conn {
-	conn, err := kafka.DialLeader(ctx, "tcp", serverAddress, topic, 0)
+const dialTimeout = 10 * time.Second
+
+func readPartitions(ctx context.Context, serverAddress string, topic string) ([]kafka.Partition, error) {
+
+	dialer := &kafka.Dialer{Timeout: dialTimeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", serverAddress)
 	if err != nil {
-		log.Fatal("failed to create kafka connection:", err)
+		return nil, err
 	}
+	defer conn.Close()
 
-	err = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return conn.ReadPartitions(topic)
+}
 
+func readLastOffset(ctx context.Context, serverAddress string, topic string, partition int) (int64, error) {
+	conn, err := kafka.DialLeader(ctx, "tcp", serverAddress, topic, partition)
 	if err != nil {
-		log.Fatal("failed to define write deadline for kafka connection:", err)
+		return 0, err
 	}
+	defer conn.Close()
 
-	return conn
+	return conn.ReadLastOffset()
 }
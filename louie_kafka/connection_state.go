@@ -0,0 +1,49 @@
+package louie_kafka
+
+import "time"
+
+type ConnectionState string
+
+const (
+	ConnectionConnecting   ConnectionState = "connecting"
+	ConnectionConnected    ConnectionState = "connected"
+	ConnectionReconnecting ConnectionState = "reconnecting"
+	ConnectionStopped      ConnectionState = "stopped"
+)
+
+func (c ConnectionState) String() string {
+	return string(c)
+}
+
+// ConnectionObserver is told about every change of a connection to the broker. The name identifies the
+// connection, e.g. the consumed kafka topic.
+type ConnectionObserver interface {
+	ConnectionChanged(name string, state ConnectionState, err error)
+}
+
+// ConnectionReporter is implemented by message buses, which report the state of their broker connections.
+type ConnectionReporter interface {
+	ObserveConnection(observer ConnectionObserver)
+}
+
+// Backoff defines the waiting time between two reconnect attempts. The time is doubled with every failed
+// attempt, starting with Min up to Max.
+type Backoff struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+func (b Backoff) next(current time.Duration) time.Duration {
+
+	if current < b.Min {
+		return b.Min
+	}
+
+	next := 2 * current
+
+	if next > b.Max {
+		return b.Max
+	}
+
+	return next
+}
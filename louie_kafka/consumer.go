@@ -2,70 +2,274 @@ package louie_kafka
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"github.com/segmentio/kafka-go"
-	"github.com/thoas/go-funk"
 	"log"
+	"sync"
+	"time"
 )
 
 type ConsumerConfig struct {
-	Quit                chan bool
-	GameEvents          chan kafka.Message
-	TechnicalEvents     chan kafka.Message
-	ServerAddress       string
-	Topic               string
-	TechnicalEventTypes []EventType
+	Router        *Router
+	ServerAddress string
+	Topic         string
+	GroupId       string
+	StartFromTail bool
+	Backoff       Backoff
+	// HealthCheckInterval is the interval the broker is checked while consuming. The reader of kafka-go
+	// retries internally and does not report a lost broker.
+	HealthCheckInterval time.Duration
+	// Observer is told about every change of the connection. Optional.
+	Observer ConnectionObserver
+	// tailOffsets are the next offsets per partition, so a reconnected tail consumer continues where it stopped.
+	tailOffsets map[int]int64
+	mutex       sync.Mutex
 }
 
+// StartConsumer reads the louie topic as member of the configured consumer group and commits the
+// offsets after processing. With StartFromTail the committed offsets are ignored and every partition
+// is read from the latest offset without committing anything.
+//
+// A failed connection to the broker is reconnected with an exponential backoff. StartConsumer returns,
+// when the context is cancelled and the message in progress is processed.
 func (consumerConfig *ConsumerConfig) StartConsumer(ctx context.Context) {
 
-	r := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:   []string{consumerConfig.ServerAddress},
-		Topic:     consumerConfig.Topic,
-		Partition: 0,
-		MaxBytes:  10e6, // 10MB
-	})
-	err := r.SetOffset(kafka.LastOffset)
+	wait := consumerConfig.Backoff.Min
+
+	consumerConfig.changeState(ConnectionConnecting, nil)
+
+	for {
+		connected, err := consumerConfig.consumeUntilFailure(ctx)
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		if connected {
+			wait = consumerConfig.Backoff.Min
+		}
+
+		log.Printf("kafka consumer of %s failed, reconnecting in %s: %s\n", consumerConfig.Topic, wait, err)
+		consumerConfig.changeState(ConnectionReconnecting, err)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		wait = consumerConfig.Backoff.next(wait)
+	}
+
+	consumerConfig.changeState(ConnectionStopped, nil)
+	log.Printf("terminate kafka consumer of %s\n", consumerConfig.Topic)
+}
+
+// consumeUntilFailure connects the readers and consumes until the broker is lost or the context is cancelled.
+// It reports, whether the connection was established before the failure.
+func (consumerConfig *ConsumerConfig) consumeUntilFailure(ctx context.Context) (bool, error) {
+
+	partitions, err := readPartitions(ctx, consumerConfig.ServerAddress, consumerConfig.Topic)
 
 	if err != nil {
-		log.Fatal("defining last offset of kafka reader failed:", err)
+		return false, fmt.Errorf("reading partitions of kafka topic failed: %w", err)
 	}
 
-	go func() {
-		for {
-			select {
-			case <-consumerConfig.Quit:
-				if err := r.Close(); err != nil {
-					log.Fatal("failed to close reader:", err)
-				}
-				log.Print("terminate kafka consumer")
-				return // terminate go function
+	readers, err := consumerConfig.newReaders(partitions)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		for _, r := range readers {
+			if err := r.Close(); err != nil {
+				log.Printf("failed to close reader: %s\n", err)
 			}
 		}
 	}()
 
+	consumerConfig.changeState(ConnectionConnected, nil)
+
+	sessionCtx, cancelSession := context.WithCancelCause(ctx)
+	defer cancelSession(nil)
+
+	go consumerConfig.checkBroker(sessionCtx, cancelSession)
+
+	var wg sync.WaitGroup
+
+	for _, r := range readers {
+		wg.Add(1)
+		go func(r *kafka.Reader) {
+			defer wg.Done()
+			cancelSession(consumerConfig.consume(ctx, sessionCtx, r))
+		}(r)
+	}
+
+	wg.Wait()
+
+	return true, context.Cause(sessionCtx)
+}
+
+func (consumerConfig *ConsumerConfig) newReaders(partitions []kafka.Partition) ([]*kafka.Reader, error) {
+
+	if !consumerConfig.StartFromTail {
+		return []*kafka.Reader{kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     []string{consumerConfig.ServerAddress},
+			GroupID:     consumerConfig.GroupId,
+			Topic:       consumerConfig.Topic,
+			StartOffset: kafka.FirstOffset,
+			MaxBytes:    10e6, // 10MB
+		})}, nil
+	}
+
+	readers := make([]*kafka.Reader, 0, len(partitions))
+
+	for _, partition := range partitions {
+		r := kafka.NewReader(kafka.ReaderConfig{
+			Brokers:   []string{consumerConfig.ServerAddress},
+			Topic:     consumerConfig.Topic,
+			Partition: partition.ID,
+			MaxBytes:  10e6, // 10MB
+		})
+
+		if err := r.SetOffset(consumerConfig.tailOffset(partition.ID)); err != nil {
+			return nil, fmt.Errorf("defining offset of kafka reader failed: %w", err)
+		}
+
+		readers = append(readers, r)
+	}
+
+	return readers, nil
+}
+
+// checkBroker cancels the session, if the broker is not reachable anymore.
+func (consumerConfig *ConsumerConfig) checkBroker(sessionCtx context.Context, cancelSession context.CancelCauseFunc) {
+
+	if consumerConfig.HealthCheckInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(consumerConfig.HealthCheckInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
-		case <-consumerConfig.Quit:
-			return // terminate go function
-		default: // otherwise read messages
-			m, err := r.ReadMessage(ctx)
-			if err != nil {
-				log.Fatal("reading messages failed:", err)
-				return
-			}
-			log.Printf("consumed kafka message: %s:%s", m.Key, m.Value)
+		case <-sessionCtx.Done():
+			return
+		case <-ticker.C:
+		}
 
-			var tmpReceivedEvent DefaultEvent
-			_ = json.Unmarshal(m.Value, &tmpReceivedEvent)
+		if _, err := readPartitions(sessionCtx, consumerConfig.ServerAddress, consumerConfig.Topic); err != nil && sessionCtx.Err() == nil {
+			cancelSession(fmt.Errorf("kafka broker not reachable: %w", err))
+			return
+		}
+	}
+}
 
-			if funk.Contains(getTechnicalEventTypes(), tmpReceivedEvent.Event) {
-				consumerConfig.TechnicalEvents <- m
-			} else if funk.Contains(getGameEventTypes(), tmpReceivedEvent.Event) {
-				consumerConfig.GameEvents <- m
-			} else {
-				log.Printf("can not assign a technical or game event")
-			}
+// maxPendingCommits bounds the fetched messages, which wait for their processing. The consumer stalls,
+// if the handlers fall behind.
+const maxPendingCommits = 1000
+
+type pendingCommit struct {
+	message   kafka.Message
+	processed <-chan struct{}
+}
+
+// consume fetches the messages until the session ends. The messages are dispatched to the queues of the
+// handlers without waiting for the processing, the offsets are committed in order after the processing. The
+// routing uses the context of the consumer, so a dispatched message is processed, even if the session is cancelled.
+func (consumerConfig *ConsumerConfig) consume(ctx context.Context, sessionCtx context.Context, r *kafka.Reader) error {
+
+	commits := make(chan pendingCommit, maxPendingCommits)
+	committed := make(chan struct{})
+
+	go func() {
+		defer close(committed)
+		consumerConfig.commitProcessed(ctx, r, commits)
+	}()
+
+	defer func() {
+		close(commits)
+		<-committed
+	}()
+
+	for {
+		m, err := r.FetchMessage(sessionCtx)
+		if err != nil {
+			return fmt.Errorf("reading messages stopped: %w", err)
+		}
+		log.Printf("consumed kafka message: %s:%s", m.Key, m.Value)
+
+		_, processed := consumerConfig.Router.Dispatch(ctx, Message{Topic: m.Topic, Key: m.Key, Value: m.Value, Time: m.Time})
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if consumerConfig.StartFromTail {
+			consumerConfig.setTailOffset(m.Partition, m.Offset+1)
+			continue
+		}
+
+		select {
+		case commits <- pendingCommit{message: m, processed: processed}:
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
+
+// commitProcessed commits the offsets in the order the messages were fetched. After the context is cancelled
+// nothing is committed anymore, the interrupted messages are consumed again after the restart.
+func (consumerConfig *ConsumerConfig) commitProcessed(ctx context.Context, r *kafka.Reader, commits <-chan pendingCommit) {
+
+	for commit := range commits {
+
+		select {
+		case <-commit.processed:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			continue
+		}
+
+		if err := r.CommitMessages(ctx, commit.message); err != nil {
+			log.Printf("committing offset %d of partition %d failed: %s\n", commit.message.Offset, commit.message.Partition, err)
+		}
+	}
+}
+
+func (consumerConfig *ConsumerConfig) tailOffset(partition int) int64 {
+
+	consumerConfig.mutex.Lock()
+	defer consumerConfig.mutex.Unlock()
+
+	if offset, ok := consumerConfig.tailOffsets[partition]; ok {
+		return offset
+	}
+
+	return kafka.LastOffset
+}
+
+func (consumerConfig *ConsumerConfig) setTailOffset(partition int, offset int64) {
+
+	consumerConfig.mutex.Lock()
+	defer consumerConfig.mutex.Unlock()
+
+	if consumerConfig.tailOffsets == nil {
+		consumerConfig.tailOffsets = make(map[int]int64)
+	}
+
+	consumerConfig.tailOffsets[partition] = offset
+}
+
+func (consumerConfig *ConsumerConfig) changeState(state ConnectionState, err error) {
+	if consumerConfig.Observer != nil {
+		consumerConfig.Observer.ConnectionChanged(consumerConfig.Topic, state, err)
+	}
+}
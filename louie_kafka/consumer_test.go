@@ -0,0 +1,79 @@
+package louie_kafka
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingObserver struct {
+	states []ConnectionState
+	mutex  sync.Mutex
+}
+
+func (observer *recordingObserver) ConnectionChanged(name string, state ConnectionState, err error) {
+	observer.mutex.Lock()
+	defer observer.mutex.Unlock()
+
+	observer.states = append(observer.states, state)
+}
+
+func (observer *recordingObserver) recordedStates() []ConnectionState {
+	observer.mutex.Lock()
+	defer observer.mutex.Unlock()
+
+	return append([]ConnectionState{}, observer.states...)
+}
+
+func Test_Backoff_DoublesUpToMax(t *testing.T) {
+
+	backoff := Backoff{Min: time.Second, Max: 5 * time.Second}
+
+	assert.Equal(t, time.Second, backoff.next(0))
+	assert.Equal(t, 2*time.Second, backoff.next(time.Second))
+	assert.Equal(t, 4*time.Second, backoff.next(2*time.Second))
+	assert.Equal(t, 5*time.Second, backoff.next(4*time.Second))
+	assert.Equal(t, 5*time.Second, backoff.next(5*time.Second))
+}
+
+func Test_StartConsumer_ReconnectsUntilCancelled(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	observer := &recordingObserver{}
+
+	consumerConfig := &ConsumerConfig{
+		Router:        &Router{},
+		ServerAddress: "127.0.0.1:1", // nothing listens here
+		Topic:         "LOUIE_EVENT",
+		Backoff:       Backoff{Min: 5 * time.Millisecond, Max: 20 * time.Millisecond},
+		Observer:      observer,
+	}
+
+	stopped := make(chan struct{})
+
+	go func() {
+		consumerConfig.StartConsumer(ctx)
+		close(stopped)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return len(observer.recordedStates()) >= 3
+	}, 5*time.Second, 5*time.Millisecond)
+
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("consumer did not stop after cancel")
+	}
+
+	states := observer.recordedStates()
+
+	assert.Equal(t, ConnectionConnecting, states[0])
+	assert.Equal(t, ConnectionReconnecting, states[1])
+	assert.Equal(t, ConnectionStopped, states[len(states)-1])
+	assert.NotContains(t, states, ConnectionConnected)
+}
@@ -0,0 +1,30 @@
+package louie_kafka
+
+type DeadLetterReason string
+
+const (
+	ParseError             DeadLetterReason = "parse_error"
+	UnknownEventType       DeadLetterReason = "unknown_event_type"
+	SchemaViolation        DeadLetterReason = "schema_violation"
+	InvalidStateTransition DeadLetterReason = "invalid_state_transition"
+	GameMismatch           DeadLetterReason = "game_mismatch"
+	QueueOverflow          DeadLetterReason = "queue_overflow"
+	UnknownSender          DeadLetterReason = "unknown_sender"
+)
+
+func (c DeadLetterReason) String() string {
+	return string(c)
+}
+
+// DeadLetterSink receives all consumed messages, which can not be processed.
+type DeadLetterSink interface {
+	Quarantine(message []byte, reason DeadLetterReason, detail string)
+}
+
+// DeadLetterEvent is published to the dead letter topic.
+type DeadLetterEvent struct {
+	Reason    DeadLetterReason `json:"reason"`
+	Detail    string           `json:"detail"`
+	Timestamp string           `json:"timestamp"`
+	Payload   string           `json:"payload"`
+}
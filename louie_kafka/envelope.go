@@ -0,0 +1,188 @@
+package louie_kafka
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/xeipuuv/gojsonschema"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// LegacyVersion marks events in the old flat format of the c-library and jan-ki-magic, which
+	// have no envelope. The whole message is treated as payload.
+	LegacyVersion   = 0
+	EnvelopeVersion = 1
+)
+
+var ErrSchemaViolation = errors.New("event does not match schema")
+
+//go:embed schema/*.json
+var schemaFiles embed.FS
+
+var (
+	envelopeSchema = mustLoadSchema("envelope")
+	payloadSchemas = mustLoadPayloadSchemas()
+)
+
+// Envelope wraps every louie event. Id, sender and timestamp are empty for legacy events.
+type Envelope struct {
+	Id        string          `json:"id"`
+	Version   int             `json:"version"`
+	Event     EventType       `json:"event"`
+	Sender    string          `json:"sender"`
+	Timestamp string          `json:"timestamp"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// DecodeEnvelope reads a versioned envelope. Messages without a version are read as legacy events.
+func DecodeEnvelope(message []byte) (*Envelope, error) {
+
+	var probe struct {
+		Version *int      `json:"version"`
+		Event   EventType `json:"event"`
+		Sender  string    `json:"sender"`
+	}
+
+	if err := json.Unmarshal(message, &probe); err != nil {
+		return nil, err
+	}
+
+	if probe.Version == nil {
+		return &Envelope{
+			Version: LegacyVersion,
+			Event:   probe.Event,
+			Sender:  probe.Sender,
+			Payload: message,
+		}, nil
+	}
+
+	var envelope Envelope
+
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		return nil, err
+	}
+
+	return &envelope, nil
+}
+
+// EventId identifies an event for the de-duplication. Versioned events are identified by the envelope
+// id. Legacy events have no id, they are identified by a hash of the whole message.
+func EventId(message []byte) (string, error) {
+
+	envelope, err := DecodeEnvelope(message)
+
+	if err != nil {
+		return "", err
+	}
+
+	if envelope.Id != "" {
+		return envelope.Id, nil
+	}
+
+	hash := sha256.Sum256(message)
+
+	return fmt.Sprintf("sha256:%s", hex.EncodeToString(hash[:])), nil
+}
+
+// UnmarshalPayload decodes the payload of a versioned or legacy event into v.
+func UnmarshalPayload(message []byte, v interface{}) error {
+
+	envelope, err := DecodeEnvelope(message)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(envelope.Payload, v)
+}
+
+// Validate checks the envelope and the payload against the published json schemas in louie_kafka/schema.
+func Validate(message []byte) error {
+
+	envelope, err := DecodeEnvelope(message)
+
+	if err != nil {
+		return err
+	}
+
+	if envelope.Version != LegacyVersion {
+		if err := validateAgainst(envelopeSchema, message); err != nil {
+			return err
+		}
+	}
+
+	payloadSchema, ok := payloadSchemas[envelope.Event]
+
+	if !ok {
+		return fmt.Errorf("%w: no schema for event type \"%s\"", ErrSchemaViolation, envelope.Event)
+	}
+
+	return validateAgainst(payloadSchema, envelope.Payload)
+}
+
+// NewEnvelopeMessage wraps a legacy payload into a versioned envelope.
+func NewEnvelopeMessage(id string, event EventType, sender string, timestamp time.Time, payload []byte) ([]byte, error) {
+	return json.Marshal(Envelope{
+		Id:        id,
+		Version:   EnvelopeVersion,
+		Event:     event,
+		Sender:    sender,
+		Timestamp: timestamp.UTC().Format(time.RFC3339),
+		Payload:   payload,
+	})
+}
+
+func validateAgainst(schema *gojsonschema.Schema, document []byte) error {
+
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(document))
+
+	if err != nil {
+		return err
+	}
+
+	if result.Valid() {
+		return nil
+	}
+
+	violations := make([]string, 0, len(result.Errors()))
+
+	for _, resultError := range result.Errors() {
+		violations = append(violations, resultError.String())
+	}
+
+	return fmt.Errorf("%w: %s", ErrSchemaViolation, strings.Join(violations, "; "))
+}
+
+func mustLoadPayloadSchemas() map[EventType]*gojsonschema.Schema {
+
+	schemas := make(map[EventType]*gojsonschema.Schema)
+
+	for _, eventType := range getAllEventTypes() {
+		schemas[eventType] = mustLoadSchema(eventType.String())
+	}
+
+	return schemas
+}
+
+func mustLoadSchema(name string) *gojsonschema.Schema {
+
+	schemaFile, err := schemaFiles.ReadFile(fmt.Sprintf("schema/%s.json", name))
+
+	if err != nil {
+		log.Fatalf("can not read json schema %s: %s", name, err)
+	}
+
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schemaFile))
+
+	if err != nil {
+		log.Fatalf("can not load json schema %s: %s", name, err)
+	}
+
+	return schema
+}
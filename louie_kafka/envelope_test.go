@@ -0,0 +1,82 @@
+package louie_kafka
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_DecodeEnvelope_Legacy(t *testing.T) {
+
+	message := []byte(`{"event":"COIN_DROP","sender":"c-library","name":"tobi","coins":2}`)
+
+	envelope, err := DecodeEnvelope(message)
+
+	assert.Nil(t, err)
+	assert.Equal(t, LegacyVersion, envelope.Version)
+	assert.Equal(t, CoinDrop, envelope.Event)
+	assert.Equal(t, "c-library", envelope.Sender)
+
+	var coinDropEvent CoinDropEvent
+
+	assert.Nil(t, UnmarshalPayload(message, &coinDropEvent))
+	assert.Equal(t, CoinDropEvent{Event: CoinDrop, Sender: "c-library", Name: "tobi", Coins: 2}, coinDropEvent)
+}
+
+func Test_DecodeEnvelope_Versioned(t *testing.T) {
+
+	message, _ := NewEnvelopeMessage("42", GameDone, "c-library", time.Now(), []byte(`{"duration":12.5,"winning_player":{"name":"tobi"}}`))
+
+	envelope, err := DecodeEnvelope(message)
+
+	assert.Nil(t, err)
+	assert.Equal(t, EnvelopeVersion, envelope.Version)
+	assert.Equal(t, "42", envelope.Id)
+	assert.Equal(t, GameDone, envelope.Event)
+
+	var gameDoneEvent GameDoneEvent
+
+	assert.Nil(t, UnmarshalPayload(message, &gameDoneEvent))
+	assert.Equal(t, 12.5, gameDoneEvent.Duration)
+	assert.Equal(t, "tobi", gameDoneEvent.WinningPlayer.Name)
+	assert.Nil(t, Validate(message))
+}
+
+func Test_Validate_Legacy(t *testing.T) {
+
+	assert.Nil(t, Validate([]byte(`{"event":"GAME_DONE","sender":"c-library","duration":30,"winning_player":{"name":"tobi"}}`)))
+	assert.Nil(t, Validate([]byte(`{"event":"PLAYERS_CONFIRM"}`)))
+}
+
+func Test_Validate_InvalidPayload(t *testing.T) {
+
+	negativeDuration := Validate([]byte(`{"event":"GAME_DONE","duration":-1,"winning_player":{"name":"tobi"}}`))
+	missingName := Validate([]byte(`{"event":"COIN_DROP","coins":2}`))
+
+	assert.True(t, errors.Is(negativeDuration, ErrSchemaViolation))
+	assert.True(t, errors.Is(missingName, ErrSchemaViolation))
+}
+
+func Test_Validate_InvalidEnvelope(t *testing.T) {
+
+	err := Validate([]byte(`{"version":1,"event":"PLAYERS_CONFIRM","payload":{}}`))
+
+	assert.True(t, errors.Is(err, ErrSchemaViolation))
+}
+
+func Test_EventId(t *testing.T) {
+
+	versioned := []byte(`{"id":"65a1","version":1,"event":"COIN_DROP","sender":"c-library","timestamp":"2024-01-12T10:15:00Z","payload":{"name":"tobi","coins":2}}`)
+	legacy := []byte(`{"event":"COIN_DROP","sender":"c-library","name":"tobi","coins":2}`)
+	otherLegacy := []byte(`{"event":"COIN_DROP","sender":"c-library","name":"tobi","coins":1}`)
+
+	versionedId, _ := EventId(versioned)
+	legacyId, _ := EventId(legacy)
+	sameLegacyId, _ := EventId(legacy)
+	otherLegacyId, _ := EventId(otherLegacy)
+
+	assert.Equal(t, "65a1", versionedId)
+	assert.Equal(t, legacyId, sameLegacyId)
+	assert.NotEqual(t, legacyId, otherLegacyId)
+}
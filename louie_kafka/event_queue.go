@@ -0,0 +1,284 @@
+package louie_kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+type OverflowPolicy string
+
+const (
+	// OverflowBlock waits until the handler took a message, at most BlockTimeout. The consumer stalls meanwhile, so
+	// the message is rejected afterwards.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldestCoinDrop replaces the oldest COIN_DROP of the queue. A COIN_DROP contains the remaining
+	// coins of the player, so a newer one supersedes it. Without a queued COIN_DROP the message is rejected, so the
+	// consumer never stalls.
+	OverflowDropOldestCoinDrop OverflowPolicy = "drop_oldest_coin_drop"
+	// OverflowReject stores the message as dead letter with the reason queue_overflow.
+	OverflowReject OverflowPolicy = "reject"
+)
+
+func (o OverflowPolicy) String() string {
+	return string(o)
+}
+
+func ParseOverflowPolicy(value string) (OverflowPolicy, error) {
+	for _, policy := range []OverflowPolicy{OverflowBlock, OverflowDropOldestCoinDrop, OverflowReject} {
+		if value == policy.String() {
+			return policy, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown overflow policy \"%s\"", value)
+}
+
+var ErrQueueFull = errors.New("event queue is full")
+
+type QueueConfig struct {
+	Capacity int
+	Policy   OverflowPolicy
+	// BlockTimeout limits the wait of the block policy. A timeout of 0 waits until the handler took a message.
+	BlockTimeout time.Duration
+}
+
+// QueueStats are the metrics of an event queue. The depth includes the message, which is handed over to the handler.
+type QueueStats struct {
+	Name          string
+	Capacity      int
+	Policy        OverflowPolicy
+	Depth         int
+	HighWatermark int
+	Dropped       int64
+	Rejected      int64
+}
+
+type queuedMessage struct {
+	event   EventType
+	message ConsumedMessage
+}
+
+// EventQueue is a bounded queue in front of an event handler. The queue hands over the messages in order.
+type EventQueue struct {
+	name          string
+	config        QueueConfig
+	out           chan<- ConsumedMessage
+	items         []queuedMessage
+	inFlight      int
+	highWatermark int
+	dropped       int64
+	rejected      int64
+	notEmpty      chan struct{}
+	notFull       chan struct{}
+	mutex         sync.Mutex
+}
+
+func NewEventQueue(name string, config QueueConfig, out chan<- ConsumedMessage) *EventQueue {
+	return &EventQueue{
+		name:     name,
+		config:   config,
+		out:      out,
+		items:    make([]queuedMessage, 0, config.Capacity),
+		notEmpty: make(chan struct{}, 1),
+		notFull:  make(chan struct{}, 1),
+	}
+}
+
+// Push appends the message or applies the overflow policy, if the queue is full. ErrQueueFull is returned for
+// rejected messages.
+func (q *EventQueue) Push(ctx context.Context, event EventType, message ConsumedMessage) error {
+
+	var blockTimeout <-chan time.Time
+
+	if q.config.Policy == OverflowBlock && q.config.BlockTimeout > 0 {
+		timer := time.NewTimer(q.config.BlockTimeout)
+		defer timer.Stop()
+		blockTimeout = timer.C
+	}
+
+	for {
+		q.mutex.Lock()
+
+		if len(q.items)+q.inFlight < q.config.Capacity {
+			q.append(event, message)
+			q.mutex.Unlock()
+			return nil
+		}
+
+		switch q.config.Policy {
+		case OverflowReject:
+			return q.reject(event)
+
+		case OverflowDropOldestCoinDrop:
+			dropped, ok := q.removeOldestCoinDrop()
+
+			if !ok {
+				// waiting for the handler would stall the consumer and the side changes with it.
+				return q.reject(event)
+			}
+
+			q.dropped++
+			q.append(event, message)
+			q.mutex.Unlock()
+
+			log.Printf("event queue %s is full, dropped oldest %s\n", q.name, CoinDrop)
+			dropped.SetResult(ProcessingResult{Outcome: OutcomeDropped, Detail: fmt.Sprintf("queue %s is full", q.name)})
+			dropped.Done()
+			return nil
+		}
+
+		q.mutex.Unlock()
+
+		select {
+		case <-q.notFull:
+		case <-blockTimeout:
+			q.mutex.Lock()
+			return q.reject(event)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// reject counts the rejected message and unlocks the queue, which must be locked by the caller.
+func (q *EventQueue) reject(event EventType) error {
+
+	q.rejected++
+	q.mutex.Unlock()
+
+	log.Printf("event queue %s is full, rejecting %s\n", q.name, event)
+	return ErrQueueFull
+}
+
+func (q *EventQueue) append(event EventType, message ConsumedMessage) {
+
+	q.items = append(q.items, queuedMessage{event: event, message: message})
+
+	if depth := len(q.items) + q.inFlight; depth > q.highWatermark {
+		q.highWatermark = depth
+	}
+
+	signal(q.notEmpty)
+}
+
+func (q *EventQueue) removeOldestCoinDrop() (ConsumedMessage, bool) {
+
+	for i, item := range q.items {
+		if item.event == CoinDrop {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			return item.message, true
+		}
+	}
+
+	return ConsumedMessage{}, false
+}
+
+// Run hands over the queued messages to the handler until the context is cancelled. Messages left in the queue
+// are not processed, they are not committed and consumed again after the restart.
+func (q *EventQueue) Run(ctx context.Context) {
+
+	for {
+		q.mutex.Lock()
+
+		if len(q.items) == 0 {
+			q.mutex.Unlock()
+
+			select {
+			case <-q.notEmpty:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		next := q.items[0]
+		q.items = q.items[1:]
+		q.inFlight = 1
+		q.mutex.Unlock()
+
+		select {
+		case q.out <- next.message:
+		case <-ctx.Done():
+			return
+		}
+
+		q.mutex.Lock()
+		q.inFlight = 0
+		q.mutex.Unlock()
+
+		signal(q.notFull)
+	}
+}
+
+func (q *EventQueue) Stats() QueueStats {
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return QueueStats{
+		Name:          q.name,
+		Capacity:      q.config.Capacity,
+		Policy:        q.config.Policy,
+		Depth:         len(q.items) + q.inFlight,
+		HighWatermark: q.highWatermark,
+		Dropped:       q.dropped,
+		Rejected:      q.rejected,
+	}
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// EventQueues separate the game events from the technical events, so a burst of COIN_DROP events never delays
+// a side change.
+type EventQueues struct {
+	Game      *EventQueue
+	Technical *EventQueue
+	wg        sync.WaitGroup
+}
+
+func NewEventQueues(gameEvents chan<- ConsumedMessage, game QueueConfig, technicalEvents chan<- ConsumedMessage, technical QueueConfig) *EventQueues {
+	return &EventQueues{
+		Game:      NewEventQueue("game", game, gameEvents),
+		Technical: NewEventQueue("technical", technical, technicalEvents),
+	}
+}
+
+// Run hands over the messages of all queues in the background until the context is cancelled.
+func (queues *EventQueues) Run(ctx context.Context) {
+	for _, queue := range queues.all() {
+		queues.wg.Add(1)
+		go func(queue *EventQueue) {
+			defer queues.wg.Done()
+			queue.Run(ctx)
+		}(queue)
+	}
+}
+
+// Wait blocks until all queues stopped. Afterwards the handler channels can be closed.
+func (queues *EventQueues) Wait() {
+	queues.wg.Wait()
+}
+
+func (queues *EventQueues) Stats() []QueueStats {
+
+	stats := make([]QueueStats, 0, 2)
+
+	for _, queue := range queues.all() {
+		stats = append(stats, queue.Stats())
+	}
+
+	return stats
+}
+
+func (queues *EventQueues) all() []*EventQueue {
+	return []*EventQueue{queues.Game, queues.Technical}
+}
@@ -0,0 +1,150 @@
+package louie_kafka
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newConsumedMessage(value string) ConsumedMessage {
+	return ConsumedMessage{
+		Message: Message{Value: []byte(value)},
+		done:    make(chan struct{}),
+		result:  &ProcessingResult{Outcome: OutcomeProcessed},
+	}
+}
+
+func Test_EventQueue_DropsOldestCoinDrop(t *testing.T) {
+
+	queue := NewEventQueue("game", QueueConfig{Capacity: 2, Policy: OverflowDropOldestCoinDrop}, make(chan ConsumedMessage))
+
+	confirm := newConsumedMessage(`{"event":"PLAYERS_CONFIRM"}`)
+	firstCoinDrop := newConsumedMessage(`{"event":"COIN_DROP","coins":2}`)
+	secondCoinDrop := newConsumedMessage(`{"event":"COIN_DROP","coins":1}`)
+
+	assert.Nil(t, queue.Push(context.Background(), PlayersConfirm, confirm))
+	assert.Nil(t, queue.Push(context.Background(), CoinDrop, firstCoinDrop))
+	assert.Nil(t, queue.Push(context.Background(), CoinDrop, secondCoinDrop))
+
+	select {
+	case <-firstCoinDrop.done:
+	default:
+		t.Fatal("oldest coin drop was not dropped")
+	}
+
+	assert.Equal(t, OutcomeDropped, firstCoinDrop.result.Outcome)
+
+	stats := queue.Stats()
+
+	assert.Equal(t, 2, stats.Depth)
+	assert.Equal(t, int64(1), stats.Dropped)
+	assert.Equal(t, []ConsumedMessage{confirm, secondCoinDrop}, []ConsumedMessage{queue.items[0].message, queue.items[1].message})
+}
+
+func Test_EventQueue_RejectsWithoutCoinDropToDrop(t *testing.T) {
+
+	queue := NewEventQueue("game", QueueConfig{Capacity: 2, Policy: OverflowDropOldestCoinDrop}, make(chan ConsumedMessage))
+
+	assert.Nil(t, queue.Push(context.Background(), PlayersConfirm, newConsumedMessage(`{"event":"PLAYERS_CONFIRM"}`)))
+	assert.Nil(t, queue.Push(context.Background(), GameDone, newConsumedMessage(`{"event":"GAME_DONE"}`)))
+
+	pushed := make(chan error, 1)
+
+	go func() {
+		pushed <- queue.Push(context.Background(), ResetGame, newConsumedMessage(`{"event":"RESET_GAME"}`))
+	}()
+
+	select {
+	case err := <-pushed:
+		assert.ErrorIs(t, err, ErrQueueFull)
+	case <-time.After(time.Second):
+		t.Fatal("push blocked the consumer")
+	}
+
+	stats := queue.Stats()
+
+	assert.Equal(t, 2, stats.Depth)
+	assert.Equal(t, int64(1), stats.Rejected)
+	assert.Equal(t, int64(0), stats.Dropped)
+}
+
+func Test_EventQueue_RejectsIfFull(t *testing.T) {
+
+	queue := NewEventQueue("technical", QueueConfig{Capacity: 1, Policy: OverflowReject}, make(chan ConsumedMessage))
+
+	assert.Nil(t, queue.Push(context.Background(), PleaseChangeSide, newConsumedMessage(`{"event":"PLZ_CHANGE_SIDE"}`)))
+	assert.ErrorIs(t, queue.Push(context.Background(), PleaseChangeSide, newConsumedMessage(`{"event":"PLZ_CHANGE_SIDE"}`)), ErrQueueFull)
+	assert.Equal(t, int64(1), queue.Stats().Rejected)
+}
+
+func Test_EventQueue_BlocksUntilHandlerTakesMessage(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out := make(chan ConsumedMessage)
+	queue := NewEventQueue("game", QueueConfig{Capacity: 1, Policy: OverflowBlock}, out)
+
+	assert.Nil(t, queue.Push(ctx, GameDone, newConsumedMessage(`{"event":"GAME_DONE"}`)))
+
+	timeoutCtx, cancelTimeout := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancelTimeout()
+
+	assert.ErrorIs(t, queue.Push(timeoutCtx, PlayersConfirm, newConsumedMessage(`{"event":"PLAYERS_CONFIRM"}`)), context.DeadlineExceeded)
+
+	go queue.Run(ctx)
+
+	assert.Equal(t, `{"event":"GAME_DONE"}`, string((<-out).Value))
+	assert.Nil(t, queue.Push(ctx, PlayersConfirm, newConsumedMessage(`{"event":"PLAYERS_CONFIRM"}`)))
+	assert.Equal(t, `{"event":"PLAYERS_CONFIRM"}`, string((<-out).Value))
+}
+
+func Test_EventQueue_BlockRejectsAfterTimeout(t *testing.T) {
+
+	queue := NewEventQueue("technical", QueueConfig{Capacity: 1, Policy: OverflowBlock, BlockTimeout: 20 * time.Millisecond}, make(chan ConsumedMessage))
+
+	assert.Nil(t, queue.Push(context.Background(), PleaseChangeSide, newConsumedMessage(`{"event":"PLZ_CHANGE_SIDE"}`)))
+	assert.ErrorIs(t, queue.Push(context.Background(), PleaseChangeSide, newConsumedMessage(`{"event":"PLZ_CHANGE_SIDE"}`)), ErrQueueFull)
+	assert.Equal(t, int64(1), queue.Stats().Rejected)
+}
+
+func Test_Dispatch_CoinDropBurstDoesNotDelaySideChange(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gameEvents := make(chan ConsumedMessage)
+	technicalEvents := make(chan ConsumedMessage)
+	queues := NewEventQueues(
+		gameEvents, QueueConfig{Capacity: 5, Policy: OverflowDropOldestCoinDrop},
+		technicalEvents, QueueConfig{Capacity: 5, Policy: OverflowBlock},
+	)
+	queues.Run(ctx)
+
+	router := &Router{GameEvents: gameEvents, TechnicalEvents: technicalEvents, Queues: queues}
+
+	// the game handler is stuck, nobody reads the game events
+	for i := 0; i < 50; i++ {
+		routed, _ := router.Dispatch(ctx, Message{Value: []byte(`{"event":"COIN_DROP","sender":"c-library","name":"tobi","coins":1}`)})
+		assert.True(t, routed)
+	}
+
+	routed, processed := router.Dispatch(ctx, Message{Value: []byte(`{"event":"PLZ_CHANGE_SIDE","sender":"jan-ki-magic"}`)})
+
+	assert.True(t, routed)
+
+	select {
+	case sideChange := <-technicalEvents:
+		sideChange.Done()
+	case <-time.After(time.Second):
+		t.Fatal("side change was delayed by the coin drops")
+	}
+
+	<-processed
+
+	gameStats := queues.Game.Stats()
+
+	assert.Equal(t, 5, gameStats.Depth)
+	assert.Equal(t, int64(45), gameStats.Dropped)
+}
@@ -0,0 +1,53 @@
+package louie_kafka
+
+type Direction string
+
+const (
+	// DirectionInbound events are consumed by the administrator.
+	DirectionInbound Direction = "inbound"
+	// DirectionOutbound events are published by the administrator.
+	DirectionOutbound Direction = "outbound"
+)
+
+func (d Direction) String() string {
+	return string(d)
+}
+
+type Outcome string
+
+const (
+	OutcomeProcessed Outcome = "processed"
+	// OutcomeDuplicate is an event, which was already processed for the game (e.g. redelivered).
+	OutcomeDuplicate Outcome = "duplicate"
+	// OutcomeRejected is an event, which is stored as dead letter.
+	OutcomeRejected Outcome = "rejected"
+	// OutcomeDropped is a COIN_DROP, which was replaced by a newer one in a full queue.
+	OutcomeDropped   Outcome = "dropped"
+	OutcomePublished Outcome = "published"
+	// OutcomeFailed is an outbound event, which could not be published after all attempts.
+	OutcomeFailed Outcome = "failed"
+)
+
+func (o Outcome) String() string {
+	return string(o)
+}
+
+// ProcessingResult tells, what happened with an event. The game id is the game the event was applied to.
+type ProcessingResult struct {
+	Outcome Outcome
+	GameId  string
+	Detail  string
+}
+
+// RecordedEvent is a consumed or published louie event with the result of its processing.
+type RecordedEvent struct {
+	Direction Direction
+	Topic     string
+	Value     []byte
+	Result    ProcessingResult
+}
+
+// EventRecorder stores the consumed and published events as audit trail.
+type EventRecorder interface {
+	Record(event RecordedEvent)
+}
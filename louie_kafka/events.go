@@ -1,5 +1,7 @@
 package louie_kafka
 
+import "github.com/thoas/go-funk"
+
 type EventType string
 
 const (
@@ -12,18 +14,75 @@ const (
 	PleaseChangeSide     EventType = "PLZ_CHANGE_SIDE"
 	ConfirmedChangedSide EventType = "CONFIRMED_CHANGE_SIDE"
 	ResetGame            EventType = "RESET_GAME"
+	PauseGame            EventType = "PAUSE_GAME"
+	ResumeGame           EventType = "RESUME_GAME"
+	AbortGame            EventType = "ABORT_GAME"
+	Heartbeat            EventType = "HEARTBEAT"
 )
 
 func (c EventType) String() string {
 	return string(c)
 }
 
-func getTechnicalEventTypes() []EventType {
-	return []EventType{ConfirmedChangedSide, PleaseChangeSide}
+func getAllEventTypes() []EventType {
+	return []EventType{
+		PlayersCanBeReceived, PlayersReady, PlayersConfirm, GameDone, CoinDrop,
+		PleaseChangeSide, ConfirmedChangedSide, ResetGame, PauseGame, ResumeGame, AbortGame, Heartbeat,
+	}
+}
+
+// EventTypes returns all known louie events, e.g. for filters in the admin ui.
+func EventTypes() []EventType {
+	return getAllEventTypes()
+}
+
+// routing tables of the administrator. Inbound events are sent by louie or the ki to the administrator,
+// outbound events are sent by the administrator.
+
+func getInboundGameEventTypes() []EventType {
+	return []EventType{PlayersCanBeReceived, PlayersConfirm, GameDone, CoinDrop, ResetGame, PauseGame, ResumeGame}
+}
+
+func getInboundTechnicalEventTypes() []EventType {
+	return []EventType{PleaseChangeSide, Heartbeat}
+}
+
+func getOutboundGameEventTypes() []EventType {
+	return []EventType{PlayersReady, ResetGame, PauseGame, ResumeGame, AbortGame}
+}
+
+func getOutboundTechnicalEventTypes() []EventType {
+	return []EventType{ConfirmedChangedSide}
+}
+
+// getGameCorrelatedEventTypes are the inbound events, which echo the game id of PLAYERS_READY.
+func getGameCorrelatedEventTypes() []EventType {
+	return []EventType{PlayersConfirm, GameDone, CoinDrop, ResetGame, PauseGame, ResumeGame}
 }
 
-func getGameEventTypes() []EventType {
-	return []EventType{PlayersCanBeReceived, PlayersReady, PlayersConfirm, GameDone, CoinDrop}
+// IsInboundGameEvent reports whether the event changes the game state, if it is consumed.
+func IsInboundGameEvent(event EventType) bool {
+	return funk.Contains(getInboundGameEventTypes(), event)
+}
+
+// IsGameCorrelated reports whether louie echoes the game id of PLAYERS_READY with the event.
+func IsGameCorrelated(event EventType) bool {
+	return funk.Contains(getGameCorrelatedEventTypes(), event)
+}
+
+// GameIdOf returns the game id of a versioned or legacy event. The id is empty, if the sender does not
+// send it yet.
+func GameIdOf(message []byte) (string, error) {
+
+	var gameReference struct {
+		GameId string `json:"game_id"`
+	}
+
+	if err := UnmarshalPayload(message, &gameReference); err != nil {
+		return "", err
+	}
+
+	return gameReference.GameId, nil
 }
 
 type GameDoneEvent struct {
@@ -31,6 +90,7 @@ type GameDoneEvent struct {
 	Sender        string        `json:"sender"`
 	Duration      float64       `json:"duration"`
 	WinningPlayer winningPlayer `json:"winning_player"`
+	GameId        string        `json:"game_id,omitempty"`
 }
 
 type winningPlayer struct {
@@ -41,10 +101,21 @@ type PlayersReadyEvent struct {
 	Event     EventType           `json:"event"`
 	Players   []PlayerDisplayName `json:"players"`
 	Timestamp string              `json:"timestamp"`
+	// Seats is the number of human players and StartingCoins are the coins of every participant at the start.
+	Seats         int `json:"seats,omitempty"`
+	StartingCoins int `json:"starting_coins,omitempty"`
+	// WithoutKi tells louie, that the ki sits out. Without the flag, the ki plays as before.
+	WithoutKi bool `json:"without_ki,omitempty"`
+	// GameId has to be echoed by louie with PLAYERS_CONFIRM, COIN_DROP and GAME_DONE.
+	GameId string `json:"game_id"`
+	// Table is the sender of the louie table, the players are sent to.
+	Table string `json:"table,omitempty"`
 }
 
 type PlayerDisplayName struct {
 	DisplayName string `json:"display_name"`
+	// Seat is the position of the player at the table, starting with 1.
+	Seat int `json:"seat,omitempty"`
 }
 
 type CoinDropEvent struct {
@@ -52,15 +123,48 @@ type CoinDropEvent struct {
 	Sender string    `json:"sender"`
 	Name   string    `json:"name"`
 	Coins  int       `json:"coins"`
+	GameId string    `json:"game_id,omitempty"`
+}
+
+// HeartbeatEvent is sent periodically by louie and the ki to signal that they are online.
+type HeartbeatEvent struct {
+	Event  EventType `json:"event"`
+	Sender string    `json:"sender"`
 }
 
 type DefaultEvent struct {
 	Event EventType `json:"event"`
 }
 
-type resetGame struct {
+// ResetGameEvent moves the game back to "announced". It is sent by the administrator and by louie.
+type ResetGameEvent struct {
 	Event  EventType `json:"event"`
-	Sender string    `json:"sender"`
+	Sender string    `json:"sender,omitempty"`
+	GameId string    `json:"game_id,omitempty"`
+	Table  string    `json:"table,omitempty"`
+}
+
+// PauseGameEvent pauses the active game or resumes the paused game with RESUME_GAME. It is sent by the
+// administrator and by louie.
+type PauseGameEvent struct {
+	Event  EventType `json:"event"`
+	Sender string    `json:"sender,omitempty"`
+	GameId string    `json:"game_id,omitempty"`
+	Table  string    `json:"table,omitempty"`
+}
+
+// AbortGameEvent tells louie, that the administrator ended the game without result.
+type AbortGameEvent struct {
+	Event  EventType `json:"event"`
+	GameId string    `json:"game_id"`
+	Table  string    `json:"table,omitempty"`
+	Reason string    `json:"reason"`
+}
+
+// ConfirmedChangeSideEvent confirms the side change of the table.
+type ConfirmedChangeSideEvent struct {
+	Event EventType `json:"event"`
+	Table string    `json:"table,omitempty"`
 }
 
 type playersConfirm struct {
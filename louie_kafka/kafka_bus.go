@@ -0,0 +1,93 @@
+package louie_kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// KafkaBus is the kafka implementation of the MessageBus.
+type KafkaBus struct {
+	serverAddress string
+	groupId       string
+	startFromTail bool
+	producer      *KafkaProducer
+	// Backoff is the waiting time between the reconnect attempts of the consumers.
+	Backoff Backoff
+	// HealthCheckInterval is the interval the consumers check the broker.
+	HealthCheckInterval time.Duration
+	observer            ConnectionObserver
+	cancelConsumers     context.CancelFunc
+	consumerCtx         context.Context
+	consumers           sync.WaitGroup
+	closeOnce           sync.Once
+}
+
+func NewKafkaBus(serverAddress string, groupId string, startFromTail bool) *KafkaBus {
+
+	consumerCtx, cancelConsumers := context.WithCancel(context.Background())
+
+	return &KafkaBus{
+		serverAddress:       serverAddress,
+		groupId:             groupId,
+		startFromTail:       startFromTail,
+		producer:            NewKafkaProducer(serverAddress),
+		Backoff:             Backoff{Min: time.Second, Max: 30 * time.Second},
+		HealthCheckInterval: 10 * time.Second,
+		consumerCtx:         consumerCtx,
+		cancelConsumers:     cancelConsumers,
+	}
+}
+
+// ObserveConnection reports the connection state of every consumer, which is subscribed afterwards.
+func (bus *KafkaBus) ObserveConnection(observer ConnectionObserver) {
+	bus.observer = observer
+}
+
+// Subscribe consumes the topic until the context is cancelled or the bus is closed.
+func (bus *KafkaBus) Subscribe(ctx context.Context, topic string, router *Router) error {
+
+	consumerConfig := &ConsumerConfig{
+		Router:              router,
+		ServerAddress:       bus.serverAddress,
+		Topic:               topic,
+		GroupId:             bus.groupId,
+		StartFromTail:       bus.startFromTail,
+		Backoff:             bus.Backoff,
+		HealthCheckInterval: bus.HealthCheckInterval,
+		Observer:            bus.observer,
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stopOnClose := context.AfterFunc(bus.consumerCtx, cancel)
+
+	bus.consumers.Add(1)
+
+	go func() {
+		defer bus.consumers.Done()
+		defer stopOnClose()
+		defer cancel()
+
+		consumerConfig.StartConsumer(ctx)
+	}()
+
+	return nil
+}
+
+func (bus *KafkaBus) Publish(ctx context.Context, topic string, messages ...Message) error {
+	return bus.producer.WriteKafkaMessage(ctx, topic, messages...)
+}
+
+// Close stops all consumers, waits until the messages in progress are processed and closes the producer.
+func (bus *KafkaBus) Close() error {
+
+	var err error
+
+	bus.closeOnce.Do(func() {
+		bus.cancelConsumers()
+		bus.consumers.Wait()
+		err = bus.producer.Close()
+	})
+
+	return err
+}
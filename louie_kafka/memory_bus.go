@@ -0,0 +1,100 @@
+package louie_kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrBusClosed = errors.New("message bus is closed")
+
+// MemoryBus is an in-process implementation of the MessageBus. Every subscription gets the
+// published messages of its topic in order. It is used for tests and local runs without a broker.
+type MemoryBus struct {
+	subscriptions map[string][]chan Message
+	published     map[string][]Message
+	closed        bool
+	mutex         sync.Mutex
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		subscriptions: make(map[string][]chan Message),
+		published:     make(map[string][]Message),
+	}
+}
+
+func (bus *MemoryBus) Subscribe(ctx context.Context, topic string, router *Router) error {
+
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	if bus.closed {
+		return ErrBusClosed
+	}
+
+	queue := make(chan Message, 1000)
+	bus.subscriptions[topic] = append(bus.subscriptions[topic], queue)
+
+	go func() {
+		for message := range queue {
+			router.Route(ctx, message)
+		}
+	}()
+
+	return nil
+}
+
+func (bus *MemoryBus) Publish(ctx context.Context, topic string, messages ...Message) error {
+
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	if bus.closed {
+		return ErrBusClosed
+	}
+
+	for _, message := range messages {
+		message.Topic = topic
+		bus.published[topic] = append(bus.published[topic], message)
+
+		for _, queue := range bus.subscriptions[topic] {
+			select {
+			case queue <- message:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	return nil
+}
+
+// Published returns all messages, which were published to the topic.
+func (bus *MemoryBus) Published(topic string) []Message {
+
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	return append([]Message{}, bus.published[topic]...)
+}
+
+func (bus *MemoryBus) Close() error {
+
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	if bus.closed {
+		return nil
+	}
+
+	bus.closed = true
+
+	for _, queues := range bus.subscriptions {
+		for _, queue := range queues {
+			close(queue)
+		}
+	}
+
+	return nil
+}
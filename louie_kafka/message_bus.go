@@ -0,0 +1,218 @@
+package louie_kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/thoas/go-funk"
+	"log"
+	"time"
+)
+
+// MessageBus is the transport of the louie events. Kafka and MQTT are supported.
+type MessageBus interface {
+	// Subscribe consumes the topic in the background and hands every message to the router.
+	Subscribe(ctx context.Context, topic string, router *Router) error
+	// Publish writes the messages to the topic in the given order.
+	Publish(ctx context.Context, topic string, messages ...Message) error
+	Close() error
+}
+
+// SenderRegistry knows the senders, which may send events to the administrator, e.g. the louie tables and their ki.
+type SenderRegistry interface {
+	IsKnownSender(sender string) bool
+}
+
+// PresenceTracker records the last time a sender was seen on the message bus.
+type PresenceTracker interface {
+	Seen(sender string)
+}
+
+var ErrNotRoutable = errors.New("message is neither a technical nor a game event")
+
+// Message is a transport independent louie event.
+type Message struct {
+	Topic string
+	Key   []byte
+	Value []byte
+	// Time is the time the message was written. It is only known for messages read from kafka.
+	Time time.Time
+}
+
+// ConsumedMessage is a message handed over to a handler. The handler has to call Done after the
+// message is processed. Only then the message bus acknowledges the message (at-least-once delivery).
+type ConsumedMessage struct {
+	Message
+	done   chan struct{}
+	result *ProcessingResult
+}
+
+func (m ConsumedMessage) Done() {
+	if m.done != nil {
+		close(m.done)
+	}
+}
+
+// SetResult tells the router the outcome of the processing. It has to be called before Done. Messages
+// without result are recorded as processed.
+func (m ConsumedMessage) SetResult(result ProcessingResult) {
+	if m.result != nil {
+		*m.result = result
+	}
+}
+
+// Router assigns consumed messages to the game or technical event handlers.
+type Router struct {
+	GameEvents      chan ConsumedMessage
+	TechnicalEvents chan ConsumedMessage
+	DeadLetterSink  DeadLetterSink
+	// Sender is the sender of the administrator. Messages of this sender are never processed.
+	Sender string
+	// Presence is told about every sender of a consumed message. Optional.
+	Presence PresenceTracker
+	// Senders quarantines the events of unknown senders, so they never change the game of another table.
+	// Optional.
+	Senders SenderRegistry
+	// Queues buffer the events in front of the handlers. Without queues the events are handed over directly.
+	// Optional.
+	Queues *EventQueues
+	// Recorder stores every consumed event with its outcome. Own events are recorded, when they are
+	// published. Optional.
+	Recorder EventRecorder
+}
+
+// Route hands an inbound event to the game or technical event handler and blocks until the handler
+// marked the message as done. Own messages and outbound commands (e.g. read back from a shared topic)
+// are skipped. Messages, which can not be routed or do not match the json schema of their event type,
+// are sent to the dead letter sink.
+func (router *Router) Route(ctx context.Context, m Message) bool {
+
+	routed, processed := router.Dispatch(ctx, m)
+
+	select {
+	case <-processed:
+	case <-ctx.Done():
+	}
+
+	return routed
+}
+
+// Dispatch hands an inbound event over like Route, but returns as soon as the event is queued. The returned
+// channel is closed, when the event is processed, skipped, quarantined or dropped.
+func (router *Router) Dispatch(ctx context.Context, m Message) (bool, <-chan struct{}) {
+
+	envelope, err := DecodeEnvelope(m.Value)
+
+	if err != nil {
+		log.Printf("can not parse consumed message: %s\n", err)
+		router.quarantine(m, ParseError, err.Error())
+		return false, closedChannel()
+	}
+
+	if router.Sender != "" && envelope.Sender == router.Sender {
+		return false, closedChannel()
+	}
+
+	if router.Senders != nil && !router.Senders.IsKnownSender(envelope.Sender) {
+		log.Printf("consumed message of unknown sender \"%s\"\n", envelope.Sender)
+		router.quarantine(m, UnknownSender, fmt.Sprintf("unknown sender \"%s\"", envelope.Sender))
+		return false, closedChannel()
+	}
+
+	if router.Presence != nil && envelope.Sender != "" {
+		router.Presence.Seen(envelope.Sender)
+	}
+
+	var target chan ConsumedMessage
+	var queue *EventQueue
+
+	if funk.Contains(getInboundTechnicalEventTypes(), envelope.Event) {
+		target = router.TechnicalEvents
+		if router.Queues != nil {
+			queue = router.Queues.Technical
+		}
+	} else if funk.Contains(getInboundGameEventTypes(), envelope.Event) {
+		target = router.GameEvents
+		if router.Queues != nil {
+			queue = router.Queues.Game
+		}
+	} else if funk.Contains(getOutboundGameEventTypes(), envelope.Event) || funk.Contains(getOutboundTechnicalEventTypes(), envelope.Event) {
+		log.Printf("skip outbound event %s of sender \"%s\"\n", envelope.Event, envelope.Sender)
+		return false, closedChannel()
+	} else {
+		log.Printf("can not assign a technical or game event")
+		router.quarantine(m, UnknownEventType, fmt.Sprintf("unknown event type \"%s\"", envelope.Event))
+		return false, closedChannel()
+	}
+
+	if err := Validate(m.Value); err != nil {
+		log.Printf("consumed message is invalid: %s\n", err)
+		router.quarantine(m, SchemaViolation, err.Error())
+		return false, closedChannel()
+	}
+
+	done := make(chan struct{})
+	result := &ProcessingResult{Outcome: OutcomeProcessed}
+	message := ConsumedMessage{Message: m, done: done, result: result}
+
+	if queue != nil {
+		if err := queue.Push(ctx, envelope.Event, message); errors.Is(err, ErrQueueFull) {
+			router.quarantine(m, QueueOverflow, fmt.Sprintf("queue %s is full", queue.name))
+			return false, closedChannel()
+		} else if err != nil {
+			return false, closedChannel()
+		}
+	} else {
+		select {
+		case target <- message:
+		case <-ctx.Done():
+			return false, closedChannel()
+		}
+	}
+
+	processed := make(chan struct{})
+
+	go func() {
+		defer close(processed)
+
+		select {
+		case <-done:
+			router.record(m, *result)
+		case <-ctx.Done():
+		}
+	}()
+
+	return true, processed
+}
+
+func closedChannel() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+
+	return ch
+}
+
+// Redeliver routes a message, which did not arrive via the message bus (e.g. a replayed dead letter),
+// like a consumed one. It blocks until the message is processed.
+func (router *Router) Redeliver(ctx context.Context, value []byte) error {
+
+	if !router.Route(ctx, Message{Value: value}) {
+		return ErrNotRoutable
+	}
+
+	return nil
+}
+
+func (router *Router) quarantine(m Message, reason DeadLetterReason, detail string) {
+	if router.DeadLetterSink != nil {
+		router.DeadLetterSink.Quarantine(m.Value, reason, detail)
+	}
+
+	router.record(m, ProcessingResult{Outcome: OutcomeRejected, Detail: reason.String()})
+}
+
+func (router *Router) record(m Message, result ProcessingResult) {
+	if router.Recorder != nil {
+		router.Recorder.Record(RecordedEvent{Direction: DirectionInbound, Topic: m.Topic, Value: m.Value, Result: result})
+	}
+}
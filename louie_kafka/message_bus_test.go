@@ -0,0 +1,146 @@
+package louie_kafka
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type recordingSink struct {
+	reasons []DeadLetterReason
+}
+
+func (sink *recordingSink) Quarantine(message []byte, reason DeadLetterReason, detail string) {
+	sink.reasons = append(sink.reasons, reason)
+}
+
+func Test_Route_SkipsOwnMessages(t *testing.T) {
+
+	sink := &recordingSink{}
+	router := &Router{
+		GameEvents:      make(chan ConsumedMessage, 1),
+		TechnicalEvents: make(chan ConsumedMessage, 1),
+		DeadLetterSink:  sink,
+		Sender:          "louie-web-administrator",
+	}
+
+	routed := router.Route(context.Background(), Message{Value: []byte(`{"event":"GAME_DONE","sender":"louie-web-administrator"}`)})
+
+	assert.False(t, routed)
+	assert.Empty(t, router.GameEvents)
+	assert.Empty(t, sink.reasons)
+}
+
+func Test_Route_SkipsOutboundEvents(t *testing.T) {
+
+	sink := &recordingSink{}
+	router := &Router{
+		GameEvents:      make(chan ConsumedMessage, 1),
+		TechnicalEvents: make(chan ConsumedMessage, 1),
+		DeadLetterSink:  sink,
+		Sender:          "louie-web-administrator",
+	}
+
+	assert.False(t, router.Route(context.Background(), Message{Value: []byte(`{"event":"PLAYERS_READY","players":[{"display_name":"tobi"}]}`)}))
+	assert.False(t, router.Route(context.Background(), Message{Value: []byte(`{"event":"CONFIRMED_CHANGE_SIDE"}`)}))
+	assert.Empty(t, router.GameEvents)
+	assert.Empty(t, router.TechnicalEvents)
+	assert.Empty(t, sink.reasons)
+}
+
+func Test_Route_InboundEvents(t *testing.T) {
+
+	router := &Router{
+		GameEvents:      make(chan ConsumedMessage, 1),
+		TechnicalEvents: make(chan ConsumedMessage, 1),
+		Sender:          "louie-web-administrator",
+	}
+
+	go func() {
+		(<-router.TechnicalEvents).Done()
+		(<-router.GameEvents).Done()
+	}()
+
+	assert.True(t, router.Route(context.Background(), Message{Value: []byte(`{"event":"PLZ_CHANGE_SIDE","sender":"jan-ki-magic"}`)}))
+	assert.True(t, router.Route(context.Background(), Message{Value: []byte(`{"event":"PLAYERS_CONFIRM","sender":"c-library"}`)}))
+}
+
+func Test_Route_QuarantinesUnknownEvents(t *testing.T) {
+
+	sink := &recordingSink{}
+	router := &Router{DeadLetterSink: sink}
+
+	assert.False(t, router.Route(context.Background(), Message{Value: []byte(`{"event":"UNKNOWN"}`)}))
+	assert.Equal(t, []DeadLetterReason{UnknownEventType}, sink.reasons)
+}
+
+type recordingPresence struct {
+	senders []string
+}
+
+func (presence *recordingPresence) Seen(sender string) {
+	presence.senders = append(presence.senders, sender)
+}
+
+func Test_Route_TracksPresence(t *testing.T) {
+
+	presence := &recordingPresence{}
+	router := &Router{
+		GameEvents:      make(chan ConsumedMessage, 1),
+		TechnicalEvents: make(chan ConsumedMessage, 1),
+		Sender:          "louie-web-administrator",
+		Presence:        presence,
+	}
+
+	go func() {
+		(<-router.TechnicalEvents).Done()
+	}()
+
+	assert.True(t, router.Route(context.Background(), Message{Value: []byte(`{"event":"HEARTBEAT","sender":"c-library"}`)}))
+	assert.False(t, router.Route(context.Background(), Message{Value: []byte(`{"event":"PLAYERS_READY","sender":"louie-web-administrator"}`)}))
+	assert.Equal(t, []string{"c-library"}, presence.senders)
+}
+
+type knownSenders []string
+
+func (senders knownSenders) IsKnownSender(sender string) bool {
+	for _, knownSender := range senders {
+		if knownSender == sender {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_Route_QuarantinesUnknownSenders(t *testing.T) {
+
+	sink := &recordingSink{}
+	presence := &recordingPresence{}
+	router := &Router{
+		GameEvents:      make(chan ConsumedMessage, 1),
+		TechnicalEvents: make(chan ConsumedMessage, 1),
+		DeadLetterSink:  sink,
+		Sender:          "louie-web-administrator",
+		Presence:        presence,
+		Senders:         knownSenders{"c-library"},
+	}
+
+	assert.False(t, router.Route(context.Background(), Message{Value: []byte(`{"event":"PLAYERS_CONFIRM","sender":"e-library"}`)}))
+	assert.Empty(t, router.GameEvents)
+	assert.Empty(t, presence.senders)
+	assert.Equal(t, []DeadLetterReason{UnknownSender}, sink.reasons)
+}
+
+func Test_Topics(t *testing.T) {
+
+	shared := Topics{Inbound: "LOUIE_EVENT", Outbound: "LOUIE_EVENT"}
+
+	assert.Equal(t, []string{"LOUIE_EVENT"}, shared.SubscribedTopics())
+	assert.Equal(t, "LOUIE_EVENT", shared.PublishTopic(ConfirmedChangedSide))
+
+	separated := Topics{Inbound: "LOUIE_INBOUND", Outbound: "LOUIE_OUTBOUND", Technical: "LOUIE_TECHNICAL"}
+
+	assert.Equal(t, []string{"LOUIE_INBOUND", "LOUIE_TECHNICAL"}, separated.SubscribedTopics())
+	assert.Equal(t, "LOUIE_OUTBOUND", separated.PublishTopic(PlayersReady))
+	assert.Equal(t, "LOUIE_TECHNICAL", separated.PublishTopic(ConfirmedChangedSide))
+}
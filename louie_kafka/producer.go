@@ -2,25 +2,48 @@ package louie_kafka
 
 import (
 	"context"
+	"fmt"
 	"github.com/segmentio/kafka-go"
-	"log"
+	"time"
 )
 
+// KafkaProducer holds one long-lived kafka writer. The writer pools the connections to the partition
+// leaders, batches messages and retries failed writes. A write returns after all in-sync replicas
+// acknowledged the messages.
 type KafkaProducer struct {
-	ServerAddress string
-	Topic         string
+	writer *kafka.Writer
 }
 
-func (p *KafkaProducer) WriteKafkaMessage(ctx context.Context, messages *[]kafka.Message) {
+func NewKafkaProducer(serverAddress string) *KafkaProducer {
+	return &KafkaProducer{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(serverAddress),
+			Balancer:               &kafka.LeastBytes{},
+			MaxAttempts:            5,
+			BatchSize:              100,
+			BatchTimeout:           10 * time.Millisecond,
+			WriteTimeout:           10 * time.Second,
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+func (p *KafkaProducer) WriteKafkaMessage(ctx context.Context, topic string, messages ...Message) error {
 
-	connection := newKafkaConnection(ctx, p.ServerAddress, p.Topic)
+	kafkaMessages := make([]kafka.Message, 0, len(messages))
 
-	_, err := connection.WriteMessages(*messages...)
-	if err != nil {
-		log.Fatal("failed to write messages:", err)
+	for _, message := range messages {
+		kafkaMessages = append(kafkaMessages, kafka.Message{Topic: topic, Key: message.Key, Value: message.Value})
 	}
 
-	if err := connection.Close(); err != nil {
-		log.Fatal("failed to close writer:", err)
+	if err := p.writer.WriteMessages(ctx, kafkaMessages...); err != nil {
+		return fmt.Errorf("failed to write messages: %w", err)
 	}
+
+	return nil
+}
+
+func (p *KafkaProducer) Close() error {
+	return p.writer.Close()
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/segmentio/kafka-go"
 	"louie-web-administrator/repository"
 	"os"
 	"testing"
@@ -17,42 +16,60 @@ func TestProducerAndConsumer(t *testing.T) {
 		t.Skip("Skipping kafka tests")
 	}
 
-	ctx := context.Background()
-	result := make(chan kafka.Message, 1000)
-	quit := make(chan bool)
+	ctx, cancel := context.WithCancel(context.Background())
+	consumed := make(chan ConsumedMessage)
+	result := make(chan Message, 1000)
 
 	messages := createLouieEventKafkaMessages()
 
-	consumerConfig := ConsumerConfig{
-		Quit:          quit,
-		GameEvents:    result,
-		ServerAddress: "localhost:9093",
-		Topic:         "test42",
+	bus := NewKafkaBus("localhost:9093", "test42-group", false)
+	router := &Router{
+		GameEvents:      consumed,
+		TechnicalEvents: consumed,
 	}
 
-	go consumerConfig.StartConsumer(ctx)
+	// consumed is never closed, the consumer of the bus can still send on it until it is closed itself.
+	readerDone := make(chan struct{})
+
+	go func() {
+		defer close(readerDone)
+
+		for {
+			select {
+			case message := <-consumed:
+				result <- message.Message
+				message.Done()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	if err := bus.Subscribe(ctx, "test42", router); err != nil {
+		t.Fatalf("subscribing failed: %s", err)
+	}
 
 	time.Sleep(2 * time.Second)
 
-	producer := KafkaProducer{
-		ServerAddress: "localhost:9093",
-		Topic:         "test42",
-	}
-
-	go producer.WriteKafkaMessage(ctx, &messages)
+	go func() {
+		if err := bus.Publish(ctx, "test42", messages...); err != nil {
+			t.Errorf("writing messages failed: %s", err)
+		}
+	}()
 
 	time.Sleep(4 * time.Second)
 
-	quit <- true
-	close(quit)
+	cancel()
+	_ = bus.Close()
+	<-readerDone
 	close(result)
 
 	for message := range result {
-		fmt.Printf("message at offset %d: %s = %s\n", message.Offset, string(message.Key), string(message.Value))
+		fmt.Printf("message of topic %s: %s = %s\n", message.Topic, string(message.Key), string(message.Value))
 	}
 }
 
-func createLouieEventKafkaMessages() []kafka.Message {
+func createLouieEventKafkaMessages() []Message {
 	gameDone, _ := json.Marshal(GameDoneEvent{
 		Sender:   "webserver",
 		Event:    GameDone,
@@ -79,7 +96,7 @@ func createLouieEventKafkaMessages() []kafka.Message {
 		Coins:  2,
 	})
 
-	resetGame, _ := json.Marshal(resetGame{
+	resetGame, _ := json.Marshal(ResetGameEvent{
 		Sender: "webserver",
 		Event:  ResetGame,
 	})
@@ -94,7 +111,7 @@ func createLouieEventKafkaMessages() []kafka.Message {
 		Sender: "webserver",
 	})
 
-	return []kafka.Message{
+	return []Message{
 		{Value: gameDone},
 		{Value: playersReady},
 		{Value: confirmedChangedSide},
@@ -0,0 +1,123 @@
+package louie_kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"github.com/segmentio/kafka-go"
+	"io"
+	"log"
+	"sort"
+	"time"
+)
+
+// ReplayStart defines where the replay of a topic starts. The offset is used for every partition. Without
+// offset and time, the topics are read from the beginning.
+type ReplayStart struct {
+	Offset int64
+	Time   time.Time
+}
+
+// ReadTopics reads the topics from the start up to the latest message, which existed when reading started.
+// The messages of all topics and partitions are returned in the order they were written.
+func ReadTopics(ctx context.Context, serverAddress string, topics []string, start ReplayStart) ([]Message, error) {
+
+	messages := make([]Message, 0)
+
+	for _, topic := range topics {
+
+		partitions, err := readPartitions(ctx, serverAddress, topic)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, partition := range partitions {
+
+			partitionMessages, err := readPartition(ctx, serverAddress, topic, partition.ID, start)
+
+			if err != nil {
+				return nil, err
+			}
+
+			messages = append(messages, partitionMessages...)
+		}
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Time.Before(messages[j].Time)
+	})
+
+	return messages, nil
+}
+
+func readPartition(ctx context.Context, serverAddress string, topic string, partition int, start ReplayStart) ([]Message, error) {
+
+	lastOffset, err := readLastOffset(ctx, serverAddress, topic, partition)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   []string{serverAddress},
+		Topic:     topic,
+		Partition: partition,
+		MaxBytes:  10e6, // 10MB
+	})
+	defer r.Close()
+
+	if !start.Time.IsZero() {
+		err = r.SetOffsetAt(ctx, start.Time)
+	} else if start.Offset > 0 {
+		err = r.SetOffset(start.Offset)
+	} else {
+		err = r.SetOffset(kafka.FirstOffset)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]Message, 0)
+
+	for r.Offset() < lastOffset {
+
+		m, err := r.FetchMessage(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, Message{Topic: m.Topic, Key: m.Key, Value: m.Value, Time: m.Time})
+
+		if m.Offset >= lastOffset-1 {
+			break
+		}
+	}
+
+	log.Printf("read %d messages of partition %d of topic %s\n", len(messages), partition, topic)
+
+	return messages, nil
+}
+
+// ReadArchive reads an event archive with one raw event per line. Empty lines are skipped.
+func ReadArchive(archive io.Reader) ([]Message, error) {
+
+	messages := make([]Message, 0)
+	scanner := bufio.NewScanner(archive)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10e6)
+
+	for scanner.Scan() {
+
+		line := bytes.TrimSpace(scanner.Bytes())
+
+		if len(line) == 0 {
+			continue
+		}
+
+		messages = append(messages, Message{Value: append([]byte(nil), line...)})
+	}
+
+	return messages, scanner.Err()
+}
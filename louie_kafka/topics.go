@@ -0,0 +1,38 @@
+package louie_kafka
+
+import "github.com/thoas/go-funk"
+
+// Topics are the topics of the message bus. Inbound events of louie and the ki are consumed from the
+// inbound topic, the commands of the administrator are published to the outbound topic. If a technical
+// topic is defined, the technical events of both directions use this topic.
+type Topics struct {
+	Inbound   string
+	Outbound  string
+	Technical string
+}
+
+// SubscribedTopics returns the topics the administrator consumes.
+func (t Topics) SubscribedTopics() []string {
+
+	if t.Technical == "" || t.Technical == t.Inbound {
+		return []string{t.Inbound}
+	}
+
+	return []string{t.Inbound, t.Technical}
+}
+
+// PublishTopic returns the topic an outgoing event is published to.
+func (t Topics) PublishTopic(event EventType) string {
+
+	if t.Technical != "" && funk.Contains(getOutboundTechnicalEventTypes(), event) {
+		return t.Technical
+	}
+
+	return t.Outbound
+}
+
+// ReplayedTopics returns the topics, which are read by a replay. Besides the events of louie, the
+// outbound PLAYERS_READY commands are needed to rebuild the games.
+func (t Topics) ReplayedTopics() []string {
+	return funk.UniqString([]string{t.Inbound, t.Outbound})
+}
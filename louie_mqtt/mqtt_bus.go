@@ -0,0 +1,156 @@
+package louie_mqtt
+
+import (
+	"context"
+	"fmt"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"log"
+	"louie-web-administrator/louie_kafka"
+	"sync"
+)
+
+// MqttBus is the mqtt implementation of the MessageBus. It is meant for small events, where a
+// lightweight local broker (e.g. mosquitto) replaces kafka. Messages are sent with the configured
+// qos. With a persistent session (qos 1) messages sent during a restart are delivered afterwards.
+type MqttBus struct {
+	client        mqtt.Client
+	qos           byte
+	subscriptions map[string]mqtt.MessageHandler
+	observer      louie_kafka.ConnectionObserver
+	mutex         sync.Mutex
+}
+
+// connectionName identifies the broker connection for the ConnectionObserver. All topics share one connection.
+const connectionName = "mqtt"
+
+func NewMqttBus(brokerUrl string, clientId string, qos byte) (*MqttBus, error) {
+
+	bus := &MqttBus{
+		qos:           qos,
+		subscriptions: make(map[string]mqtt.MessageHandler),
+	}
+
+	options := mqtt.NewClientOptions().
+		AddBroker(brokerUrl).
+		SetClientID(clientId).
+		SetCleanSession(false).
+		SetOrderMatters(true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOnConnectHandler(bus.onConnect).
+		SetConnectionLostHandler(func(client mqtt.Client, err error) {
+			log.Printf("connection to mqtt broker lost: %s\n", err)
+			bus.changeState(louie_kafka.ConnectionReconnecting, err)
+		})
+
+	bus.client = mqtt.NewClient(options)
+
+	token := bus.client.Connect()
+
+	if token.Wait() && token.Error() != nil {
+		return nil, fmt.Errorf("failed to connect to mqtt broker: %w", token.Error())
+	}
+
+	return bus, nil
+}
+
+// Subscribe routes every message of the topic. The handler returns after the message is processed,
+// only then the message is acknowledged.
+func (bus *MqttBus) Subscribe(ctx context.Context, topic string, router *louie_kafka.Router) error {
+
+	handler := func(client mqtt.Client, message mqtt.Message) {
+		log.Printf("consumed mqtt message: %s:%s", message.Topic(), message.Payload())
+
+		router.Route(ctx, louie_kafka.Message{Topic: message.Topic(), Value: message.Payload()})
+	}
+
+	bus.mutex.Lock()
+	bus.subscriptions[topic] = handler
+	bus.mutex.Unlock()
+
+	if !bus.client.IsConnectionOpen() {
+		// subscribed by the on connect handler
+		return nil
+	}
+
+	token := bus.client.Subscribe(topic, bus.qos, handler)
+
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", topic, token.Error())
+	}
+
+	return nil
+}
+
+func (bus *MqttBus) Publish(ctx context.Context, topic string, messages ...louie_kafka.Message) error {
+
+	for _, message := range messages {
+		token := bus.client.Publish(topic, bus.qos, false, message.Value)
+
+		select {
+		case <-token.Done():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if token.Error() != nil {
+			return fmt.Errorf("failed to publish message: %w", token.Error())
+		}
+	}
+
+	return nil
+}
+
+func (bus *MqttBus) Close() error {
+	bus.client.Disconnect(250)
+	bus.changeState(louie_kafka.ConnectionStopped, nil)
+	log.Print("terminate mqtt client")
+
+	return nil
+}
+
+// ObserveConnection reports the current and every following state of the broker connection. The paho client
+// reconnects on its own.
+func (bus *MqttBus) ObserveConnection(observer louie_kafka.ConnectionObserver) {
+
+	bus.mutex.Lock()
+	bus.observer = observer
+	bus.mutex.Unlock()
+
+	if bus.client.IsConnectionOpen() {
+		bus.changeState(louie_kafka.ConnectionConnected, nil)
+	} else {
+		bus.changeState(louie_kafka.ConnectionReconnecting, nil)
+	}
+}
+
+func (bus *MqttBus) onConnect(client mqtt.Client) {
+	bus.resubscribe(client)
+	bus.changeState(louie_kafka.ConnectionConnected, nil)
+}
+
+func (bus *MqttBus) changeState(state louie_kafka.ConnectionState, err error) {
+
+	bus.mutex.Lock()
+	observer := bus.observer
+	bus.mutex.Unlock()
+
+	if observer != nil {
+		observer.ConnectionChanged(connectionName, state, err)
+	}
+}
+
+// resubscribe subscribes all topics again after a (re)connect.
+func (bus *MqttBus) resubscribe(client mqtt.Client) {
+
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	for topic, handler := range bus.subscriptions {
+		token := client.Subscribe(topic, bus.qos, handler)
+
+		if token.Wait() && token.Error() != nil {
+			log.Printf("failed to subscribe to %s: %s\n", topic, token.Error())
+		}
+	}
+}
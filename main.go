@@ -5,14 +5,15 @@ import (
 	_ "embed"
 	"errors"
 	"fmt"
-	"github.com/segmentio/kafka-go"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
 	"louie-web-administrator/admin"
 	"louie-web-administrator/configuration"
 	"louie-web-administrator/dashboard"
+	"louie-web-administrator/health"
 	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/louie_mqtt"
 	"louie-web-administrator/repository"
 	"louie-web-administrator/service"
 	"louie-web-administrator/websocket"
@@ -56,87 +57,202 @@ func main() {
 	cfg := processConfiguration()
 	// ---
 
-	// --- init mongo db ---
-	credential := options.Credential{
-		AuthMechanism: "SCRAM-SHA-256",
-		AuthSource:    "admin",
-		Username:      cfg.Database.DatabaseUser,
-		Password:      cfg.Database.DatabasePassword,
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		runReplay(ctx, cfg, os.Args[2:])
+		return
 	}
-	clientOpts := options.Client().
-		ApplyURI(fmt.Sprintf("mongodb://%s:%d",
-			cfg.Database.DatabaseServer,
-			cfg.Database.DatabasePort)).
-		SetAuth(credential)
 
-	client, err := mongo.Connect(ctx, clientOpts)
-	if err != nil {
-		log.Fatal(err)
-	}
+	// --- init mongo db ---
+	client := connectMongo(ctx, cfg)
 	defer client.Disconnect(ctx)
 	// ---
 
 	// --- init repositories ---
 	userRepository := repository.NewUserRepo(ctx, client, cfg.Database.DatabaseName)
 	gameRepository := repository.NewGameRepository(ctx, client, cfg.Database.DatabaseName)
+	outboxRepository := repository.NewOutboxRepository(ctx, client, cfg.Database.DatabaseName)
+	deadLetterRepository := repository.NewDeadLetterRepository(ctx, client, cfg.Database.DatabaseName)
+	eventRepository := repository.NewEventRepository(ctx, client, cfg.Database.DatabaseName)
+	matchRepository := repository.NewMatchRepository(ctx, client, cfg.Database.DatabaseName)
 	// ---
 
 	// --- init channels ---
-	kafkaGameEventsChannel := make(chan kafka.Message)
-	kafkaTechnicalEventsChannel := make(chan kafka.Message)
-	kafkaQuitChannel := make(chan bool)
-	dashboardChannel := make(chan *websocket.DashboardSignal, 100)
+	kafkaGameEventsChannel := make(chan louie_kafka.ConsumedMessage)
+	kafkaTechnicalEventsChannel := make(chan louie_kafka.ConsumedMessage)
 	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
 	// ---
 
-	// --- init kafka consumer ---
-	setupKafkaConsumer(ctx, cfg, kafkaGameEventsChannel, kafkaTechnicalEventsChannel, kafkaQuitChannel)
+	// --- init tables ---
+	tables, err := service.ParseTables(cfg.Tables)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the ki of the default table keeps its sender of the time before tables existed.
+	if tables[0].KiSender == "" {
+		tables[0].KiSender = cfg.Presence.KiSender
+	}
+
+	tableService := service.NewTableService(tables)
+
+	if err := tableService.AssignMissingTables(userRepository, gameRepository); err != nil {
+		log.Fatalf("assigning the default table failed: %s", err)
+	}
+
+	dashboardChannels := make(map[string]chan *websocket.DashboardSignal)
+	devices := make([]service.Device, 0, 2*len(tables))
+	kiDevices := make([]service.Device, 0, len(tables))
+
+	for i, table := range tables {
+		dashboardChannels[table.Sender] = make(chan *websocket.DashboardSignal, 100)
+		devices = append(devices, service.Device{Name: table.Name, Sender: table.Sender, Table: true})
+
+		if table.KiSender == "" {
+			continue
+		}
+
+		if i == 0 {
+			kiDevices = append(kiDevices, service.Device{Name: "KI", Sender: table.KiSender})
+		} else {
+			kiDevices = append(kiDevices, service.Device{Name: fmt.Sprintf("KI %s", table.Name), Sender: table.KiSender})
+		}
+	}
+	// ---
+
+	// --- init message bus ---
+	messageBus := setupMessageBus(cfg)
+	topics := setupTopics(cfg)
 	// ---
 
-	// --- init kafka producer ---
-	kafkaProducer := louie_kafka.KafkaProducer{
-		ServerAddress: fmt.Sprintf("%s:%s", cfg.Kafka.Server, cfg.Kafka.Port),
-		Topic:         cfg.Kafka.LouieEventTopic,
+	// --- init admin websocket ---
+	adminUiWebsocket := websocket.InitAdminUiWebsocket(adminUiChannel)
+	// ---
+
+	// --- init broker connection state ---
+	connectionService := service.NewConnectionService(adminUiWebsocket)
+
+	if reporter, ok := messageBus.(louie_kafka.ConnectionReporter); ok {
+		reporter.ObserveConnection(connectionService)
 	}
 	// ---
 
 	// --- init services ---
+	outboxService := service.NewOutboxService(outboxRepository, messageBus, topics, adminUiWebsocket, cfg.Outbox.MaxAttempts, cfg.Events.Sender, cfg.Events.SendEnvelope)
 	userService := &service.UserSer{UserRepository: userRepository}
 	gameService := &service.GameSer{
 		UserRepository: userRepository,
 		GameRepository: gameRepository,
-		KafkaProducer:  kafkaProducer,
+		OutboxService:  outboxService,
+		Defaults: repository.GameSettings{
+			Seats:         cfg.Game.Seats,
+			StartingCoins: cfg.Game.StartingCoins,
+		},
 	}
+	presenceService := service.NewPresenceService(
+		append(devices, kiDevices...),
+		cfg.Presence.StaleAfter,
+		cfg.Presence.OfflineAfter,
+		adminUiWebsocket,
+	)
+	gameService.Presence = presenceService
+	deadLetterService := &service.DeadLetterSer{
+		DeadLetterRepository: deadLetterRepository,
+		AdminUiSocket:        adminUiWebsocket,
+		MessageBus:           messageBus,
+		DeadLetterTopic:      cfg.Kafka.DeadLetterTopic,
+	}
+	eventStoreService := service.NewEventStoreService(eventRepository, cfg.EventStore.Retention, cfg.EventStore.HeartbeatRetention)
+	outboxService.Recorder = eventStoreService
 	// ---
 
-	// --- init websockets ---
-	adminUiWebsocket := websocket.InitAdminUiWebsocket(adminUiChannel)
+	// --- init event queues ---
+	eventQueues := setupEventQueues(cfg, kafkaGameEventsChannel, kafkaTechnicalEventsChannel)
+	queueService := service.NewQueueService(eventQueues, adminUiWebsocket)
+	// ---
+
+	// --- init message bus subscription ---
+	eventRouter := &louie_kafka.Router{
+		GameEvents:      kafkaGameEventsChannel,
+		TechnicalEvents: kafkaTechnicalEventsChannel,
+		DeadLetterSink:  deadLetterService,
+		Sender:          cfg.Events.Sender,
+		Presence:        presenceService,
+		Senders:         tableService,
+		Recorder:        eventStoreService,
+		Queues:          eventQueues,
+	}
+	deadLetterService.Redeliverer = eventRouter
 
+	queueCtx, stopQueues := context.WithCancel(ctx)
+	eventQueues.Run(queueCtx)
+
+	consumerCtx, stopConsumers := context.WithCancel(ctx)
+
+	for _, topic := range topics.SubscribedTopics() {
+		if err := messageBus.Subscribe(consumerCtx, topic, eventRouter); err != nil {
+			log.Fatal(err)
+		}
+	}
+	// ---
+
+	// --- init outbox relay ---
+	relayCtx, stopRelay := context.WithCancel(ctx)
+	outboxService.RunOutboxRelay(relayCtx, cfg.Outbox.RelayInterval)
+	// ---
+
+	// --- init presence monitor ---
+	presenceService.RunPresenceMonitor(relayCtx, cfg.Presence.CheckInterval)
+	// ---
+
+	// --- init queue monitor ---
+	queueService.RunQueueMonitor(relayCtx, cfg.Queues.MonitorInterval)
+	// ---
+
+	// --- init event retention ---
+	eventStoreService.RunEventRetention(relayCtx, cfg.EventStore.RetentionCheckInterval)
+	// ---
+
+	// --- init dashboard websocket ---
 	dashboardWebsocket := websocket.InitGameDashboardSocket(
-		dashboardChannel,
+		dashboardChannels,
+		tableService.Default().Sender,
 		gameService.GetCurrentDashboardState,
 	)
 	// ---
 
+	// --- init game watchdog ---
+	gameWatchdogService := service.NewGameWatchdogService(gameService, tableService, setupWatchdogTimeouts(cfg), adminUiWebsocket, dashboardWebsocket)
+	gameWatchdogService.RunGameWatchdog(relayCtx, cfg.Watchdog.Interval)
+	// ---
+
 	// --- init technical event handler ---
-	technicalEventHandler := service.RunTechnicalEventHandler(kafkaTechnicalEventsChannel, adminUiChannel, kafkaProducer)
+	technicalEventHandler := service.RunTechnicalEventHandler(kafkaTechnicalEventsChannel, adminUiChannel, outboxService, tableService)
+	// ---
+
+	// --- init match queue ---
+	matchQueueService := service.NewMatchQueueService(matchRepository, userRepository, gameService, tableService, adminUiWebsocket, cfg.Matches.CoolDown)
 	// ---
 
 	// --- init state changer ---
-	stateChanger := service.GameStateChecker{UserService: userService, GameService: gameService, GameDashboardSocket: *dashboardWebsocket, AdminUiSocket: *adminUiWebsocket}
+	stateChanger := service.GameStateChecker{UserService: userService, GameService: gameService, GameDashboardSocket: *dashboardWebsocket, AdminUiSocket: *adminUiWebsocket, DeadLetterService: deadLetterService, Tables: tableService, RequireGameId: cfg.Events.RequireGameId, MatchQueue: matchQueueService}
 	stateChanger.RunGameStateChecker(kafkaGameEventsChannel)
 	// ---
 
-	// --- init louki ki user ---
-	userService.InitOrRefreshLouki()
+	// --- init ki users of the tables ---
+	userService.InitOrRefreshKiUsers(tableService.GetAll())
+	// ---
+
+	// --- continue the match queues, which waited for the cool-down before the restart ---
+	matchQueueService.ResumeAfterRestart()
 	// ---
 
 	// --- init admin event service ---
-	adminEventService := service.InitAdminEventService(userService, gameService, adminUiWebsocket)
+	adminEventService := service.InitAdminEventService(userService, gameService, tableService, adminUiWebsocket)
 	// ---
 
 	// --- init controller routes ---
-	router := setupRoutes(userService, gameService, dashboardWebsocket, adminUiWebsocket, technicalEventHandler, adminEventService)
+	router := setupRoutes(userService, gameService, tableService, outboxService, deadLetterService, presenceService, eventStoreService, connectionService, queueService, matchQueueService, dashboardWebsocket, adminUiWebsocket, technicalEventHandler, adminEventService)
 
 	server := &http.Server{
 		Addr: listenAddr,
@@ -165,9 +281,24 @@ func main() {
 		log.Print("stopping server")
 		cancel()
 
-		log.Printf("stopping kafka consumer")
-		kafkaQuitChannel <- true
+		log.Printf("stopping message bus consumers")
+		stopConsumers()
+
+		log.Printf("stopping outbox relay")
+		stopRelay()
+
+		// waits until the consumed messages in progress are processed
+		log.Printf("stopping message bus")
+		if err := messageBus.Close(); err != nil {
+			log.Printf("closing message bus failed: %s\n", err)
+		}
+
+		log.Printf("stopping event queues")
+		stopQueues()
+		eventQueues.Wait()
+		// the queues were the only senders, so the handlers stop after the remaining events.
 		close(kafkaGameEventsChannel)
+		close(kafkaTechnicalEventsChannel)
 	}()
 
 	if err := server.Shutdown(ctx); err != nil {
@@ -176,30 +307,148 @@ func main() {
 	log.Print("server exited")
 }
 
-func setupKafkaConsumer(
-	ctx context.Context,
-	config *configuration.Config,
-	gameEventsChannel chan kafka.Message,
-	technicalEventsChannel chan kafka.Message,
-	quit chan bool) {
-
-	consumerConfig := louie_kafka.ConsumerConfig{
-		Quit:            quit,
-		GameEvents:      gameEventsChannel,
-		TechnicalEvents: technicalEventsChannel,
-		ServerAddress: fmt.Sprintf("%s:%s",
-			config.Kafka.Server,
-			config.Kafka.Port,
-		),
-		Topic: config.Kafka.LouieEventTopic,
+func connectMongo(ctx context.Context, config *configuration.Config) *mongo.Client {
+
+	credential := options.Credential{
+		AuthMechanism: "SCRAM-SHA-256",
+		AuthSource:    "admin",
+		Username:      config.Database.DatabaseUser,
+		Password:      config.Database.DatabasePassword,
+	}
+	clientOpts := options.Client().
+		ApplyURI(fmt.Sprintf("mongodb://%s:%d",
+			config.Database.DatabaseServer,
+			config.Database.DatabasePort)).
+		SetAuth(credential)
+
+	client, err := mongo.Connect(ctx, clientOpts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return client
+}
+
+func setupMessageBus(config *configuration.Config) louie_kafka.MessageBus {
+
+	switch config.MessageBus.Transport {
+
+	case configuration.MqttMessageBus:
+		mqttBus, err := louie_mqtt.NewMqttBus(config.Mqtt.BrokerUrl, config.Mqtt.ClientId, config.Mqtt.Qos)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return mqttBus
+
+	case configuration.KafkaMessageBus:
+		kafkaBus := louie_kafka.NewKafkaBus(
+			fmt.Sprintf("%s:%s",
+				config.Kafka.Server,
+				config.Kafka.Port,
+			),
+			config.Kafka.ConsumerGroup,
+			config.Kafka.StartFromTail,
+		)
+		kafkaBus.Backoff = louie_kafka.Backoff{Min: config.Kafka.ReconnectBackoffMin, Max: config.Kafka.ReconnectBackoffMax}
+		kafkaBus.HealthCheckInterval = config.Kafka.HealthCheckInterval
+
+		return kafkaBus
+	}
+
+	log.Fatalf("unknown message bus \"%s\"", config.MessageBus.Transport)
+
+	return nil
+}
+
+func setupEventQueues(config *configuration.Config, gameEvents chan louie_kafka.ConsumedMessage, technicalEvents chan louie_kafka.ConsumedMessage) *louie_kafka.EventQueues {
+
+	gameOverflow, err := louie_kafka.ParseOverflowPolicy(config.Queues.GameOverflow)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the consumer waits for a blocked game queue, so the technical events would wait for the game handler.
+	if gameOverflow == louie_kafka.OverflowBlock {
+		log.Fatal("the overflow policy of the game queue must not be block, use drop_oldest_coin_drop or reject")
 	}
 
-	go consumerConfig.StartConsumer(ctx)
+	technicalOverflow, err := louie_kafka.ParseOverflowPolicy(config.Queues.TechnicalOverflow)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if config.Queues.GameCapacity < 1 || config.Queues.TechnicalCapacity < 1 {
+		log.Fatal("the capacity of the event queues must be at least 1")
+	}
+
+	return louie_kafka.NewEventQueues(
+		gameEvents,
+		louie_kafka.QueueConfig{Capacity: config.Queues.GameCapacity, Policy: gameOverflow},
+		technicalEvents,
+		louie_kafka.QueueConfig{Capacity: config.Queues.TechnicalCapacity, Policy: technicalOverflow, BlockTimeout: config.Queues.BlockTimeout},
+	)
+}
+
+func setupWatchdogTimeouts(config *configuration.Config) map[repository.GameState]service.StateTimeout {
+
+	timeouts := map[repository.GameState]service.StateTimeout{}
+
+	for state, timeout := range map[repository.GameState]struct {
+		timeout time.Duration
+		action  string
+	}{
+		repository.GameAnnounced: {config.Watchdog.AnnouncedTimeout, config.Watchdog.AnnouncedAction},
+		repository.GameReady:     {config.Watchdog.ReadyTimeout, config.Watchdog.ReadyAction},
+		repository.GameActive:    {config.Watchdog.ActiveTimeout, config.Watchdog.ActiveAction},
+		repository.GamePaused:    {config.Watchdog.PausedTimeout, config.Watchdog.PausedAction},
+	} {
+		action, err := service.ParseWatchdogAction(timeout.action)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		timeouts[state] = service.StateTimeout{Timeout: timeout.timeout, Action: action}
+	}
+
+	return timeouts
+}
+
+// setupTopics falls back to the shared louie event topic for every direction without own topic.
+func setupTopics(config *configuration.Config) louie_kafka.Topics {
+
+	topics := louie_kafka.Topics{
+		Inbound:   config.Topics.Inbound,
+		Outbound:  config.Topics.Outbound,
+		Technical: config.Topics.Technical,
+	}
+
+	if topics.Inbound == "" {
+		topics.Inbound = config.Kafka.LouieEventTopic
+	}
+
+	if topics.Outbound == "" {
+		topics.Outbound = config.Kafka.LouieEventTopic
+	}
+
+	return topics
 }
 
 func setupRoutes(
 	userService *service.UserSer,
 	gameService *service.GameSer,
+	tableService *service.TableSer,
+	outboxService *service.OutboxSer,
+	deadLetterService *service.DeadLetterSer,
+	presenceService *service.PresenceSer,
+	eventStoreService *service.EventStoreSer,
+	connectionService *service.ConnectionSer,
+	queueService *service.QueueSer,
+	matchQueueService *service.MatchQueueSer,
 	gameDashboardSocket *websocket.GameDashboardSocket,
 	adminUiWebsocket *websocket.AdminUiWebsocket,
 	technicalEventHandler *service.TechnicalEventHandler,
@@ -208,52 +457,163 @@ func setupRoutes(
 	router := mux.NewRouter()
 
 	router.
-		HandleFunc("/", admin.Main(userService, gameService)).
+		HandleFunc("/", admin.Main(userService, gameService, tableService, outboxService, deadLetterService, presenceService, connectionService, queueService)).
+		Methods("GET")
+
+	router.
+		HandleFunc("/health/live", health.Live()).
+		Methods("GET")
+
+	router.
+		HandleFunc("/health/ready", health.Ready(connectionService)).
+		Methods("GET")
+
+	router.
+		HandleFunc("/metrics/queues", health.Queues(queueService)).
 		Methods("GET")
 
 	router.
-		HandleFunc("/confirm", admin.ConfirmSideChange(technicalEventHandler)).
+		HandleFunc("/confirm", admin.ConfirmSideChange(technicalEventHandler, tableService)).
 		Methods("POST")
 
 	router.
-		HandleFunc("/user/filter", admin.Filter(userService)).
+		HandleFunc("/user/filter", admin.Filter(userService, tableService)).
+		Methods("POST").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/user/wait", admin.Wait(userService, tableService, adminEventService)).
+		Methods("PUT").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/user/state", admin.State(userService, tableService, adminEventService)).
+		Methods("PUT").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/user/table", admin.Table(userService, tableService, adminEventService)).
+		Methods("PUT").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/user/position", admin.Position(userService, tableService)).
+		Methods("PUT").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/user/position/shuffle", admin.Shuffle(userService, gameService, tableService)).
+		Methods("PUT").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/user/{page:[0-9]+}", admin.Page(userService, tableService)).
+		Methods("GET")
+
+	router.
+		HandleFunc("/game", admin.AnnounceGame(userService, gameService, tableService)).
 		Methods("POST").
 		Headers("Content-Type", "application/x-www-form-urlencoded")
 
 	router.
-		HandleFunc("/user/wait", admin.Wait(userService, adminEventService)).
+		HandleFunc("/game", admin.GameTable(gameService, tableService)).
+		Methods("GET")
+
+	router.
+		HandleFunc("/game", admin.RemoveGame(gameService, tableService, gameDashboardSocket)).
 		Methods("PUT").
 		Headers("Content-Type", "application/x-www-form-urlencoded")
 
 	router.
-		HandleFunc("/user/state", admin.State(userService, adminEventService)).
+		HandleFunc("/game/reset", admin.ResetGame(gameService, tableService, gameDashboardSocket)).
 		Methods("PUT").
 		Headers("Content-Type", "application/x-www-form-urlencoded")
 
 	router.
-		HandleFunc("/user/position", admin.Position(userService)).
+		HandleFunc("/game/abort", admin.AbortGame(gameService, tableService, gameDashboardSocket)).
 		Methods("PUT").
 		Headers("Content-Type", "application/x-www-form-urlencoded")
 
 	router.
-		HandleFunc("/user/position/shuffle", admin.Shuffle(userService)).
+		HandleFunc("/game/pause", admin.PauseGame(gameService, tableService, gameDashboardSocket)).
 		Methods("PUT").
 		Headers("Content-Type", "application/x-www-form-urlencoded")
 
 	router.
-		HandleFunc("/user/{page:[0-9]+}", admin.Page(userService)).
+		HandleFunc("/game/resume", admin.ResumeGame(gameService, tableService, gameDashboardSocket)).
+		Methods("PUT").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/game/history", admin.GameHistory(gameService, tableService)).
+		Methods("GET")
+
+	router.
+		HandleFunc("/match", admin.MatchQueue(matchQueueService, gameService, tableService)).
 		Methods("GET")
 
 	router.
-		HandleFunc("/game", admin.AnnounceGame(userService, gameService)).
+		HandleFunc("/match", admin.EnqueueMatch(matchQueueService, gameService, tableService)).
+		Methods("POST").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/match/edit", admin.EditMatch(matchQueueService, gameService, tableService)).
+		Methods("PUT").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/match/up", admin.MoveMatch(matchQueueService, gameService, tableService, -1)).
+		Methods("PUT").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/match/down", admin.MoveMatch(matchQueueService, gameService, tableService, 1)).
+		Methods("PUT").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/match/skip", admin.SkipMatch(matchQueueService, gameService, tableService)).
+		Methods("PUT").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/match/announce", admin.AnnounceNextMatch(matchQueueService, gameService, tableService)).
 		Methods("POST").
 		Headers("Content-Type", "application/x-www-form-urlencoded")
 
 	router.
-		HandleFunc("/game", admin.RemoveGame(userService, gameService, gameDashboardSocket)).
+		HandleFunc("/outbox/retry", admin.RetryOutboxEntry(outboxService)).
+		Methods("PUT").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/outbox/discard", admin.DiscardOutboxEntry(outboxService)).
+		Methods("PUT").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/dead-letter", admin.DeadLetters(deadLetterService)).
+		Methods("GET")
+
+	router.
+		HandleFunc("/dead-letter/replay", admin.ReplayDeadLetter(deadLetterService)).
+		Methods("PUT").
+		Headers("Content-Type", "application/x-www-form-urlencoded")
+
+	router.
+		HandleFunc("/dead-letter/discard", admin.DiscardDeadLetter(deadLetterService)).
 		Methods("PUT").
 		Headers("Content-Type", "application/x-www-form-urlencoded")
 
+	router.
+		HandleFunc("/events", admin.Events(eventStoreService)).
+		Methods("GET")
+
+	router.
+		HandleFunc("/archive", admin.Archive(gameService, tableService)).
+		Methods("GET")
+
 	abs, err := filepath.Abs("./admin/static")
 
 	if err != nil {
@@ -267,7 +627,7 @@ func setupRoutes(
 
 	// --- User-Ui Dashboard (Angular App) ---
 	router.
-		HandleFunc("/user", dashboard.Post(userService)).
+		HandleFunc("/user", dashboard.Post(userService, tableService)).
 		Methods("POST").
 		Headers("Content-Type", "application/json")
 
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"louie-web-administrator/configuration"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/service"
+	"os"
+	"time"
+)
+
+// runReplay re-reads the louie events from the kafka topics or an event archive and re-runs the game state
+// transitions against a fresh database. Without target database, the replay is a dry-run with the in-memory
+// repositories. Afterwards the replayed user statistics are compared with the current registered users.
+func runReplay(ctx context.Context, cfg *configuration.Config, args []string) {
+
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	fromOffset := flags.Int64("from-offset", 0, "offset of every partition the replay starts with")
+	fromTime := flags.String("from-time", "", "timestamp (RFC3339) the replay starts with, e.g. 2024-05-01T10:00:00Z")
+	archive := flags.String("archive", "", "event archive with one event per line, which is replayed instead of the kafka topics")
+	targetDatabase := flags.String("target-db", "", "database, which is dropped and rebuilt by the replay. Empty for a dry-run")
+
+	_ = flags.Parse(args)
+
+	if *targetDatabase == cfg.Database.DatabaseName {
+		log.Fatalf("the target database must not be the database of the administrator \"%s\"", cfg.Database.DatabaseName)
+	}
+
+	messages, err := readReplayedMessages(ctx, cfg, *fromOffset, *fromTime, *archive)
+
+	if err != nil {
+		log.Fatalf("reading replayed events failed: %s", err)
+	}
+
+	client := connectMongo(ctx, cfg)
+	defer client.Disconnect(ctx)
+
+	currentUsers, err := repository.NewUserRepo(ctx, client, cfg.Database.DatabaseName).GetAll()
+
+	if err != nil {
+		log.Fatalf("reading registered users failed: %s", err)
+	}
+
+	var userRepository repository.UserRepository
+	var gameRepository repository.GameRepository
+	var outboxRepository repository.OutboxRepository
+
+	if *targetDatabase == "" {
+		log.Print("dry-run replay with in-memory repositories")
+		userRepository = repository.NewMemoryUserRepo()
+		outboxRepository = repository.NewMemoryOutboxRepo()
+		gameRepository = repository.NewMemoryGameRepo(outboxRepository)
+	} else {
+		log.Printf("replay into database %s", *targetDatabase)
+		if err := client.Database(*targetDatabase).Drop(ctx); err != nil {
+			log.Fatalf("dropping target database failed: %s", err)
+		}
+		userRepository = repository.NewUserRepo(ctx, client, *targetDatabase)
+		gameRepository = repository.NewGameRepository(ctx, client, *targetDatabase)
+		outboxRepository = repository.NewOutboxRepository(ctx, client, *targetDatabase)
+	}
+
+	replayCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tables, err := service.ParseTables(cfg.Tables)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	replayer := service.NewReplayer(replayCtx, userRepository, gameRepository, outboxRepository, service.NewTableService(tables), cfg.Events.Sender)
+
+	if err := replayer.SeedUsers(currentUsers); err != nil {
+		log.Fatal(err)
+	}
+
+	result := replayer.Replay(messages)
+	log.Printf("replayed %d events, %d processed, %d skipped", len(messages), result.Processed, result.Skipped)
+
+	replayedUsers, err := userRepository.GetAll()
+
+	if err != nil {
+		log.Fatalf("reading replayed users failed: %s", err)
+	}
+
+	if err := service.PrintStatisticDiff(os.Stdout, service.DiffStatistics(currentUsers, replayedUsers)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func readReplayedMessages(ctx context.Context, cfg *configuration.Config, fromOffset int64, fromTime string, archive string) ([]louie_kafka.Message, error) {
+
+	if archive != "" {
+		archiveFile, err := os.Open(archive)
+
+		if err != nil {
+			return nil, err
+		}
+		defer archiveFile.Close()
+
+		return louie_kafka.ReadArchive(archiveFile)
+	}
+
+	if cfg.MessageBus.Transport != configuration.KafkaMessageBus {
+		return nil, fmt.Errorf("the message bus \"%s\" can not be replayed, use an event archive", cfg.MessageBus.Transport)
+	}
+
+	start := louie_kafka.ReplayStart{Offset: fromOffset}
+
+	if fromTime != "" {
+		startTime, err := time.Parse(time.RFC3339, fromTime)
+
+		if err != nil {
+			return nil, err
+		}
+
+		start.Time = startTime
+	}
+
+	return louie_kafka.ReadTopics(
+		ctx,
+		fmt.Sprintf("%s:%s", cfg.Kafka.Server, cfg.Kafka.Port),
+		setupTopics(cfg).ReplayedTopics(),
+		start,
+	)
+}
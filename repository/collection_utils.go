@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -31,3 +32,36 @@ func existsCollection(database *mongo.Database, collectionName string) (bool, *m
 
 	return false, nil
 }
+
+// withTransaction runs the operations in a mongo transaction. Standalone mongo servers (like the
+// one on our raspberry) do not support transactions. Then the operations run without a transaction
+// and compensate is called, if one of them fails.
+func withTransaction(ctx context.Context, client *mongo.Client, operations func(ctx context.Context) error, compensate func()) error {
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionContext mongo.SessionContext) (interface{}, error) {
+		return nil, operations(sessionContext)
+	})
+
+	if err == nil || !isTransactionNotSupported(err) {
+		return err
+	}
+
+	if err = operations(ctx); err != nil {
+		compensate()
+		return err
+	}
+
+	return nil
+}
+
+func isTransactionNotSupported(err error) bool {
+	var commandError mongo.CommandError
+
+	// 20 = IllegalOperation: "Transaction numbers are only allowed on a replica set member or mongos"
+	return errors.As(err, &commandError) && commandError.Code == 20
+}
@@ -9,4 +9,20 @@ const KiName = "Louki"
 const Player1CoinMarker = "player_1_coins"
 const Player2CoinMarker = "player_2_coins"
 const Player3CoinMarker = "player_3_coins"
+const Player4CoinMarker = "player_4_coins"
 const KiCoinMarker = "ki_coins"
+
+const OutboxCollection = "outbox"
+
+const DeadLettersCollection = "deadLetters"
+
+const EventsCollection = "events"
+
+const MatchesCollection = "matches"
+
+// DefaultSeats and DefaultStartingCoins are the settings of a game, which were fixed before they were configurable.
+// Without the ki, a game has one more seat.
+const DefaultSeats = 3
+const DefaultStartingCoins = 3
+const MaxSeatsWithKi = 3
+const MaxSeats = 4
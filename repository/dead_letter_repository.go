@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+type DeadLetterRepository interface {
+	Add(entry DeadLetterEntity) (*primitive.ObjectID, error)
+	Get(id string) (*DeadLetterEntity, error)
+	GetOpen(limit int64) ([]DeadLetterEntity, error)
+	CountOpen() (int64, error)
+	UpdateStatus(id string, status DeadLetterStatus) error
+}
+
+type DeadLetterRepo struct {
+	collection *mongo.Collection
+}
+
+type DeadLetterStatus string
+
+const (
+	DeadLetterOpen      DeadLetterStatus = "open"
+	DeadLetterReplayed  DeadLetterStatus = "replayed"
+	DeadLetterDiscarded DeadLetterStatus = "discarded"
+)
+
+// DeadLetterEntity is a consumed louie event, which could not be processed. The raw payload is
+// kept, so the admin can replay the event.
+type DeadLetterEntity struct {
+	Id        primitive.ObjectID `bson:"_id"`
+	Payload   string             `bson:"payload"`
+	Reason    string             `bson:"reason"`
+	Detail    string             `bson:"detail"`
+	Status    DeadLetterStatus   `bson:"status"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func NewDeadLetterEntity(payload []byte, reason string, detail string) DeadLetterEntity {
+	return DeadLetterEntity{
+		Id:        primitive.NewObjectID(),
+		Payload:   string(payload),
+		Reason:    reason,
+		Detail:    detail,
+		Status:    DeadLetterOpen,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+func NewDeadLetterRepository(ctx context.Context, client *mongo.Client, databaseName string) *DeadLetterRepo {
+	database := client.Database(databaseName)
+	exists, existingCollection := existsCollection(database, DeadLettersCollection)
+
+	if exists == true {
+		log.Printf("dead letters collection exists \n")
+		return &DeadLetterRepo{collection: existingCollection}
+	}
+
+	err := database.CreateCollection(ctx, DeadLettersCollection)
+
+	if err != nil {
+		log.Fatal(fmt.Sprintf("can not create dead letters collection: %s", err))
+	}
+
+	collection := database.Collection(DeadLettersCollection)
+
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+
+	if err != nil {
+		log.Fatal(fmt.Sprintf("can not create dead letters collection status index: %s", err))
+	}
+
+	return &DeadLetterRepo{collection: collection}
+}
+
+func (config *DeadLetterRepo) Add(entry DeadLetterEntity) (*primitive.ObjectID, error) {
+	ctx := context.Background()
+
+	result, err := config.collection.InsertOne(ctx, &entry)
+
+	if err != nil {
+		log.Printf("saving dead letter failed %s\n", err)
+		return nil, err
+	}
+
+	entryId, ok := result.InsertedID.(primitive.ObjectID)
+
+	if !ok {
+		return nil, errors.New("can not cast returned id to mongo primitive object id")
+	}
+
+	return &entryId, nil
+}
+
+func (config *DeadLetterRepo) Get(id string) (*DeadLetterEntity, error) {
+	ctx := context.Background()
+
+	parsedId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("can not parse a not valid dead letter id %s\n", err)
+		return nil, err
+	}
+
+	var result DeadLetterEntity
+
+	err = config.collection.FindOne(ctx, bson.M{"_id": parsedId}).Decode(&result)
+
+	if err != nil {
+		log.Printf("can not find dead letter %s: %s\n", id, err)
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (config *DeadLetterRepo) GetOpen(limit int64) ([]DeadLetterEntity, error) {
+	ctx := context.Background()
+	entries := make([]DeadLetterEntity, 0)
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(limit)
+
+	cursor, err := config.collection.Find(ctx, bson.M{"status": DeadLetterOpen}, findOptions)
+
+	if err != nil {
+		log.Printf("some error occured during get open dead letters: %s\n", err)
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var entry DeadLetterEntity
+		if err := cursor.Decode(&entry); err != nil {
+			log.Printf("some error occured during decoding dead letters received from mongo db: %s\n", err)
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (config *DeadLetterRepo) CountOpen() (int64, error) {
+	ctx := context.Background()
+
+	count, err := config.collection.CountDocuments(ctx, bson.M{"status": DeadLetterOpen})
+
+	if err != nil {
+		log.Printf("some error occured during count open dead letters: %s\n", err)
+		return -1, err
+	}
+
+	return count, nil
+}
+
+func (config *DeadLetterRepo) UpdateStatus(id string, status DeadLetterStatus) error {
+	ctx := context.Background()
+
+	parsedId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("can not parse a not valid dead letter id %s\n", err)
+		return err
+	}
+
+	_, err = config.collection.UpdateOne(ctx, bson.M{"_id": parsedId}, bson.M{"$set": bson.M{"status": status}})
+
+	if err != nil {
+		log.Printf("some error occured during update dead letter %s to %s: %s\n", id, status, err)
+		return err
+	}
+
+	return nil
+}
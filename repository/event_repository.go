@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+type EventRepository interface {
+	Add(event EventEntity) error
+	Find(filter EventFilter, limit int64) ([]EventEntity, error)
+	RemoveOlderThan(createdBefore time.Time) (int64, error)
+	RemoveEventOlderThan(event string, createdBefore time.Time) (int64, error)
+}
+
+type EventRepo struct {
+	collection *mongo.Collection
+}
+
+// EventEntity is a consumed or published louie event. It is the audit trail of the games.
+type EventEntity struct {
+	Id        primitive.ObjectID `bson:"_id"`
+	EventId   string             `bson:"event_id"`
+	Event     string             `bson:"event"`
+	Direction string             `bson:"direction"`
+	Topic     string             `bson:"topic"`
+	Sender    string             `bson:"sender"`
+	GameId    string             `bson:"game_id"`
+	Outcome   string             `bson:"outcome"`
+	Detail    string             `bson:"detail"`
+	Payload   string             `bson:"payload"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// EventFilter restricts the events by game id and event type. Empty fields do not filter.
+type EventFilter struct {
+	GameId string
+	Event  string
+}
+
+func NewEventRepository(ctx context.Context, client *mongo.Client, databaseName string) *EventRepo {
+	database := client.Database(databaseName)
+	exists, existingCollection := existsCollection(database, EventsCollection)
+
+	if exists == true {
+		log.Printf("events collection exists \n")
+		return &EventRepo{collection: existingCollection}
+	}
+
+	err := database.CreateCollection(ctx, EventsCollection)
+
+	if err != nil {
+		log.Fatal(fmt.Sprintf("can not create events collection: %s", err))
+	}
+
+	collection := database.Collection(EventsCollection)
+
+	_, err = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "game_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+
+	if err != nil {
+		log.Fatal(fmt.Sprintf("can not create events collection indexes: %s", err))
+	}
+
+	return &EventRepo{collection: collection}
+}
+
+func (config *EventRepo) Add(event EventEntity) error {
+	ctx := context.Background()
+
+	_, err := config.collection.InsertOne(ctx, &event)
+
+	if err != nil {
+		log.Printf("saving event failed %s\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// Find returns the newest events first.
+func (config *EventRepo) Find(filter EventFilter, limit int64) ([]EventEntity, error) {
+	ctx := context.Background()
+	events := make([]EventEntity, 0)
+
+	query := bson.M{}
+
+	if filter.GameId != "" {
+		query["game_id"] = filter.GameId
+	}
+
+	if filter.Event != "" {
+		query["event"] = filter.Event
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(limit)
+
+	cursor, err := config.collection.Find(ctx, query, findOptions)
+
+	if err != nil {
+		log.Printf("some error occured during find events: %s\n", err)
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var event EventEntity
+		if err := cursor.Decode(&event); err != nil {
+			log.Printf("some error occured during decoding events received from mongo db: %s\n", err)
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// RemoveEventOlderThan removes the events of one type, e.g. the heartbeats, which are kept shorter.
+func (config *EventRepo) RemoveEventOlderThan(event string, createdBefore time.Time) (int64, error) {
+	ctx := context.Background()
+
+	result, err := config.collection.DeleteMany(ctx, bson.M{"event": event, "created_at": bson.M{"$lt": createdBefore}})
+
+	if err != nil {
+		log.Printf("some error occured during remove %s events older than %s: %s\n", event, createdBefore, err)
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+func (config *EventRepo) RemoveOlderThan(createdBefore time.Time) (int64, error) {
+	ctx := context.Background()
+
+	result, err := config.collection.DeleteMany(ctx, bson.M{"created_at": bson.M{"$lt": createdBefore}})
+
+	if err != nil {
+		log.Printf("some error occured during remove events older than %s: %s\n", createdBefore, err)
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+func (s *RepositoryTestSuite) Test_FindEvents_FilteredByGameAndEvent() {
+
+	eventRepository := NewEventRepository(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	for i, event := range []EventEntity{
+		{Event: "PLAYERS_READY", GameId: "game-1", Direction: "outbound"},
+		{Event: "COIN_DROP", GameId: "game-1", Direction: "inbound"},
+		{Event: "COIN_DROP", GameId: "game-2", Direction: "inbound"},
+	} {
+		event.Id = primitive.NewObjectID()
+		event.CreatedAt = now.Add(time.Duration(i) * time.Second)
+		assert.NoError(s.T(), eventRepository.Add(event))
+	}
+
+	events, err := eventRepository.Find(EventFilter{GameId: "game-1"}, 10)
+
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), events, 2)
+	assert.Equal(s.T(), "COIN_DROP", events[0].Event)
+
+	events, err = eventRepository.Find(EventFilter{Event: "COIN_DROP"}, 1)
+
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), events, 1)
+	assert.Equal(s.T(), "game-2", events[0].GameId)
+}
+
+func (s *RepositoryTestSuite) Test_RemoveEventsOlderThan() {
+
+	eventRepository := NewEventRepository(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
+
+	now := time.Now().UTC()
+
+	assert.NoError(s.T(), eventRepository.Add(EventEntity{Id: primitive.NewObjectID(), Event: "COIN_DROP", CreatedAt: now.Add(-48 * time.Hour)}))
+	assert.NoError(s.T(), eventRepository.Add(EventEntity{Id: primitive.NewObjectID(), Event: "GAME_DONE", CreatedAt: now}))
+
+	removed, err := eventRepository.RemoveOlderThan(now.Add(-24 * time.Hour))
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(1), removed)
+
+	events, _ := eventRepository.Find(EventFilter{}, 10)
+
+	assert.Len(s.T(), events, 1)
+	assert.Equal(s.T(), "GAME_DONE", events[0].Event)
+}
+
+func (s *RepositoryTestSuite) Test_RemoveHeartbeatsOlderThan() {
+
+	eventRepository := NewEventRepository(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
+
+	now := time.Now().UTC()
+
+	assert.NoError(s.T(), eventRepository.Add(EventEntity{Id: primitive.NewObjectID(), Event: "HEARTBEAT", CreatedAt: now.Add(-48 * time.Hour)}))
+	assert.NoError(s.T(), eventRepository.Add(EventEntity{Id: primitive.NewObjectID(), Event: "COIN_DROP", CreatedAt: now.Add(-48 * time.Hour)}))
+
+	removed, err := eventRepository.RemoveEventOlderThan("HEARTBEAT", now.Add(-24*time.Hour))
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(1), removed)
+
+	events, _ := eventRepository.Find(EventFilter{}, 10)
+
+	assert.Len(s.T(), events, 1)
+	assert.Equal(s.T(), "COIN_DROP", events[0].Event)
+}
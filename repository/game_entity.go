@@ -2,6 +2,8 @@ package repository
 
 import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strconv"
+	"time"
 )
 
 type GameState string
@@ -9,6 +11,8 @@ type GameState string
 type GameEntity struct {
 	Id       primitive.ObjectID `bson:"_id"`
 	Duration float64            `bson:"duration"`
+	// Table is the sender of the louie table, the game is played on.
+	Table string `bson:"table"`
 
 	KiName  string `bson:"ki_name"`
 	KiCoins int    `bson:"ki_coins"`
@@ -22,7 +26,143 @@ type GameEntity struct {
 	Player3      string `bson:"player_3"`
 	Player3Coins int    `bson:"player_3_coins"`
 
+	Player4      string `bson:"player_4"`
+	Player4Coins int    `bson:"player_4_coins"`
+
+	// Seats is the number of human players and StartingCoins are the coins of every participant at the start. Both
+	// are 0 for games, which were created before they were configurable.
+	Seats         int `bson:"seats,omitempty"`
+	StartingCoins int `bson:"starting_coins,omitempty"`
+
 	State GameState
+	// AbortReason tells, why an aborted game ended without result.
+	AbortReason string `bson:"abort_reason,omitempty"`
+	// Winner is the display name of the winning player of a finished game.
+	Winner string `bson:"winner,omitempty"`
+
+	// CreatedAt is zero for games, which were created before the archive existed. FinishedAt is set, when the
+	// game is finished or aborted.
+	CreatedAt  time.Time  `bson:"created_at,omitempty"`
+	FinishedAt *time.Time `bson:"finished_at,omitempty"`
+
+	// LastActivityAt is the time of the last COIN_DROP, which changes the game without entry in the history.
+	LastActivityAt time.Time `bson:"last_activity_at,omitempty"`
+
+	// ProcessedEvents are the ids of the louie events, which changed the game. Redelivered events are skipped.
+	ProcessedEvents []string `bson:"processed_events"`
+
+	// History lists the state transitions of the game in the order they happened, including the
+	// rejected ones.
+	History []GameTransition `bson:"history,omitempty"`
+}
+
+// GameTransition is one entry of the game history. Rejected transitions keep the state of the game,
+// so From and To are equal and Detail tells the reason.
+type GameTransition struct {
+	From      GameState `bson:"from"`
+	To        GameState `bson:"to"`
+	Event     string    `bson:"event"`
+	Rejected  bool      `bson:"rejected"`
+	Detail    string    `bson:"detail,omitempty"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// GameSettings are chosen, when a game is announced.
+type GameSettings struct {
+	// Seats is the number of human players.
+	Seats         int
+	StartingCoins int
+	// WithoutKi lets the ki of the table sit out, e.g. for four human players or a maintenance of the ki.
+	WithoutKi bool
+}
+
+func DefaultGameSettings() GameSettings {
+	return GameSettings{Seats: DefaultSeats, StartingCoins: DefaultStartingCoins}
+}
+
+// newGame seats the members by their position. Members without a position within the seats are not seated.
+func newGame(table string, kiName string, settings GameSettings, gameMembers []RegisteredUser) GameEntity {
+
+	var game = GameEntity{
+		Id:            primitive.NewObjectID(),
+		Table:         table,
+		KiName:        kiName,
+		State:         GameAnnounced,
+		Duration:      InitialGameDuration,
+		Seats:         settings.Seats,
+		StartingCoins: settings.StartingCoins,
+		CreatedAt:     time.Now(),
+	}
+
+	game.KiCoins = game.coinsOfSeat(game.KiName)
+
+	for _, member := range gameMembers {
+		if seat, err := strconv.Atoi(member.Pos); err == nil && seat <= game.SeatsOrDefault() {
+			game.TakeSeat(seat, member.DisplayName)
+		}
+	}
+
+	return game
+}
+
+// TakeSeat puts the player with the starting coins on the seat 1 to 4.
+func (game *GameEntity) TakeSeat(seat int, player string) {
+	switch seat {
+	case 1:
+		game.Player1, game.Player1Coins = player, game.coinsOfSeat(player)
+	case 2:
+		game.Player2, game.Player2Coins = player, game.coinsOfSeat(player)
+	case 3:
+		game.Player3, game.Player3Coins = player, game.coinsOfSeat(player)
+	case 4:
+		game.Player4, game.Player4Coins = player, game.coinsOfSeat(player)
+	}
+}
+
+// resetGame moves the game back to "announced" with the starting coins of the seated players.
+func resetGame(game *GameEntity) {
+
+	game.State = GameAnnounced
+	game.Duration = InitialGameDuration
+	game.KiCoins = game.coinsOfSeat(game.KiName)
+	game.Player1Coins = game.coinsOfSeat(game.Player1)
+	game.Player2Coins = game.coinsOfSeat(game.Player2)
+	game.Player3Coins = game.coinsOfSeat(game.Player3)
+	game.Player4Coins = game.coinsOfSeat(game.Player4)
+	game.ProcessedEvents = []string{}
+}
+
+// SeatsOrDefault falls back to DefaultSeats for games, which were created before the seats were configurable.
+func (game *GameEntity) SeatsOrDefault() int {
+	if game.Seats == 0 {
+		return DefaultSeats
+	}
+
+	return game.Seats
+}
+
+// StartingCoinsOrDefault falls back to DefaultStartingCoins for games, which were created before the starting
+// coins were configurable.
+func (game *GameEntity) StartingCoinsOrDefault() int {
+	if game.StartingCoins == 0 {
+		return DefaultStartingCoins
+	}
+
+	return game.StartingCoins
+}
+
+// IsOver tells, whether the game ended with or without result. Games, which are not over, are running.
+func (c GameState) IsOver() bool {
+	return c == GameFinished || c == GameAborted
+}
+
+// coinsOfSeat are the starting coins of the player. An empty seat has no coins.
+func (game *GameEntity) coinsOfSeat(player string) int {
+	if player == "" {
+		return 0
+	}
+
+	return game.StartingCoinsOrDefault()
 }
 
 func (c GameState) String() string {
@@ -34,4 +174,24 @@ const (
 	GameReady     GameState = "ready"
 	GameActive    GameState = "active"
 	GameFinished  GameState = "finished"
+	// GamePaused interrupts an active game. The game clock stops and coin drops are not accepted.
+	GamePaused GameState = "paused"
+	// GameAborted ends a game without result. The statistics of the players are not touched.
+	GameAborted GameState = "aborted"
 )
+
+// GameStates returns all states of a game, e.g. for filters in the admin ui.
+func GameStates() []GameState {
+	return []GameState{GameAnnounced, GameReady, GameActive, GamePaused, GameFinished, GameAborted}
+}
+
+// runningGameStates are the states of a game, which is not over yet.
+var runningGameStates = []GameState{GameAnnounced, GameReady, GameActive, GamePaused}
+
+// GameFilter restricts the archived games. Empty fields do not filter.
+type GameFilter struct {
+	Table string
+	State GameState
+	// Player is the display name of one of the seated players.
+	Player string
+}
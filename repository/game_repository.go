@@ -7,69 +7,74 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
+	"time"
 )
 
 type GameRepository interface {
-	CreateGame(gameMembers []RegisteredUser) (*primitive.ObjectID, error)
-	GetCurrent() (*GameEntity, error)
+	CreateGame(table string, kiName string, settings GameSettings, gameMembers []RegisteredUser) (*primitive.ObjectID, error)
+	InsertGame(game GameEntity) error
+	Get(gameId string) (*GameEntity, error)
+	GetCurrent(table string) (*GameEntity, error)
+	Find(filter GameFilter, page int64, pageSize int64) ([]GameEntity, error)
+	Count(filter GameFilter) (int64, error)
+	AssignMissingTable(table string) error
 	RemoveGame(gameId string) (*mongo.DeleteResult, error)
 	UpdateState(gameId string, state GameState) (*GameEntity, error)
+	UpdateStateWithOutboxEntry(gameId string, state GameState, entry OutboxEntity) (*GameEntity, error)
+	Reset(gameId string) (*GameEntity, error)
+	ResetWithOutboxEntry(gameId string, entry OutboxEntity) (*GameEntity, error)
+	AbortWithOutboxEntry(gameId string, reason string, entry OutboxEntity) (*GameEntity, error)
+	Finish(gameId string, duration float64, winner string) (*GameEntity, error)
 	UpdateDuration(gameId string, duration float64) (*GameEntity, error)
 	UpdateCoins(gameId string, playerCoinMarker string, coins int) (*GameEntity, error)
+	AddProcessedEvent(gameId string, eventId string) error
+	AddTransition(gameId string, transition GameTransition) error
 }
 
 type GameRepo struct {
-	collection *mongo.Collection
+	collection       *mongo.Collection
+	outboxCollection *mongo.Collection
 }
 
 func NewGameRepository(ctx context.Context, client *mongo.Client, databaseName string) *GameRepo {
 
 	database := client.Database(databaseName)
 
-	exists, existingCollection := existsCollection(database, GamesCollection)
+	exists, collection := existsCollection(database, GamesCollection)
 
 	if exists == true {
 		log.Printf("games collection exists \n")
-		return &GameRepo{collection: existingCollection}
+	} else {
+		err := database.CreateCollection(ctx, GamesCollection)
+
+		if err != nil {
+			log.Fatal(fmt.Sprintf("can not create games collection: %s", err))
+		}
+
+		collection = database.Collection(GamesCollection)
 	}
 
-	err := database.CreateCollection(ctx, GamesCollection)
+	// the indexes are created for existing collections too, which were created before the archive. Creating an
+	// existing index does nothing.
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "table", Value: 1}, {Key: "state", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	})
 
 	if err != nil {
-		log.Fatal(fmt.Sprintf("can not create games collection: %s", err))
+		log.Fatal(fmt.Sprintf("can not create games collection indexes: %s", err))
 	}
 
-	collection := client.Database(databaseName).Collection(GamesCollection)
-
-	return &GameRepo{collection: collection}
+	return &GameRepo{collection: collection, outboxCollection: database.Collection(OutboxCollection)}
 }
 
-func (config *GameRepo) CreateGame(gameMembers []RegisteredUser) (*primitive.ObjectID, error) {
+func (config *GameRepo) CreateGame(table string, kiName string, settings GameSettings, gameMembers []RegisteredUser) (*primitive.ObjectID, error) {
 
 	ctx := context.Background()
 
-	var game = GameEntity{
-		Id:       primitive.NewObjectID(),
-		KiName:   KiName,
-		KiCoins:  3,
-		State:    GameAnnounced,
-		Duration: InitialGameDuration,
-	}
-
-	for _, member := range gameMembers {
-		switch member.Pos {
-		case "1":
-			game.Player1 = member.DisplayName
-			game.Player1Coins = 3
-		case "2":
-			game.Player2 = member.DisplayName
-			game.Player2Coins = 3
-		case "3":
-			game.Player3 = member.DisplayName
-			game.Player3Coins = 3
-		}
-	}
+	var game = newGame(table, kiName, settings, gameMembers)
 
 	result, err := config.collection.InsertOne(ctx, &game)
 	if err != nil {
@@ -86,12 +91,61 @@ func (config *GameRepo) CreateGame(gameMembers []RegisteredUser) (*primitive.Obj
 	return &gameId, nil
 }
 
-func (config *GameRepo) GetCurrent() (*GameEntity, error) {
+// InsertGame stores the game as it is, including its id. It is used to rebuild games during a replay.
+func (config *GameRepo) InsertGame(game GameEntity) error {
+
+	ctx := context.Background()
+
+	_, err := config.collection.InsertOne(ctx, &game)
+	if err != nil {
+		log.Printf("inserting game %s failed %s\n", game.Id.Hex(), err)
+		return err
+	}
+
+	return nil
+}
+
+func (config *GameRepo) Get(gameId string) (*GameEntity, error) {
 
 	ctx := context.Background()
 	var result GameEntity
 
-	game := config.collection.FindOne(ctx, bson.D{})
+	parsedId, err := primitive.ObjectIDFromHex(gameId)
+
+	if err != nil {
+		log.Printf("can not parse a not valid game id %s\n", err)
+		return nil, err
+	}
+
+	game := config.collection.FindOne(ctx, bson.M{"_id": parsedId})
+
+	if errors.Is(game.Err(), mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+
+	err = game.Decode(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetCurrent returns the running game of the table. If no game is running, the latest finished or aborted game
+// is returned, so its result stays visible until the next game is announced.
+func (config *GameRepo) GetCurrent(table string) (*GameEntity, error) {
+
+	ctx := context.Background()
+	var result GameEntity
+
+	latestFirst := options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}})
+
+	game := config.collection.FindOne(ctx, bson.M{"table": table, "state": bson.M{"$in": runningGameStates}}, latestFirst)
+
+	if errors.Is(game.Err(), mongo.ErrNoDocuments) {
+		game = config.collection.FindOne(ctx, bson.M{"table": table}, latestFirst)
+	}
 
 	if errors.Is(game.Err(), mongo.ErrNoDocuments) {
 		return nil, nil
@@ -106,6 +160,94 @@ func (config *GameRepo) GetCurrent() (*GameEntity, error) {
 	return &result, nil
 }
 
+// Find returns one page of the games, which match the filter. The latest game comes first.
+func (config *GameRepo) Find(filter GameFilter, page int64, pageSize int64) ([]GameEntity, error) {
+
+	ctx := context.Background()
+	games := make([]GameEntity, 0)
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetSkip((page - 1) * pageSize).
+		SetLimit(pageSize)
+
+	cursor, err := config.collection.Find(ctx, gameFilterQuery(filter), findOptions)
+
+	if err != nil {
+		log.Printf("some error occured during find games: %s\n", err)
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var game GameEntity
+		if err := cursor.Decode(&game); err != nil {
+			log.Printf("some error occured during decoding games received from mongo db: %s\n", err)
+			return nil, err
+		}
+		games = append(games, game)
+	}
+
+	return games, nil
+}
+
+func (config *GameRepo) Count(filter GameFilter) (int64, error) {
+
+	ctx := context.Background()
+
+	count, err := config.collection.CountDocuments(ctx, gameFilterQuery(filter))
+
+	if err != nil {
+		log.Printf("some error occured during count games: %s\n", err)
+		return -1, err
+	}
+
+	return count, nil
+}
+
+func gameFilterQuery(filter GameFilter) bson.M {
+
+	query := bson.M{}
+
+	if filter.Table != "" {
+		query["table"] = filter.Table
+	}
+
+	if filter.State != "" {
+		query["state"] = filter.State
+	}
+
+	if filter.Player != "" {
+		query["$or"] = []bson.M{
+			{"player1": filter.Player},
+			{"player_2": filter.Player},
+			{"player_3": filter.Player},
+			{"player_4": filter.Player},
+		}
+	}
+
+	return query
+}
+
+// AssignMissingTable moves the games, which were created before tables existed, to the given table.
+func (config *GameRepo) AssignMissingTable(table string) error {
+
+	ctx := context.Background()
+
+	filter := bson.M{"table": bson.M{"$in": bson.A{nil, ""}}}
+	update := bson.M{"$set": bson.M{"table": table}}
+
+	_, err := config.collection.UpdateMany(ctx, filter, update)
+
+	if err != nil {
+		log.Printf("some error occured during assigning games to table %s: %s\n", table, err)
+		return err
+	}
+
+	return nil
+}
+
 func (config *GameRepo) RemoveGame(gameId string) (*mongo.DeleteResult, error) {
 
 	ctx := context.Background()
@@ -143,8 +285,51 @@ func (config *GameRepo) UpdateState(gameId string, state GameState) (*GameEntity
 		return nil, err
 	}
 
-	game, err := config.GetCurrent()
+	game, err := config.Get(gameId)
+
+	if err != nil {
+		log.Printf("after updating game state, receiving of current game failed: %s\n", err)
+		return nil, err
+	}
+
+	return game, nil
+}
+
+// UpdateStateWithOutboxEntry changes the game state and stores the outgoing event, which belongs to
+// the state change, in one step.
+func (config *GameRepo) UpdateStateWithOutboxEntry(gameId string, state GameState, entry OutboxEntity) (*GameEntity, error) {
+	ctx := context.Background()
+
+	parsedId, err := primitive.ObjectIDFromHex(gameId)
+	if err != nil {
+		log.Printf("can not parse a not valid game id %s\n", err)
+		return nil, err
+	}
+
+	filter := bson.M{"_id": parsedId}
+	update := bson.M{"$set": bson.M{"state": state}}
+
+	err = withTransaction(ctx, config.collection.Database().Client(),
+		func(ctx context.Context) error {
+			if _, err := config.outboxCollection.InsertOne(ctx, &entry); err != nil {
+				return err
+			}
+
+			_, err := config.collection.UpdateOne(ctx, filter, update)
+			return err
+		},
+		func() {
+			if _, err := config.outboxCollection.DeleteOne(context.Background(), bson.M{"_id": entry.Id}); err != nil {
+				log.Printf("removing outbox entry %s after failed game state update failed: %s\n", entry.Id.Hex(), err)
+			}
+		})
+
+	if err != nil {
+		log.Printf("some error occured during update game state to %s with outbox event %s of game %s: %s\n", state, entry.Event, gameId, err)
+		return nil, err
+	}
 
+	game, err := config.Get(gameId)
 	if err != nil {
 		log.Printf("after updating game state, receiving of current game failed: %s\n", err)
 		return nil, err
@@ -153,6 +338,188 @@ func (config *GameRepo) UpdateState(gameId string, state GameState) (*GameEntity
 	return game, nil
 }
 
+// Reset moves the game back to "announced" with the starting coins. The processed events are removed, so
+// louie can start the round again.
+func (config *GameRepo) Reset(gameId string) (*GameEntity, error) {
+
+	ctx := context.Background()
+
+	filter, update, err := config.resetUpdate(ctx, gameId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = config.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		log.Printf("some error occured during reset of game %s: %s\n", gameId, err)
+		return nil, err
+	}
+
+	game, err := config.Get(gameId)
+
+	if err != nil {
+		log.Printf("after resetting game, receiving of current game failed: %s\n", err)
+		return nil, err
+	}
+
+	return game, nil
+}
+
+// ResetWithOutboxEntry resets the game and stores the outgoing RESET_GAME event in one step.
+func (config *GameRepo) ResetWithOutboxEntry(gameId string, entry OutboxEntity) (*GameEntity, error) {
+
+	ctx := context.Background()
+
+	filter, update, err := config.resetUpdate(ctx, gameId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = withTransaction(ctx, config.collection.Database().Client(),
+		func(ctx context.Context) error {
+			if _, err := config.outboxCollection.InsertOne(ctx, &entry); err != nil {
+				return err
+			}
+
+			_, err := config.collection.UpdateOne(ctx, filter, update)
+			return err
+		},
+		func() {
+			if _, err := config.outboxCollection.DeleteOne(context.Background(), bson.M{"_id": entry.Id}); err != nil {
+				log.Printf("removing outbox entry %s after failed game reset failed: %s\n", entry.Id.Hex(), err)
+			}
+		})
+
+	if err != nil {
+		log.Printf("some error occured during reset with outbox event %s of game %s: %s\n", entry.Event, gameId, err)
+		return nil, err
+	}
+
+	game, err := config.Get(gameId)
+
+	if err != nil {
+		log.Printf("after resetting game, receiving of current game failed: %s\n", err)
+		return nil, err
+	}
+
+	return game, nil
+}
+
+// AbortWithOutboxEntry moves the game to "aborted" with the reason and stores the outgoing ABORT_GAME event in
+// one step.
+func (config *GameRepo) AbortWithOutboxEntry(gameId string, reason string, entry OutboxEntity) (*GameEntity, error) {
+
+	ctx := context.Background()
+
+	parsedId, err := primitive.ObjectIDFromHex(gameId)
+
+	if err != nil {
+		log.Printf("can not parse a not valid game id %s\n", err)
+		return nil, err
+	}
+
+	filter := bson.M{"_id": parsedId}
+	update := bson.M{"$set": bson.M{"state": GameAborted, "abort_reason": reason, "finished_at": time.Now()}}
+
+	err = withTransaction(ctx, config.collection.Database().Client(),
+		func(ctx context.Context) error {
+			if _, err := config.outboxCollection.InsertOne(ctx, &entry); err != nil {
+				return err
+			}
+
+			_, err := config.collection.UpdateOne(ctx, filter, update)
+			return err
+		},
+		func() {
+			if _, err := config.outboxCollection.DeleteOne(context.Background(), bson.M{"_id": entry.Id}); err != nil {
+				log.Printf("removing outbox entry %s after failed game abort failed: %s\n", entry.Id.Hex(), err)
+			}
+		})
+
+	if err != nil {
+		log.Printf("some error occured during abort with outbox event %s of game %s: %s\n", entry.Event, gameId, err)
+		return nil, err
+	}
+
+	game, err := config.Get(gameId)
+
+	if err != nil {
+		log.Printf("after aborting game, receiving of current game failed: %s\n", err)
+		return nil, err
+	}
+
+	return game, nil
+}
+
+// Finish moves the game to "finished" with the duration and the winner of the game.
+func (config *GameRepo) Finish(gameId string, duration float64, winner string) (*GameEntity, error) {
+
+	ctx := context.Background()
+
+	parsedId, err := primitive.ObjectIDFromHex(gameId)
+
+	if err != nil {
+		log.Printf("can not parse a not valid game id %s\n", err)
+		return nil, err
+	}
+
+	filter := bson.M{"_id": parsedId}
+	update := bson.M{"$set": bson.M{"state": GameFinished, "duration": duration, "winner": winner, "finished_at": time.Now()}}
+
+	_, err = config.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		log.Printf("some error occured during finishing game %s: %s\n", gameId, err)
+		return nil, err
+	}
+
+	game, err := config.Get(gameId)
+
+	if err != nil {
+		log.Printf("after finishing game, receiving of current game failed: %s\n", err)
+		return nil, err
+	}
+
+	return game, nil
+}
+
+func (config *GameRepo) resetUpdate(ctx context.Context, gameId string) (bson.M, bson.M, error) {
+
+	parsedId, err := primitive.ObjectIDFromHex(gameId)
+
+	if err != nil {
+		log.Printf("can not parse a not valid game id %s\n", err)
+		return nil, nil, err
+	}
+
+	filter := bson.M{"_id": parsedId}
+
+	var game GameEntity
+
+	if err := config.collection.FindOne(ctx, filter).Decode(&game); err != nil {
+		log.Printf("can not find game %s to reset: %s\n", gameId, err)
+		return nil, nil, err
+	}
+
+	resetGame(&game)
+
+	update := bson.M{"$set": bson.M{
+		"state":            game.State,
+		"duration":         game.Duration,
+		KiCoinMarker:       game.KiCoins,
+		Player1CoinMarker:  game.Player1Coins,
+		Player2CoinMarker:  game.Player2Coins,
+		Player3CoinMarker:  game.Player3Coins,
+		Player4CoinMarker:  game.Player4Coins,
+		"processed_events": game.ProcessedEvents,
+	}}
+
+	return filter, update, nil
+}
+
 func (config *GameRepo) UpdateDuration(gameId string, duration float64) (*GameEntity, error) {
 
 	ctx := context.Background()
@@ -174,7 +541,7 @@ func (config *GameRepo) UpdateDuration(gameId string, duration float64) (*GameEn
 		return nil, err
 	}
 
-	game, err := config.GetCurrent()
+	game, err := config.Get(gameId)
 
 	if err != nil {
 		log.Printf("after updating game duration, receiving of current game failed: %s\n", err)
@@ -196,7 +563,7 @@ func (config *GameRepo) UpdateCoins(gameId string, playerCoinMarker string, coin
 	}
 
 	filter := bson.M{"_id": parsedId}
-	update := bson.M{"$set": bson.M{fmt.Sprintf("%s", playerCoinMarker): coins}}
+	update := bson.M{"$set": bson.M{fmt.Sprintf("%s", playerCoinMarker): coins, "last_activity_at": time.Now()}}
 
 	_, err = config.collection.UpdateOne(ctx, filter, update)
 
@@ -205,7 +572,7 @@ func (config *GameRepo) UpdateCoins(gameId string, playerCoinMarker string, coin
 		return nil, err
 	}
 
-	game, err := config.GetCurrent()
+	game, err := config.Get(gameId)
 
 	if err != nil {
 		log.Printf("after updating game coins, receiving of current game failed: %s\n", err)
@@ -214,3 +581,52 @@ func (config *GameRepo) UpdateCoins(gameId string, playerCoinMarker string, coin
 
 	return game, nil
 }
+
+func (config *GameRepo) AddProcessedEvent(gameId string, eventId string) error {
+
+	ctx := context.Background()
+
+	parsedId, err := primitive.ObjectIDFromHex(gameId)
+
+	if err != nil {
+		log.Printf("can not parse a not valid game id %s\n", err)
+		return err
+	}
+
+	filter := bson.M{"_id": parsedId}
+	update := bson.M{"$addToSet": bson.M{"processed_events": eventId}}
+
+	_, err = config.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		log.Printf("some error occured during adding processed event %s to game %s: %s\n", eventId, gameId, err)
+		return err
+	}
+
+	return nil
+}
+
+// AddTransition appends the transition to the history of the game.
+func (config *GameRepo) AddTransition(gameId string, transition GameTransition) error {
+
+	ctx := context.Background()
+
+	parsedId, err := primitive.ObjectIDFromHex(gameId)
+
+	if err != nil {
+		log.Printf("can not parse a not valid game id %s\n", err)
+		return err
+	}
+
+	filter := bson.M{"_id": parsedId}
+	update := bson.M{"$push": bson.M{"history": transition}}
+
+	_, err = config.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		log.Printf("some error occured during adding transition %s of game %s: %s\n", transition.Event, gameId, err)
+		return err
+	}
+
+	return nil
+}
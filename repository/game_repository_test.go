@@ -3,13 +3,14 @@ package repository
 import (
 	"context"
 	"github.com/stretchr/testify/assert"
+	"time"
 )
 
 func (s *RepositoryTestSuite) Test_CreateGame() {
 
 	gameRepository := NewGameRepository(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
 
-	gameId, err := gameRepository.CreateGame([]RegisteredUser{
+	gameId, err := gameRepository.CreateGame("c-library", KiName, DefaultGameSettings(), []RegisteredUser{
 		{
 			DisplayName: "max",
 			Pos:         "1",
@@ -26,21 +27,26 @@ func (s *RepositoryTestSuite) Test_CreateGame() {
 
 	assert.NoError(s.T(), err)
 
-	currentGame, err := gameRepository.GetCurrent()
+	currentGame, err := gameRepository.GetCurrent("c-library")
 	assert.NoError(s.T(), err)
+	assert.WithinDuration(s.T(), time.Now(), currentGame.CreatedAt, time.Minute)
 
 	assert.Equal(s.T(), &GameEntity{
-		Id:           *gameId,
-		Duration:     InitialGameDuration,
-		KiName:       KiName,
-		KiCoins:      3,
-		Player1:      "max",
-		Player1Coins: 3,
-		Player2:      "emil",
-		Player2Coins: 3,
-		Player3:      "andreas",
-		Player3Coins: 3,
-		State:        GameAnnounced,
+		Id:            *gameId,
+		Duration:      InitialGameDuration,
+		Table:         "c-library",
+		KiName:        KiName,
+		KiCoins:       3,
+		Player1:       "max",
+		Player1Coins:  3,
+		Player2:       "emil",
+		Player2Coins:  3,
+		Player3:       "andreas",
+		Player3Coins:  3,
+		State:         GameAnnounced,
+		Seats:         DefaultSeats,
+		StartingCoins: DefaultStartingCoins,
+		CreatedAt:     currentGame.CreatedAt,
 	}, currentGame)
 }
 
@@ -48,24 +54,129 @@ func (s *RepositoryTestSuite) Test_CreateGame_WithoutUsers() {
 
 	gameRepository := NewGameRepository(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
 
-	gameId, err := gameRepository.CreateGame([]RegisteredUser{})
+	gameId, err := gameRepository.CreateGame("c-library", KiName, DefaultGameSettings(), []RegisteredUser{})
 
 	assert.NoError(s.T(), err)
 
-	currentGame, err := gameRepository.GetCurrent()
+	currentGame, err := gameRepository.GetCurrent("c-library")
 	assert.NoError(s.T(), err)
+	assert.WithinDuration(s.T(), time.Now(), currentGame.CreatedAt, time.Minute)
 
 	assert.Equal(s.T(), &GameEntity{
-		Id:           *gameId,
-		Duration:     InitialGameDuration,
-		KiName:       KiName,
-		KiCoins:      3,
-		Player1:      "",
-		Player1Coins: 0,
-		Player2:      "",
-		Player2Coins: 0,
-		Player3:      "",
-		Player3Coins: 0,
-		State:        GameAnnounced,
+		Id:            *gameId,
+		Duration:      InitialGameDuration,
+		Table:         "c-library",
+		KiName:        KiName,
+		KiCoins:       3,
+		Player1:       "",
+		Player1Coins:  0,
+		Player2:       "",
+		Player2Coins:  0,
+		Player3:       "",
+		Player3Coins:  0,
+		State:         GameAnnounced,
+		Seats:         DefaultSeats,
+		StartingCoins: DefaultStartingCoins,
+		CreatedAt:     currentGame.CreatedAt,
 	}, currentGame)
 }
+
+func (s *RepositoryTestSuite) Test_CreateGame_WithSettings() {
+
+	gameRepository := NewGameRepository(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
+
+	gameId, err := gameRepository.CreateGame("c-library-5", KiName, GameSettings{Seats: 2, StartingCoins: 5}, []RegisteredUser{
+		{DisplayName: "max", Pos: "1"},
+		{DisplayName: "emil", Pos: "2"},
+		{DisplayName: "andreas", Pos: "3"},
+	})
+
+	assert.NoError(s.T(), err)
+
+	game, err := gameRepository.Get(gameId.Hex())
+	assert.NoError(s.T(), err)
+
+	assert.Equal(s.T(), 5, game.KiCoins)
+	assert.Equal(s.T(), 5, game.Player1Coins)
+	assert.Equal(s.T(), 5, game.Player2Coins)
+	assert.Equal(s.T(), "", game.Player3)
+	assert.Equal(s.T(), 0, game.Player3Coins)
+	assert.Equal(s.T(), 2, game.Seats)
+}
+
+func (s *RepositoryTestSuite) Test_GetCurrent_PerTable() {
+
+	gameRepository := NewGameRepository(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
+
+	firstTableGameId, err := gameRepository.CreateGame("c-library", KiName, DefaultGameSettings(), []RegisteredUser{})
+	assert.NoError(s.T(), err)
+
+	secondTableGameId, err := gameRepository.CreateGame("c-library-2", "Louki 2", DefaultGameSettings(), []RegisteredUser{})
+	assert.NoError(s.T(), err)
+
+	firstTableGame, err := gameRepository.GetCurrent("c-library")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), *firstTableGameId, firstTableGame.Id)
+
+	secondTableGame, err := gameRepository.GetCurrent("c-library-2")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), *secondTableGameId, secondTableGame.Id)
+	assert.Equal(s.T(), "Louki 2", secondTableGame.KiName)
+}
+
+func (s *RepositoryTestSuite) Test_GetCurrent_PrefersRunningGame() {
+
+	gameRepository := NewGameRepository(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
+
+	runningGameId, err := gameRepository.CreateGame("c-library-3", KiName, DefaultGameSettings(), []RegisteredUser{})
+	assert.NoError(s.T(), err)
+
+	finishedGameId, err := gameRepository.CreateGame("c-library-3", KiName, DefaultGameSettings(), []RegisteredUser{})
+	assert.NoError(s.T(), err)
+
+	_, err = gameRepository.Finish(finishedGameId.Hex(), 42.0, "max")
+	assert.NoError(s.T(), err)
+
+	currentGame, err := gameRepository.GetCurrent("c-library-3")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), *runningGameId, currentGame.Id)
+
+	_, err = gameRepository.UpdateState(runningGameId.Hex(), GameFinished)
+	assert.NoError(s.T(), err)
+
+	currentGame, err = gameRepository.GetCurrent("c-library-3")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), *finishedGameId, currentGame.Id)
+	assert.Equal(s.T(), "max", currentGame.Winner)
+	assert.NotNil(s.T(), currentGame.FinishedAt)
+}
+
+func (s *RepositoryTestSuite) Test_FindGames() {
+
+	gameRepository := NewGameRepository(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
+
+	for i := 0; i < 3; i++ {
+		_, err := gameRepository.CreateGame("c-library-4", KiName, DefaultGameSettings(), []RegisteredUser{{DisplayName: "emil", Pos: "2"}})
+		assert.NoError(s.T(), err)
+	}
+
+	latestGameId, err := gameRepository.CreateGame("c-library-4", KiName, DefaultGameSettings(), []RegisteredUser{{DisplayName: "max", Pos: "1"}})
+	assert.NoError(s.T(), err)
+
+	games, err := gameRepository.Find(GameFilter{Table: "c-library-4"}, 1, 2)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), games, 2)
+	assert.Equal(s.T(), *latestGameId, games[0].Id)
+
+	games, err = gameRepository.Find(GameFilter{Table: "c-library-4"}, 2, 2)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), games, 2)
+
+	count, err := gameRepository.Count(GameFilter{Table: "c-library-4", Player: "emil"})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(3), count)
+
+	count, err = gameRepository.Count(GameFilter{Table: "c-library-4", State: GameFinished})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(0), count)
+}
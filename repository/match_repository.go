@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+type MatchRepository interface {
+	Add(match MatchEntity) (*primitive.ObjectID, error)
+	Get(id string) (*MatchEntity, error)
+	GetQueued(table string) ([]MatchEntity, error)
+	Update(match MatchEntity) error
+}
+
+type MatchRepo struct {
+	collection *mongo.Collection
+}
+
+type MatchState string
+
+const (
+	MatchQueued    MatchState = "queued"
+	MatchAnnounced MatchState = "announced"
+	MatchSkipped   MatchState = "skipped"
+)
+
+// MatchEntity is an upcoming game of a table. The queued matches are announced in the order of their position,
+// the announced and skipped matches are kept.
+type MatchEntity struct {
+	Id    primitive.ObjectID `bson:"_id"`
+	Table string             `bson:"table"`
+	// Players are the display names in the order of their seats.
+	Players       []string   `bson:"players"`
+	StartingCoins int        `bson:"starting_coins"`
+	WithoutKi     bool       `bson:"without_ki"`
+	Position      int64      `bson:"position"`
+	State         MatchState `bson:"state"`
+	// GameId is the game, the match was announced as.
+	GameId    *primitive.ObjectID `bson:"game_id,omitempty"`
+	CreatedAt time.Time           `bson:"created_at"`
+}
+
+func NewMatchEntity(table string, players []string, settings GameSettings, position int64) MatchEntity {
+	return MatchEntity{
+		Id:            primitive.NewObjectID(),
+		Table:         table,
+		Players:       players,
+		StartingCoins: settings.StartingCoins,
+		WithoutKi:     settings.WithoutKi,
+		Position:      position,
+		State:         MatchQueued,
+		CreatedAt:     time.Now().UTC(),
+	}
+}
+
+// Settings of the game of the match. Every player takes a seat.
+func (match *MatchEntity) Settings() GameSettings {
+	return GameSettings{Seats: len(match.Players), StartingCoins: match.StartingCoins, WithoutKi: match.WithoutKi}
+}
+
+func NewMatchRepository(ctx context.Context, client *mongo.Client, databaseName string) *MatchRepo {
+	database := client.Database(databaseName)
+	exists, existingCollection := existsCollection(database, MatchesCollection)
+
+	if exists == true {
+		log.Printf("matches collection exists \n")
+		return &MatchRepo{collection: existingCollection}
+	}
+
+	err := database.CreateCollection(ctx, MatchesCollection)
+
+	if err != nil {
+		log.Fatal(fmt.Sprintf("can not create matches collection: %s", err))
+	}
+
+	collection := database.Collection(MatchesCollection)
+
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "table", Value: 1}, {Key: "state", Value: 1}, {Key: "position", Value: 1}},
+	})
+
+	if err != nil {
+		log.Fatal(fmt.Sprintf("can not create matches collection queue index: %s", err))
+	}
+
+	return &MatchRepo{collection: collection}
+}
+
+func (config *MatchRepo) Add(match MatchEntity) (*primitive.ObjectID, error) {
+	ctx := context.Background()
+
+	result, err := config.collection.InsertOne(ctx, &match)
+
+	if err != nil {
+		log.Printf("saving match failed %s\n", err)
+		return nil, err
+	}
+
+	matchId, ok := result.InsertedID.(primitive.ObjectID)
+
+	if !ok {
+		return nil, errors.New("can not cast returned id to mongo primitive object id")
+	}
+
+	return &matchId, nil
+}
+
+func (config *MatchRepo) Get(id string) (*MatchEntity, error) {
+	ctx := context.Background()
+
+	parsedId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("can not parse a not valid match id %s\n", err)
+		return nil, err
+	}
+
+	var result MatchEntity
+
+	err = config.collection.FindOne(ctx, bson.M{"_id": parsedId}).Decode(&result)
+
+	if err != nil {
+		log.Printf("can not find match %s: %s\n", id, err)
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetQueued returns the queued matches of the table, the next match first.
+func (config *MatchRepo) GetQueued(table string) ([]MatchEntity, error) {
+	ctx := context.Background()
+	matches := make([]MatchEntity, 0)
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "position", Value: 1}})
+
+	cursor, err := config.collection.Find(ctx, bson.M{"table": table, "state": MatchQueued}, findOptions)
+
+	if err != nil {
+		log.Printf("some error occured during get queued matches: %s\n", err)
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var match MatchEntity
+		if err := cursor.Decode(&match); err != nil {
+			log.Printf("some error occured during decoding matches received from mongo db: %s\n", err)
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+
+	return matches, nil
+}
+
+func (config *MatchRepo) Update(match MatchEntity) error {
+	ctx := context.Background()
+
+	_, err := config.collection.ReplaceOne(ctx, bson.M{"_id": match.Id}, &match)
+
+	if err != nil {
+		log.Printf("some error occured during update match %s: %s\n", match.Id.Hex(), err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *RepositoryTestSuite) Test_GetQueuedMatches_OrderedByPosition() {
+
+	matchRepository := NewMatchRepository(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
+
+	secondMatchId, err := matchRepository.Add(NewMatchEntity("c-library", []string{"emil"}, DefaultGameSettings(), 2))
+
+	assert.NoError(s.T(), err)
+
+	firstMatchId, err := matchRepository.Add(NewMatchEntity("c-library", []string{"tobi", "willi"}, GameSettings{StartingCoins: 5}, 1))
+
+	assert.NoError(s.T(), err)
+
+	_, err = matchRepository.Add(NewMatchEntity("c-library-2", []string{"jann"}, DefaultGameSettings(), 0))
+
+	assert.NoError(s.T(), err)
+
+	matches, err := matchRepository.GetQueued("c-library")
+
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), matches, 2)
+	assert.Equal(s.T(), *firstMatchId, matches[0].Id)
+	assert.Equal(s.T(), GameSettings{Seats: 2, StartingCoins: 5}, matches[0].Settings())
+
+	skippedMatch := matches[0]
+	skippedMatch.State = MatchSkipped
+
+	assert.NoError(s.T(), matchRepository.Update(skippedMatch))
+
+	matches, err = matchRepository.GetQueued("c-library")
+
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), matches, 1)
+	assert.Equal(s.T(), *secondMatchId, matches[0].Id)
+
+	match, err := matchRepository.Get(firstMatchId.Hex())
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), MatchSkipped, match.State)
+}
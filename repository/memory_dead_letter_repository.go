@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"sync"
+)
+
+// MemoryDeadLetterRepo keeps the dead letters in memory. It is used for tests and local runs without
+// a mongo db.
+type MemoryDeadLetterRepo struct {
+	entries []DeadLetterEntity
+	mutex   sync.Mutex
+}
+
+func NewMemoryDeadLetterRepo() *MemoryDeadLetterRepo {
+	return &MemoryDeadLetterRepo{entries: make([]DeadLetterEntity, 0)}
+}
+
+func (config *MemoryDeadLetterRepo) Add(entry DeadLetterEntity) (*primitive.ObjectID, error) {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	config.entries = append(config.entries, entry)
+
+	return &entry.Id, nil
+}
+
+func (config *MemoryDeadLetterRepo) Get(id string) (*DeadLetterEntity, error) {
+
+	parsedId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	for _, entry := range config.entries {
+		if entry.Id == parsedId {
+			return &entry, nil
+		}
+	}
+
+	return nil, mongo.ErrNoDocuments
+}
+
+// GetOpen returns the newest open dead letters first.
+func (config *MemoryDeadLetterRepo) GetOpen(limit int64) ([]DeadLetterEntity, error) {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	entries := make([]DeadLetterEntity, 0)
+
+	for i := len(config.entries) - 1; i >= 0 && int64(len(entries)) < limit; i-- {
+		if config.entries[i].Status == DeadLetterOpen {
+			entries = append(entries, config.entries[i])
+		}
+	}
+
+	return entries, nil
+}
+
+func (config *MemoryDeadLetterRepo) CountOpen() (int64, error) {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	var count int64
+
+	for _, entry := range config.entries {
+		if entry.Status == DeadLetterOpen {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (config *MemoryDeadLetterRepo) UpdateStatus(id string, status DeadLetterStatus) error {
+
+	parsedId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return err
+	}
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	for i := range config.entries {
+		if config.entries[i].Id == parsedId {
+			config.entries[i].Status = status
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryEventRepo keeps the events in memory. It is used for tests and local runs without a mongo db.
+type MemoryEventRepo struct {
+	events []EventEntity
+	mutex  sync.Mutex
+}
+
+func NewMemoryEventRepo() *MemoryEventRepo {
+	return &MemoryEventRepo{events: make([]EventEntity, 0)}
+}
+
+func (config *MemoryEventRepo) Add(event EventEntity) error {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	config.events = append(config.events, event)
+
+	return nil
+}
+
+// Find returns the newest events first.
+func (config *MemoryEventRepo) Find(filter EventFilter, limit int64) ([]EventEntity, error) {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	events := make([]EventEntity, 0)
+
+	for i := len(config.events) - 1; i >= 0 && int64(len(events)) < limit; i-- {
+		event := config.events[i]
+
+		if (filter.GameId == "" || event.GameId == filter.GameId) && (filter.Event == "" || event.Event == filter.Event) {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+func (config *MemoryEventRepo) RemoveOlderThan(createdBefore time.Time) (int64, error) {
+	return config.remove(func(event EventEntity) bool {
+		return event.CreatedAt.Before(createdBefore)
+	})
+}
+
+func (config *MemoryEventRepo) RemoveEventOlderThan(eventType string, createdBefore time.Time) (int64, error) {
+	return config.remove(func(event EventEntity) bool {
+		return event.Event == eventType && event.CreatedAt.Before(createdBefore)
+	})
+}
+
+func (config *MemoryEventRepo) remove(expired func(event EventEntity) bool) (int64, error) {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	remaining := make([]EventEntity, 0, len(config.events))
+
+	for _, event := range config.events {
+		if !expired(event) {
+			remaining = append(remaining, event)
+		}
+	}
+
+	removed := int64(len(config.events) - len(remaining))
+	config.events = remaining
+
+	return removed, nil
+}
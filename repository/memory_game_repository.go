@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/thoas/go-funk"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"sync"
+	"time"
+)
+
+// MemoryGameRepo keeps the games in memory. It is used for tests and local runs without a mongo db.
+// Outbox entries of state changes are stored in the given outbox repository.
+type MemoryGameRepo struct {
+	games            []GameEntity
+	outboxRepository OutboxRepository
+	mutex            sync.Mutex
+}
+
+func NewMemoryGameRepo(outboxRepository OutboxRepository) *MemoryGameRepo {
+	return &MemoryGameRepo{games: make([]GameEntity, 0), outboxRepository: outboxRepository}
+}
+
+func (config *MemoryGameRepo) CreateGame(table string, kiName string, settings GameSettings, gameMembers []RegisteredUser) (*primitive.ObjectID, error) {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	var game = newGame(table, kiName, settings, gameMembers)
+
+	config.games = append(config.games, game)
+
+	return &game.Id, nil
+}
+
+func (config *MemoryGameRepo) InsertGame(game GameEntity) error {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	for _, existingGame := range config.games {
+		if existingGame.Id == game.Id {
+			return fmt.Errorf("game %s exists", game.Id.Hex())
+		}
+	}
+
+	config.games = append(config.games, game)
+
+	return nil
+}
+
+func (config *MemoryGameRepo) Get(gameId string) (*GameEntity, error) {
+
+	parsedId, err := primitive.ObjectIDFromHex(gameId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	for _, game := range config.games {
+		if game.Id == parsedId {
+			return &game, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (config *MemoryGameRepo) GetCurrent(table string) (*GameEntity, error) {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	var latest *GameEntity
+
+	for i := len(config.games) - 1; i >= 0; i-- {
+		if config.games[i].Table != table {
+			continue
+		}
+
+		game := config.games[i]
+
+		if !game.State.IsOver() {
+			return &game, nil
+		}
+
+		if latest == nil {
+			latest = &game
+		}
+	}
+
+	return latest, nil
+}
+
+func (config *MemoryGameRepo) Find(filter GameFilter, page int64, pageSize int64) ([]GameEntity, error) {
+
+	games := config.filter(filter)
+
+	from := (page - 1) * pageSize
+
+	if from < 0 || from >= int64(len(games)) {
+		return []GameEntity{}, nil
+	}
+
+	to := from + pageSize
+
+	if to > int64(len(games)) {
+		to = int64(len(games))
+	}
+
+	return games[from:to], nil
+}
+
+func (config *MemoryGameRepo) Count(filter GameFilter) (int64, error) {
+	return int64(len(config.filter(filter))), nil
+}
+
+// filter returns the matching games, the latest game first.
+func (config *MemoryGameRepo) filter(filter GameFilter) []GameEntity {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	games := make([]GameEntity, 0)
+
+	for i := len(config.games) - 1; i >= 0; i-- {
+		game := config.games[i]
+
+		if filter.Table != "" && game.Table != filter.Table {
+			continue
+		}
+
+		if filter.State != "" && game.State != filter.State {
+			continue
+		}
+
+		if filter.Player != "" && game.Player1 != filter.Player && game.Player2 != filter.Player && game.Player3 != filter.Player &&
+			game.Player4 != filter.Player {
+			continue
+		}
+
+		games = append(games, game)
+	}
+
+	return games
+}
+
+func (config *MemoryGameRepo) AssignMissingTable(table string) error {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	for i := range config.games {
+		if config.games[i].Table == "" {
+			config.games[i].Table = table
+		}
+	}
+
+	return nil
+}
+
+func (config *MemoryGameRepo) RemoveGame(gameId string) (*mongo.DeleteResult, error) {
+
+	parsedId, err := primitive.ObjectIDFromHex(gameId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	for i, game := range config.games {
+		if game.Id == parsedId {
+			config.games = append(config.games[:i], config.games[i+1:]...)
+			return &mongo.DeleteResult{DeletedCount: 1}, nil
+		}
+	}
+
+	return &mongo.DeleteResult{DeletedCount: 0}, nil
+}
+
+func (config *MemoryGameRepo) UpdateState(gameId string, state GameState) (*GameEntity, error) {
+	return config.update(gameId, func(game *GameEntity) error {
+		game.State = state
+		return nil
+	})
+}
+
+func (config *MemoryGameRepo) UpdateStateWithOutboxEntry(gameId string, state GameState, entry OutboxEntity) (*GameEntity, error) {
+
+	if _, err := config.outboxRepository.Add(entry); err != nil {
+		return nil, err
+	}
+
+	return config.UpdateState(gameId, state)
+}
+
+func (config *MemoryGameRepo) Reset(gameId string) (*GameEntity, error) {
+	return config.update(gameId, func(game *GameEntity) error {
+		resetGame(game)
+		return nil
+	})
+}
+
+func (config *MemoryGameRepo) ResetWithOutboxEntry(gameId string, entry OutboxEntity) (*GameEntity, error) {
+
+	if _, err := config.outboxRepository.Add(entry); err != nil {
+		return nil, err
+	}
+
+	return config.Reset(gameId)
+}
+
+func (config *MemoryGameRepo) AbortWithOutboxEntry(gameId string, reason string, entry OutboxEntity) (*GameEntity, error) {
+
+	if _, err := config.outboxRepository.Add(entry); err != nil {
+		return nil, err
+	}
+
+	return config.update(gameId, func(game *GameEntity) error {
+		finishedAt := time.Now()
+		game.State = GameAborted
+		game.AbortReason = reason
+		game.FinishedAt = &finishedAt
+		return nil
+	})
+}
+
+func (config *MemoryGameRepo) Finish(gameId string, duration float64, winner string) (*GameEntity, error) {
+	return config.update(gameId, func(game *GameEntity) error {
+		finishedAt := time.Now()
+		game.State = GameFinished
+		game.Duration = duration
+		game.Winner = winner
+		game.FinishedAt = &finishedAt
+		return nil
+	})
+}
+
+func (config *MemoryGameRepo) UpdateDuration(gameId string, duration float64) (*GameEntity, error) {
+	return config.update(gameId, func(game *GameEntity) error {
+		game.Duration = duration
+		return nil
+	})
+}
+
+func (config *MemoryGameRepo) UpdateCoins(gameId string, playerCoinMarker string, coins int) (*GameEntity, error) {
+	return config.update(gameId, func(game *GameEntity) error {
+		switch playerCoinMarker {
+		case Player1CoinMarker:
+			game.Player1Coins = coins
+		case Player2CoinMarker:
+			game.Player2Coins = coins
+		case Player3CoinMarker:
+			game.Player3Coins = coins
+		case Player4CoinMarker:
+			game.Player4Coins = coins
+		case KiCoinMarker:
+			game.KiCoins = coins
+		default:
+			return fmt.Errorf("unknown coin marker %s", playerCoinMarker)
+		}
+		game.LastActivityAt = time.Now()
+		return nil
+	})
+}
+
+func (config *MemoryGameRepo) AddProcessedEvent(gameId string, eventId string) error {
+	_, err := config.update(gameId, func(game *GameEntity) error {
+		if !funk.ContainsString(game.ProcessedEvents, eventId) {
+			game.ProcessedEvents = append(game.ProcessedEvents, eventId)
+		}
+		return nil
+	})
+
+	return err
+}
+
+func (config *MemoryGameRepo) AddTransition(gameId string, transition GameTransition) error {
+	_, err := config.update(gameId, func(game *GameEntity) error {
+		game.History = append(game.History, transition)
+		return nil
+	})
+
+	return err
+}
+
+// update applies the change to the game and returns the changed game afterwards.
+func (config *MemoryGameRepo) update(gameId string, change func(game *GameEntity) error) (*GameEntity, error) {
+
+	parsedId, err := primitive.ObjectIDFromHex(gameId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config.mutex.Lock()
+
+	for i := range config.games {
+		if config.games[i].Id == parsedId {
+			err = change(&config.games[i])
+		}
+	}
+
+	config.mutex.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Get(gameId)
+}
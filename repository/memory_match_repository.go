@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"sort"
+	"sync"
+)
+
+// MemoryMatchRepo keeps the matches in memory. It is used for tests and local runs without a mongo db.
+type MemoryMatchRepo struct {
+	matches []MatchEntity
+	mutex   sync.Mutex
+}
+
+func NewMemoryMatchRepo() *MemoryMatchRepo {
+	return &MemoryMatchRepo{matches: make([]MatchEntity, 0)}
+}
+
+func (config *MemoryMatchRepo) Add(match MatchEntity) (*primitive.ObjectID, error) {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	config.matches = append(config.matches, match)
+
+	return &match.Id, nil
+}
+
+func (config *MemoryMatchRepo) Get(id string) (*MatchEntity, error) {
+
+	parsedId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	for _, match := range config.matches {
+		if match.Id == parsedId {
+			return &match, nil
+		}
+	}
+
+	return nil, mongo.ErrNoDocuments
+}
+
+// GetQueued returns the queued matches of the table, the next match first.
+func (config *MemoryMatchRepo) GetQueued(table string) ([]MatchEntity, error) {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	matches := make([]MatchEntity, 0)
+
+	for _, match := range config.matches {
+		if match.Table == table && match.State == MatchQueued {
+			matches = append(matches, match)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Position < matches[j].Position
+	})
+
+	return matches, nil
+}
+
+func (config *MemoryMatchRepo) Update(match MatchEntity) error {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	for i := range config.matches {
+		if config.matches[i].Id == match.Id {
+			config.matches[i] = match
+			return nil
+		}
+	}
+
+	return mongo.ErrNoDocuments
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"sync"
+	"time"
+)
+
+// MemoryOutboxRepo keeps the outbox entries in memory. It is used for tests and local runs without
+// a mongo db.
+type MemoryOutboxRepo struct {
+	entries []OutboxEntity
+	mutex   sync.Mutex
+}
+
+func NewMemoryOutboxRepo() *MemoryOutboxRepo {
+	return &MemoryOutboxRepo{entries: make([]OutboxEntity, 0)}
+}
+
+func (config *MemoryOutboxRepo) Add(entry OutboxEntity) (*primitive.ObjectID, error) {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	config.entries = append(config.entries, entry)
+
+	return &entry.Id, nil
+}
+
+func (config *MemoryOutboxRepo) GetPending(limit int64) ([]OutboxEntity, error) {
+	return config.getByState(OutboxPending, limit), nil
+}
+
+func (config *MemoryOutboxRepo) GetFailed(limit int64) ([]OutboxEntity, error) {
+	return config.getByState(OutboxFailed, limit), nil
+}
+
+func (config *MemoryOutboxRepo) CountByState(state OutboxState) (int64, error) {
+	return int64(len(config.getByState(state, -1))), nil
+}
+
+func (config *MemoryOutboxRepo) MarkSent(id primitive.ObjectID) error {
+	_, err := config.update(id, func(entry *OutboxEntity) {
+		sentAt := time.Now().UTC()
+
+		entry.State = OutboxSent
+		entry.SentAt = &sentAt
+		entry.LastError = ""
+	})
+
+	return err
+}
+
+func (config *MemoryOutboxRepo) MarkAttemptFailed(id primitive.ObjectID, reason string, maxAttempts int) (*OutboxEntity, error) {
+	return config.update(id, func(entry *OutboxEntity) {
+		entry.Attempts += 1
+		entry.LastError = reason
+
+		if entry.Attempts >= maxAttempts {
+			entry.State = OutboxFailed
+		}
+	})
+}
+
+func (config *MemoryOutboxRepo) Retry(id string) error {
+
+	parsedId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = config.update(parsedId, func(entry *OutboxEntity) {
+		if entry.State == OutboxFailed {
+			entry.State = OutboxPending
+			entry.Attempts = 0
+		}
+	})
+
+	return err
+}
+
+func (config *MemoryOutboxRepo) Remove(id string) error {
+
+	parsedId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return err
+	}
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	for i, entry := range config.entries {
+		if entry.Id == parsedId {
+			config.entries = append(config.entries[:i], config.entries[i+1:]...)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// getByState returns the entries in creation order. A negative limit returns all entries.
+func (config *MemoryOutboxRepo) getByState(state OutboxState, limit int64) []OutboxEntity {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	entries := make([]OutboxEntity, 0)
+
+	for _, entry := range config.entries {
+		if limit >= 0 && int64(len(entries)) >= limit {
+			break
+		}
+
+		if entry.State == state {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+func (config *MemoryOutboxRepo) update(id primitive.ObjectID, change func(entry *OutboxEntity)) (*OutboxEntity, error) {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	for i := range config.entries {
+		if config.entries[i].Id == id {
+			change(&config.entries[i])
+			entry := config.entries[i]
+			return &entry, nil
+		}
+	}
+
+	return nil, mongo.ErrNoDocuments
+}
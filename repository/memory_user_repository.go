@@ -0,0 +1,343 @@
+package repository
+
+import (
+	"errors"
+	"github.com/thoas/go-funk"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryUserRepo keeps the registered users in memory. It is used for tests and local runs without
+// a mongo db.
+type MemoryUserRepo struct {
+	users []RegisteredUser
+	mutex sync.Mutex
+}
+
+func NewMemoryUserRepo() *MemoryUserRepo {
+	return &MemoryUserRepo{users: make([]RegisteredUser, 0)}
+}
+
+func (config *MemoryUserRepo) Create(user RegisteredUser) (*mongo.InsertOneResult, error) {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	for _, existingUser := range config.users {
+		if existingUser.Email == user.Email || existingUser.DisplayName == user.DisplayName {
+			return nil, errors.New("user with same email or display name exists")
+		}
+	}
+
+	newUser := RegisteredUser{
+		Id:                    primitive.NewObjectID(),
+		RegistrationTimestamp: time.Now().UTC(),
+
+		AcceptNewsletter:   user.AcceptNewsletter,
+		AcceptNotification: user.AcceptNotification,
+		DisplayName:        user.DisplayName,
+		Email:              user.Email,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+
+		BestDuration: InitialUserDuration,
+		GamesWon:     0,
+		PlayedGames:  0,
+		State:        UserWaiting,
+		Pos:          "1",
+		Table:        user.Table,
+
+		IsKiUser: false,
+	}
+
+	config.users = append(config.users, newUser)
+
+	return &mongo.InsertOneResult{InsertedID: newUser.Id}, nil
+}
+
+func (config *MemoryUserRepo) CreateKiUser(kiName string, table string) (*mongo.InsertOneResult, error) {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	kiUser := RegisteredUser{
+		Id:                    primitive.NewObjectID(),
+		RegistrationTimestamp: time.Now().UTC(),
+		DisplayName:           kiName,
+		BestDuration:          InitialUserDuration,
+		State:                 UserActive,
+		Pos:                   "-1",
+		Table:                 table,
+		IsKiUser:              true,
+	}
+
+	config.users = append(config.users, kiUser)
+
+	return &mongo.InsertOneResult{InsertedID: kiUser.Id}, nil
+}
+
+func (config *MemoryUserRepo) Get(email string) (*RegisteredUser, error) {
+	return config.findFirst(func(user RegisteredUser) bool {
+		return user.Email == email
+	})
+}
+
+func (config *MemoryUserRepo) GetByDisplayName(displayName string) (*RegisteredUser, error) {
+	return config.findFirst(func(user RegisteredUser) bool {
+		return user.DisplayName == displayName
+	})
+}
+
+func (config *MemoryUserRepo) GetByGameId(gameId primitive.ObjectID) ([]RegisteredUser, error) {
+	return config.findAll(func(user RegisteredUser) bool {
+		return user.GameId != nil && *user.GameId == gameId
+	}), nil
+}
+
+func (config *MemoryUserRepo) GetAllActive(table string) ([]RegisteredUser, error) {
+	return config.findAll(func(user RegisteredUser) bool {
+		return user.State == UserActive && user.Table == table
+	}), nil
+}
+
+func (config *MemoryUserRepo) GetAll() ([]RegisteredUser, error) {
+	return config.findAll(func(user RegisteredUser) bool {
+		return true
+	}), nil
+}
+
+func (config *MemoryUserRepo) GetPagedSortedByRegistrationDateWithoutKiUser(page int64, nameFilter string) ([]RegisteredUser, error) {
+
+	users, err := config.getAllWithoutKiUser(nameFilter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(users, func(i, j int) bool {
+		left, right := users[i].LastTimePlayed, users[j].LastTimePlayed
+
+		if left != nil && right != nil && !left.Equal(*right) {
+			return left.After(*right)
+		}
+
+		if (left == nil) != (right == nil) {
+			return left != nil
+		}
+
+		return users[i].RegistrationTimestamp.After(users[j].RegistrationTimestamp)
+	})
+
+	pageSize := int64(10)
+	skip := (page - 1) * pageSize
+
+	if skip >= int64(len(users)) {
+		return []RegisteredUser{}, nil
+	}
+
+	end := skip + pageSize
+
+	if end > int64(len(users)) {
+		end = int64(len(users))
+	}
+
+	return users[skip:end], nil
+}
+
+func (config *MemoryUserRepo) CountAllWithoutKiUser(nameFilter string) (int64, error) {
+
+	users, err := config.getAllWithoutKiUser(nameFilter)
+
+	if err != nil {
+		return -1, err
+	}
+
+	return int64(len(users)), nil
+}
+
+func (config *MemoryUserRepo) UpdateGameStatisticValues(user RegisteredUser, gameId primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return config.update(func(existingUser *RegisteredUser) bool {
+		if existingUser.Id != user.Id || funk.Contains(existingUser.CountedGames, gameId) {
+			return false
+		}
+
+		lastTimePlayed := time.Now()
+
+		existingUser.BestDuration = user.BestDuration
+		existingUser.GamesWon = user.GamesWon
+		existingUser.PlayedGames = user.PlayedGames
+		existingUser.LastTimePlayed = &lastTimePlayed
+		existingUser.CountedGames = append(existingUser.CountedGames, gameId)
+
+		return true
+	})
+}
+
+func (config *MemoryUserRepo) UpdateGameRelationship(userId *primitive.ObjectID, gameId *primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return config.update(func(existingUser *RegisteredUser) bool {
+		if existingUser.Id != *userId {
+			return false
+		}
+
+		existingUser.GameId = gameId
+
+		return true
+	})
+}
+
+func (config *MemoryUserRepo) UpdatePosition(id string, position string) (*mongo.UpdateResult, error) {
+
+	parsedId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return config.update(func(existingUser *RegisteredUser) bool {
+		if existingUser.Id != parsedId {
+			return false
+		}
+
+		existingUser.Pos = position
+
+		return true
+	})
+}
+
+func (config *MemoryUserRepo) UpdateState(id string, state string) (*mongo.UpdateResult, error) {
+
+	parsedId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return config.update(func(existingUser *RegisteredUser) bool {
+		if existingUser.Id != parsedId {
+			return false
+		}
+
+		existingUser.State = UserState(state)
+
+		return true
+	})
+}
+
+func (config *MemoryUserRepo) UpdateTable(id string, table string) (*mongo.UpdateResult, error) {
+
+	parsedId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return config.update(func(existingUser *RegisteredUser) bool {
+		if existingUser.Id != parsedId {
+			return false
+		}
+
+		existingUser.Table = table
+
+		return true
+	})
+}
+
+func (config *MemoryUserRepo) AssignMissingTable(table string) (*mongo.UpdateResult, error) {
+	return config.update(func(existingUser *RegisteredUser) bool {
+		if existingUser.Table != "" {
+			return false
+		}
+
+		existingUser.Table = table
+
+		return true
+	})
+}
+
+func (config *MemoryUserRepo) UpdateAllNonKiUsers(state UserState) (*mongo.UpdateResult, error) {
+	return config.update(func(existingUser *RegisteredUser) bool {
+		if existingUser.IsKiUser {
+			return false
+		}
+
+		existingUser.State = state
+
+		return true
+	})
+}
+
+func (config *MemoryUserRepo) Remove(displayName string) error {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	for i, user := range config.users {
+		if user.DisplayName == displayName {
+			config.users = append(config.users[:i], config.users[i+1:]...)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (config *MemoryUserRepo) getAllWithoutKiUser(nameFilter string) ([]RegisteredUser, error) {
+
+	nameRegex, err := regexp.Compile(nameFilter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return config.findAll(func(user RegisteredUser) bool {
+		return !user.IsKiUser && nameRegex.MatchString(user.DisplayName)
+	}), nil
+}
+
+func (config *MemoryUserRepo) findFirst(matches func(user RegisteredUser) bool) (*RegisteredUser, error) {
+
+	users := config.findAll(matches)
+
+	if len(users) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	return &users[0], nil
+}
+
+func (config *MemoryUserRepo) findAll(matches func(user RegisteredUser) bool) []RegisteredUser {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	registeredUsers := make([]RegisteredUser, 0)
+
+	for _, user := range config.users {
+		if matches(user) {
+			registeredUsers = append(registeredUsers, user)
+		}
+	}
+
+	return registeredUsers
+}
+
+// update applies the change to all users, for which change returns true.
+func (config *MemoryUserRepo) update(change func(existingUser *RegisteredUser) bool) (*mongo.UpdateResult, error) {
+
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
+	var modified int64
+
+	for i := range config.users {
+		if change(&config.users[i]) {
+			modified++
+		}
+	}
+
+	return &mongo.UpdateResult{MatchedCount: modified, ModifiedCount: modified}, nil
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+type OutboxRepository interface {
+	Add(entry OutboxEntity) (*primitive.ObjectID, error)
+	GetPending(limit int64) ([]OutboxEntity, error)
+	GetFailed(limit int64) ([]OutboxEntity, error)
+	CountByState(state OutboxState) (int64, error)
+	MarkSent(id primitive.ObjectID) error
+	MarkAttemptFailed(id primitive.ObjectID, reason string, maxAttempts int) (*OutboxEntity, error)
+	Retry(id string) error
+	Remove(id string) error
+}
+
+type OutboxRepo struct {
+	collection *mongo.Collection
+}
+
+type OutboxState string
+
+const (
+	OutboxPending OutboxState = "pending"
+	OutboxSent    OutboxState = "sent"
+	OutboxFailed  OutboxState = "failed"
+)
+
+// OutboxEntity is an outgoing louie event. It is written together with the state change which
+// causes the event and published afterwards by the outbox relay.
+type OutboxEntity struct {
+	Id        primitive.ObjectID `bson:"_id"`
+	Event     string             `bson:"event"`
+	Payload   string             `bson:"payload"`
+	State     OutboxState        `bson:"state"`
+	Attempts  int                `bson:"attempts"`
+	LastError string             `bson:"last_error"`
+	CreatedAt time.Time          `bson:"created_at"`
+	SentAt    *time.Time         `bson:"sent_at"`
+}
+
+func NewOutboxEntity(event string, payload []byte) OutboxEntity {
+	return OutboxEntity{
+		Id:        primitive.NewObjectID(),
+		Event:     event,
+		Payload:   string(payload),
+		State:     OutboxPending,
+		Attempts:  0,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+func NewOutboxRepository(ctx context.Context, client *mongo.Client, databaseName string) *OutboxRepo {
+	database := client.Database(databaseName)
+	exists, existingCollection := existsCollection(database, OutboxCollection)
+
+	if exists == true {
+		log.Printf("outbox collection exists \n")
+		return &OutboxRepo{collection: existingCollection}
+	}
+
+	err := database.CreateCollection(ctx, OutboxCollection)
+
+	if err != nil {
+		log.Fatal(fmt.Sprintf("can not create outbox collection: %s", err))
+	}
+
+	collection := database.Collection(OutboxCollection)
+
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "state", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+
+	if err != nil {
+		log.Fatal(fmt.Sprintf("can not create outbox collection state index: %s", err))
+	}
+
+	return &OutboxRepo{collection: collection}
+}
+
+func (config *OutboxRepo) Add(entry OutboxEntity) (*primitive.ObjectID, error) {
+	ctx := context.Background()
+
+	result, err := config.collection.InsertOne(ctx, &entry)
+
+	if err != nil {
+		log.Printf("saving outbox entry failed %s\n", err)
+		return nil, err
+	}
+
+	entryId, ok := result.InsertedID.(primitive.ObjectID)
+
+	if !ok {
+		return nil, errors.New("can not cast returned id to mongo primitive object id")
+	}
+
+	return &entryId, nil
+}
+
+func (config *OutboxRepo) GetPending(limit int64) ([]OutboxEntity, error) {
+	return config.getByState(OutboxPending, limit)
+}
+
+func (config *OutboxRepo) GetFailed(limit int64) ([]OutboxEntity, error) {
+	return config.getByState(OutboxFailed, limit)
+}
+
+func (config *OutboxRepo) getByState(state OutboxState, limit int64) ([]OutboxEntity, error) {
+	ctx := context.Background()
+	entries := make([]OutboxEntity, 0)
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetLimit(limit)
+
+	cursor, err := config.collection.Find(ctx, bson.M{"state": state}, findOptions)
+
+	if err != nil {
+		log.Printf("some error occured during get %s outbox entries: %s\n", state, err)
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var entry OutboxEntity
+		if err := cursor.Decode(&entry); err != nil {
+			log.Printf("some error occured during decoding outbox entries received from mongo db: %s\n", err)
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (config *OutboxRepo) CountByState(state OutboxState) (int64, error) {
+	ctx := context.Background()
+
+	count, err := config.collection.CountDocuments(ctx, bson.M{"state": state})
+
+	if err != nil {
+		log.Printf("some error occured during count %s outbox entries: %s\n", state, err)
+		return -1, err
+	}
+
+	return count, nil
+}
+
+func (config *OutboxRepo) MarkSent(id primitive.ObjectID) error {
+	ctx := context.Background()
+
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{"state": OutboxSent, "sent_at": time.Now().UTC(), "last_error": ""}}
+
+	_, err := config.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		log.Printf("some error occured during mark outbox entry %s as sent: %s\n", id.Hex(), err)
+		return err
+	}
+
+	return nil
+}
+
+// MarkAttemptFailed counts the failed publishing attempt. After maxAttempts the entry is marked as
+// failed and is only published again after a retry of the admin.
+func (config *OutboxRepo) MarkAttemptFailed(id primitive.ObjectID, reason string, maxAttempts int) (*OutboxEntity, error) {
+	ctx := context.Background()
+
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$inc": bson.M{"attempts": 1},
+		"$set": bson.M{"last_error": reason},
+	}
+
+	var entry OutboxEntity
+
+	err := config.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&entry)
+
+	if err != nil {
+		log.Printf("some error occured during count failed attempt of outbox entry %s: %s\n", id.Hex(), err)
+		return nil, err
+	}
+
+	if entry.Attempts < maxAttempts {
+		return &entry, nil
+	}
+
+	_, err = config.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"state": OutboxFailed}})
+
+	if err != nil {
+		log.Printf("some error occured during mark outbox entry %s as failed: %s\n", id.Hex(), err)
+		return nil, err
+	}
+
+	entry.State = OutboxFailed
+
+	return &entry, nil
+}
+
+func (config *OutboxRepo) Retry(id string) error {
+	ctx := context.Background()
+
+	parsedId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("can not parse a not valid outbox entry id %s\n", err)
+		return err
+	}
+
+	filter := bson.M{"_id": parsedId, "state": OutboxFailed}
+	update := bson.M{"$set": bson.M{"state": OutboxPending, "attempts": 0}}
+
+	_, err = config.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		log.Printf("some error occured during retry of outbox entry %s: %s\n", id, err)
+		return err
+	}
+
+	return nil
+}
+
+func (config *OutboxRepo) Remove(id string) error {
+	ctx := context.Background()
+
+	parsedId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("can not parse a not valid outbox entry id %s\n", err)
+		return err
+	}
+
+	_, err = config.collection.DeleteOne(ctx, bson.M{"_id": parsedId})
+
+	if err != nil {
+		log.Printf("deleting outbox entry failed %s\n", err)
+		return err
+	}
+
+	return nil
+}
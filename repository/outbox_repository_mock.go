@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TestOutboxRepository struct {
+	mock.Mock
+}
+
+func (testOutboxRepository *TestOutboxRepository) Add(entry OutboxEntity) (*primitive.ObjectID, error) {
+	args := testOutboxRepository.Called(entry)
+	return args.Get(0).(*primitive.ObjectID), args.Error(1)
+}
+
+func (testOutboxRepository *TestOutboxRepository) GetPending(limit int64) ([]OutboxEntity, error) {
+	args := testOutboxRepository.Called(limit)
+	return args.Get(0).([]OutboxEntity), args.Error(1)
+}
+
+func (testOutboxRepository *TestOutboxRepository) GetFailed(limit int64) ([]OutboxEntity, error) {
+	args := testOutboxRepository.Called(limit)
+	return args.Get(0).([]OutboxEntity), args.Error(1)
+}
+
+func (testOutboxRepository *TestOutboxRepository) CountByState(state OutboxState) (int64, error) {
+	args := testOutboxRepository.Called(state)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (testOutboxRepository *TestOutboxRepository) MarkSent(id primitive.ObjectID) error {
+	args := testOutboxRepository.Called(id)
+	return args.Error(0)
+}
+
+func (testOutboxRepository *TestOutboxRepository) MarkAttemptFailed(id primitive.ObjectID, reason string, maxAttempts int) (*OutboxEntity, error) {
+	args := testOutboxRepository.Called(id, reason, maxAttempts)
+	return args.Get(0).(*OutboxEntity), args.Error(1)
+}
+
+func (testOutboxRepository *TestOutboxRepository) Retry(id string) error {
+	args := testOutboxRepository.Called(id)
+	return args.Error(0)
+}
+
+func (testOutboxRepository *TestOutboxRepository) Remove(id string) error {
+	args := testOutboxRepository.Called(id)
+	return args.Error(0)
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *RepositoryTestSuite) Test_UpdateStateWithOutboxEntry() {
+
+	gameRepository := NewGameRepository(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
+	outboxRepository := NewOutboxRepository(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
+
+	gameId, err := gameRepository.CreateGame("c-library", KiName, DefaultGameSettings(), []RegisteredUser{})
+	assert.NoError(s.T(), err)
+
+	entry := NewOutboxEntity("PLAYERS_READY", []byte("{\"event\":\"PLAYERS_READY\"}"))
+
+	game, err := gameRepository.UpdateStateWithOutboxEntry(gameId.Hex(), GameReady, entry)
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), GameReady, game.State)
+
+	pending, err := outboxRepository.GetPending(10)
+
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), pending, 1)
+	assert.Equal(s.T(), entry.Id, pending[0].Id)
+	assert.Equal(s.T(), "{\"event\":\"PLAYERS_READY\"}", pending[0].Payload)
+}
+
+func (s *RepositoryTestSuite) Test_MarkAttemptFailed_MaxAttemptsReached() {
+
+	outboxRepository := NewOutboxRepository(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
+
+	entryId, err := outboxRepository.Add(NewOutboxEntity("CONFIRMED_CHANGE_SIDE", []byte("{}")))
+	assert.NoError(s.T(), err)
+
+	entry, err := outboxRepository.MarkAttemptFailed(*entryId, "broker not available", 2)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), OutboxPending, entry.State)
+
+	entry, err = outboxRepository.MarkAttemptFailed(*entryId, "broker not available", 2)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), OutboxFailed, entry.State)
+	assert.Equal(s.T(), 2, entry.Attempts)
+
+	failedCount, err := outboxRepository.CountByState(OutboxFailed)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(1), failedCount)
+
+	err = outboxRepository.Retry(entryId.Hex())
+	assert.NoError(s.T(), err)
+
+	pending, err := outboxRepository.GetPending(10)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), pending, 1)
+	assert.Equal(s.T(), 0, pending[0].Attempts)
+}
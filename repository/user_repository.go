@@ -13,18 +13,20 @@ import (
 
 type UserRepository interface {
 	Create(user RegisteredUser) (*mongo.InsertOneResult, error)
-	CreateKiUser() (*mongo.InsertOneResult, error)
+	CreateKiUser(kiName string, table string) (*mongo.InsertOneResult, error)
 	Get(email string) (*RegisteredUser, error)
 	GetByDisplayName(displayName string) (*RegisteredUser, error)
 	GetByGameId(gameId primitive.ObjectID) ([]RegisteredUser, error)
-	GetAllActive() ([]RegisteredUser, error)
+	GetAllActive(table string) ([]RegisteredUser, error)
 	GetAll() ([]RegisteredUser, error)
 	GetPagedSortedByRegistrationDateWithoutKiUser(page int64, nameFilter string) ([]RegisteredUser, error)
 	CountAllWithoutKiUser(nameFilter string) (int64, error)
-	UpdateGameStatisticValues(user RegisteredUser) (*mongo.UpdateResult, error)
+	UpdateGameStatisticValues(user RegisteredUser, gameId primitive.ObjectID) (*mongo.UpdateResult, error)
 	UpdateGameRelationship(userId *primitive.ObjectID, gameId *primitive.ObjectID) (*mongo.UpdateResult, error)
 	UpdatePosition(id string, position string) (*mongo.UpdateResult, error)
 	UpdateState(id string, state string) (*mongo.UpdateResult, error)
+	UpdateTable(id string, table string) (*mongo.UpdateResult, error)
+	AssignMissingTable(table string) (*mongo.UpdateResult, error)
 	UpdateAllNonKiUsers(state UserState) (*mongo.UpdateResult, error)
 	Remove(displayName string) error
 }
@@ -58,6 +60,11 @@ type RegisteredUser struct {
 	PlayedGames    int        `bson:"played_games"`
 	State          UserState  `bson:"state"`
 	Pos            string     `bson:"pos"`
+	// Table is the sender of the louie table, the user is queued for.
+	Table string `bson:"table"`
+
+	// CountedGames are the games, which are already part of the statistic values of the user.
+	CountedGames []primitive.ObjectID `bson:"counted_games"`
 
 	IsKiUser bool `bson:"is_ki_user"`
 }
@@ -116,7 +123,7 @@ func (config *UserRepo) Remove(displayName string) error {
 	return nil
 }
 
-func (config *UserRepo) CreateKiUser() (*mongo.InsertOneResult, error) {
+func (config *UserRepo) CreateKiUser(kiName string, table string) (*mongo.InsertOneResult, error) {
 
 	ctx := context.Background()
 
@@ -126,7 +133,7 @@ func (config *UserRepo) CreateKiUser() (*mongo.InsertOneResult, error) {
 
 		AcceptNewsletter:   false,
 		AcceptNotification: false,
-		DisplayName:        KiName,
+		DisplayName:        kiName,
 		Email:              "",
 		FirstName:          "",
 		LastName:           "",
@@ -136,6 +143,7 @@ func (config *UserRepo) CreateKiUser() (*mongo.InsertOneResult, error) {
 		PlayedGames:  0,
 		State:        UserActive,
 		Pos:          "-1",
+		Table:        table,
 
 		IsKiUser: true,
 	}
@@ -169,6 +177,7 @@ func (config *UserRepo) Create(user RegisteredUser) (*mongo.InsertOneResult, err
 		PlayedGames:  0,
 		State:        UserWaiting,
 		Pos:          "1",
+		Table:        user.Table,
 
 		IsKiUser: false,
 	}
@@ -244,17 +253,22 @@ func (config *UserRepo) UpdatePosition(id string, position string) (*mongo.Updat
 	return mongoSingleResult, nil
 }
 
-func (config *UserRepo) UpdateGameStatisticValues(user RegisteredUser) (*mongo.UpdateResult, error) {
+// UpdateGameStatisticValues stores the statistic values of the user, which include the given game. If the
+// game is already counted for the user (e.g. a redelivered GAME_DONE), nothing is updated.
+func (config *UserRepo) UpdateGameStatisticValues(user RegisteredUser, gameId primitive.ObjectID) (*mongo.UpdateResult, error) {
 
 	ctx := context.Background()
 
-	filter := bson.M{"_id": user.Id}
-	update := bson.M{"$set": bson.M{
-		"best_duration":    user.BestDuration,
-		"games_won":        user.GamesWon,
-		"played_games":     user.PlayedGames,
-		"last_time_played": time.Now(),
-	}}
+	filter := bson.M{"_id": user.Id, "counted_games": bson.M{"$ne": gameId}}
+	update := bson.M{
+		"$set": bson.M{
+			"best_duration":    user.BestDuration,
+			"games_won":        user.GamesWon,
+			"played_games":     user.PlayedGames,
+			"last_time_played": time.Now(),
+		},
+		"$addToSet": bson.M{"counted_games": gameId},
+	}
 
 	mongoSingleResult, err := config.collection.UpdateOne(ctx, filter, update)
 
@@ -332,12 +346,54 @@ func (config *UserRepo) UpdateState(id string, state string) (*mongo.UpdateResul
 	return mongoSingleResult, nil
 }
 
-func (config *UserRepo) GetAllActive() ([]RegisteredUser, error) {
+func (config *UserRepo) UpdateTable(id string, table string) (*mongo.UpdateResult, error) {
+
+	ctx := context.Background()
+
+	parsedId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		log.Printf("can not parse a not valid user id %s\n", err)
+		return nil, err
+	}
+
+	filter := bson.M{"_id": parsedId}
+	update := bson.M{"$set": bson.M{"table": table}}
+
+	mongoSingleResult, err := config.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		log.Printf("some error occured during update user table to %s of user %s: %s\n", table, id, err)
+		return nil, err
+	}
+
+	return mongoSingleResult, nil
+}
+
+// AssignMissingTable queues the users, which were registered before tables existed, for the given table.
+func (config *UserRepo) AssignMissingTable(table string) (*mongo.UpdateResult, error) {
+
+	ctx := context.Background()
+
+	filter := bson.M{"table": bson.M{"$in": bson.A{nil, ""}}}
+	update := bson.M{"$set": bson.M{"table": table}}
+
+	mongoSingleResult, err := config.collection.UpdateMany(ctx, filter, update)
+
+	if err != nil {
+		log.Printf("some error occured during assigning users to table %s: %s\n", table, err)
+		return nil, err
+	}
+
+	return mongoSingleResult, nil
+}
+
+func (config *UserRepo) GetAllActive(table string) ([]RegisteredUser, error) {
 
 	ctx := context.Background()
 	registeredUsers := make([]RegisteredUser, 0)
 
-	cursor, err := config.collection.Find(ctx, bson.M{"state": UserActive})
+	cursor, err := config.collection.Find(ctx, bson.M{"state": UserActive, "table": table})
 
 	if err != nil {
 		log.Printf("some error occured during get all active users: %s\n", err)
@@ -10,8 +10,8 @@ type TestUserRepository struct {
 	mock.Mock
 }
 
-func (testUserRepository *TestUserRepository) UpdateGameStatisticValues(user RegisteredUser) (*mongo.UpdateResult, error) {
-	args := testUserRepository.Called(user)
+func (testUserRepository *TestUserRepository) UpdateGameStatisticValues(user RegisteredUser, gameId primitive.ObjectID) (*mongo.UpdateResult, error) {
+	args := testUserRepository.Called(user, gameId)
 	return args.Get(0).(*mongo.UpdateResult), args.Error(1)
 }
 
@@ -45,8 +45,8 @@ func (testUserRepository *TestUserRepository) UpdateState(id string, state strin
 	return args.Get(0).(*mongo.UpdateResult), args.Error(1)
 }
 
-func (testUserRepository *TestUserRepository) GetAllActive() ([]RegisteredUser, error) {
-	args := testUserRepository.Called()
+func (testUserRepository *TestUserRepository) GetAllActive(table string) ([]RegisteredUser, error) {
+	args := testUserRepository.Called(table)
 	return args.Get(0).([]RegisteredUser), args.Error(1)
 }
 
@@ -69,8 +69,8 @@ func (testUserRepository *TestUserRepository) GetByDisplayName(displayName strin
 	return args.Get(0).(*RegisteredUser), args.Error(1)
 }
 
-func (testUserRepository *TestUserRepository) CreateKiUser() (*mongo.InsertOneResult, error) {
-	args := testUserRepository.Called()
+func (testUserRepository *TestUserRepository) CreateKiUser(kiName string, table string) (*mongo.InsertOneResult, error) {
+	args := testUserRepository.Called(kiName, table)
 	return args.Get(0).(*mongo.InsertOneResult), args.Error(1)
 }
 
@@ -83,3 +83,13 @@ func (testUserRepository *TestUserRepository) UpdateAllNonKiUsers(state UserStat
 	args := testUserRepository.Called(state)
 	return args.Get(0).(*mongo.UpdateResult), args.Error(1)
 }
+
+func (testUserRepository *TestUserRepository) UpdateTable(id string, table string) (*mongo.UpdateResult, error) {
+	args := testUserRepository.Called(id, table)
+	return args.Get(0).(*mongo.UpdateResult), args.Error(1)
+}
+
+func (testUserRepository *TestUserRepository) AssignMissingTable(table string) (*mongo.UpdateResult, error) {
+	args := testUserRepository.Called(table)
+	return args.Get(0).(*mongo.UpdateResult), args.Error(1)
+}
@@ -47,7 +47,7 @@ func (s *RepositoryTestSuite) Test_CreateKiUser() {
 
 	userRepository := NewUserRepo(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
 
-	_, err := userRepository.CreateKiUser()
+	_, err := userRepository.CreateKiUser(KiName, "c-library")
 
 	assert.NoError(s.T(), err)
 
@@ -64,6 +64,7 @@ func (s *RepositoryTestSuite) Test_CreateKiUser() {
 	assert.Equal(s.T(), 0, kiUser.GamesWon)
 	assert.Equal(s.T(), 0, kiUser.PlayedGames)
 	assert.Equal(s.T(), "-1", kiUser.Pos)
+	assert.Equal(s.T(), "c-library", kiUser.Table)
 	assert.Equal(s.T(), true, kiUser.IsKiUser)
 }
 
@@ -96,6 +97,44 @@ func (s *RepositoryTestSuite) Test_UpdatePosition() {
 	assert.Equal(s.T(), "2", users[0].Pos)
 }
 
+func (s *RepositoryTestSuite) Test_UpdateGameStatisticValues_CountsGameOnce() {
+
+	userRepository := NewUserRepo(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
+
+	createUserResult, _ := userRepository.Create(RegisteredUser{
+		DisplayName: "max",
+		Email:       "max@mustermann.de",
+		Pos:         "1",
+	})
+
+	gameId := primitive.NewObjectID()
+	user, _ := userRepository.GetByDisplayName("max")
+
+	user.GamesWon = 1
+	user.PlayedGames = 1
+
+	firstResult, err := userRepository.UpdateGameStatisticValues(*user, gameId)
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(1), firstResult.ModifiedCount)
+
+	// replay of the same game
+	user.GamesWon = 2
+	user.PlayedGames = 2
+
+	replayResult, err := userRepository.UpdateGameStatisticValues(*user, gameId)
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(0), replayResult.ModifiedCount)
+
+	updatedUser, _ := userRepository.GetByDisplayName("max")
+
+	assert.Equal(s.T(), createUserResult.InsertedID, updatedUser.Id)
+	assert.Equal(s.T(), 1, updatedUser.GamesWon)
+	assert.Equal(s.T(), 1, updatedUser.PlayedGames)
+	assert.Equal(s.T(), []primitive.ObjectID{gameId}, updatedUser.CountedGames)
+}
+
 func (s *RepositoryTestSuite) Test_UpdatePosition_UseWrongId() {
 
 	userRepository := NewUserRepo(context.Background(), s.mongoDb.Client(), s.mongoDb.Name())
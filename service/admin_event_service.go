@@ -5,29 +5,37 @@ import "louie-web-administrator/websocket"
 type AdminEventService struct {
 	gameService    GameService
 	userService    UserService
+	tableService   *TableSer
 	adminWebsocket *websocket.AdminUiWebsocket
 }
 
-func InitAdminEventService(userService UserService, gameService GameService, adminWebsocket *websocket.AdminUiWebsocket) *AdminEventService {
+func InitAdminEventService(userService UserService, gameService GameService, tableService *TableSer, adminWebsocket *websocket.AdminUiWebsocket) *AdminEventService {
 	return &AdminEventService{
 		userService:    userService,
 		gameService:    gameService,
+		tableService:   tableService,
 		adminWebsocket: adminWebsocket,
 	}
 }
 
 func (a *AdminEventService) CheckActiveUsersAndEnableOrDisableGameButton() {
 
-	activeUsersCount := a.userService.CountActiveUsersWithoutKiUser()
-	currentGame, _ := a.gameService.GetCurrentGame()
+	for _, table := range a.tableService.GetAll() {
 
-	if activeUsersCount >= 3 && currentGame == nil {
-		a.adminWebsocket.SendToAdminUi(&websocket.AdminUiEvent{
-			EventType: websocket.ActivateGameStartButton,
-		})
-	} else {
-		a.adminWebsocket.SendToAdminUi(&websocket.AdminUiEvent{
-			EventType: websocket.DeactivateGameStartButton,
-		})
+		activeUsersCount := a.userService.CountActiveUsersWithoutKiUser(table.Sender)
+		currentGame, _ := a.gameService.GetCurrentGame(table.Sender)
+
+		// the seats of the game are chosen on announcing, so a single active user is enough.
+		if activeUsersCount >= 1 && (currentGame == nil || currentGame.State.IsOver()) {
+			a.adminWebsocket.SendToAdminUi(&websocket.AdminUiEvent{
+				EventType: websocket.ActivateGameStartButton,
+				Table:     table.Sender,
+			})
+		} else {
+			a.adminWebsocket.SendToAdminUi(&websocket.AdminUiEvent{
+				EventType: websocket.DeactivateGameStartButton,
+				Table:     table.Sender,
+			})
+		}
 	}
 }
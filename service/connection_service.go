@@ -0,0 +1,121 @@
+package service
+
+import (
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/websocket"
+	"sort"
+	"sync"
+	"time"
+)
+
+type BrokerConnection struct {
+	Name  string
+	State louie_kafka.ConnectionState
+	// Error is the reason of the last reconnect.
+	Error string
+	Since string
+}
+
+type connectionStatus struct {
+	state louie_kafka.ConnectionState
+	err   error
+	since time.Time
+}
+
+// ConnectionSer keeps the state of the broker connections of the message bus for the admin ui and the
+// health endpoints.
+type ConnectionSer struct {
+	AdminUiSocket *websocket.AdminUiWebsocket
+	connections   map[string]connectionStatus
+	mutex         sync.Mutex
+	now           func() time.Time
+}
+
+func NewConnectionService(adminUiSocket *websocket.AdminUiWebsocket) *ConnectionSer {
+	return &ConnectionSer{
+		AdminUiSocket: adminUiSocket,
+		connections:   make(map[string]connectionStatus),
+		now:           time.Now,
+	}
+}
+
+// ConnectionChanged records the new state of the connection and updates the admin ui.
+func (c *ConnectionSer) ConnectionChanged(name string, state louie_kafka.ConnectionState, err error) {
+
+	c.mutex.Lock()
+	c.connections[name] = connectionStatus{state: state, err: err, since: c.now()}
+	c.mutex.Unlock()
+
+	if c.AdminUiSocket != nil {
+		c.AdminUiSocket.SendToAdminUi(toConnectionAdminUiEvent(c.GetConnections()))
+	}
+}
+
+// GetConnections returns the connections ordered by name.
+func (c *ConnectionSer) GetConnections() []BrokerConnection {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	connections := make([]BrokerConnection, 0, len(c.connections))
+
+	for name, status := range c.connections {
+
+		connection := BrokerConnection{
+			Name:  name,
+			State: status.state,
+			Since: status.since.Local().Format(repository.GermanDateTimeFormat),
+		}
+
+		if status.err != nil {
+			connection.Error = status.err.Error()
+		}
+
+		connections = append(connections, connection)
+	}
+
+	sort.Slice(connections, func(i, j int) bool {
+		return connections[i].Name < connections[j].Name
+	})
+
+	return connections
+}
+
+// IsConnected reports whether there is at least one connection and all connections are established.
+func (c *ConnectionSer) IsConnected() bool {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.connections) == 0 {
+		return false
+	}
+
+	for _, status := range c.connections {
+		if status.state != louie_kafka.ConnectionConnected {
+			return false
+		}
+	}
+
+	return true
+}
+
+func toConnectionAdminUiEvent(connections []BrokerConnection) *websocket.AdminUiEvent {
+
+	brokerConnections := make([]websocket.BrokerConnection, 0, len(connections))
+
+	for _, connection := range connections {
+		brokerConnections = append(brokerConnections, websocket.BrokerConnection{
+			Name:  connection.Name,
+			State: connection.State.String(),
+			Error: connection.Error,
+			Since: connection.Since,
+		})
+	}
+
+	return &websocket.AdminUiEvent{
+		EventType:   websocket.ConnectionStatus,
+		Connections: brokerConnections,
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/websocket"
+	"testing"
+)
+
+func Test_Connection_ConnectedOnlyIfAllConnectionsAreEstablished(t *testing.T) {
+
+	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
+	connectionService := NewConnectionService(websocket.InitAdminUiWebsocket(adminUiChannel))
+
+	assert.False(t, connectionService.IsConnected())
+
+	connectionService.ConnectionChanged("LOUIE_INBOUND", louie_kafka.ConnectionConnected, nil)
+	connectionService.ConnectionChanged("LOUIE_TECHNICAL", louie_kafka.ConnectionReconnecting, errors.New("broker not reachable"))
+
+	assert.False(t, connectionService.IsConnected())
+
+	<-adminUiChannel
+	adminUiEvent := <-adminUiChannel
+
+	assert.Equal(t, websocket.ConnectionStatus, adminUiEvent.EventType)
+	assert.Equal(t, []string{"LOUIE_INBOUND", "LOUIE_TECHNICAL"}, []string{adminUiEvent.Connections[0].Name, adminUiEvent.Connections[1].Name})
+	assert.Equal(t, "reconnecting", adminUiEvent.Connections[1].State)
+	assert.Equal(t, "broker not reachable", adminUiEvent.Connections[1].Error)
+
+	connectionService.ConnectionChanged("LOUIE_TECHNICAL", louie_kafka.ConnectionConnected, nil)
+
+	assert.True(t, connectionService.IsConnected())
+	assert.Empty(t, connectionService.GetConnections()[1].Error)
+}
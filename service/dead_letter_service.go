@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/websocket"
+	"time"
+)
+
+type DeadLetterService interface {
+	louie_kafka.DeadLetterSink
+	GetOpen() ([]DeadLetterEntry, error)
+	CountOpen() int64
+	Replay(ctx context.Context, id string) error
+	Discard(id string) error
+}
+
+// Redeliverer routes a raw event into the processing again, like a consumed one.
+type Redeliverer interface {
+	Redeliver(ctx context.Context, value []byte) error
+}
+
+type DeadLetterEntry struct {
+	Id        string
+	Payload   string
+	Reason    string
+	Detail    string
+	CreatedAt string
+}
+
+type DeadLetterSer struct {
+	DeadLetterRepository repository.DeadLetterRepository
+	AdminUiSocket        *websocket.AdminUiWebsocket
+	Redeliverer          Redeliverer
+	MessageBus           MessagePublisher
+	// DeadLetterTopic is the topic the dead letters are additionally published to. Optional.
+	DeadLetterTopic string
+}
+
+func (d *DeadLetterSer) Quarantine(message []byte, reason louie_kafka.DeadLetterReason, detail string) {
+
+	log.Printf("quarantine event (%s: %s): %s\n", reason, detail, message)
+
+	entity := repository.NewDeadLetterEntity(message, reason.String(), detail)
+
+	if _, err := d.DeadLetterRepository.Add(entity); err != nil {
+		log.Printf("storing dead letter failed: %s\n", err)
+	}
+
+	d.publishToDeadLetterTopic(entity)
+
+	d.AdminUiSocket.SendToAdminUi(&websocket.AdminUiEvent{
+		EventType:   websocket.DeadLetterStatus,
+		DeadLetters: d.CountOpen(),
+	})
+}
+
+func (d *DeadLetterSer) publishToDeadLetterTopic(entity repository.DeadLetterEntity) {
+
+	if d.DeadLetterTopic == "" {
+		return
+	}
+
+	deadLetterEvent, err := json.Marshal(louie_kafka.DeadLetterEvent{
+		Reason:    louie_kafka.DeadLetterReason(entity.Reason),
+		Detail:    entity.Detail,
+		Timestamp: entity.CreatedAt.Format(time.RFC3339),
+		Payload:   entity.Payload,
+	})
+
+	if err != nil {
+		log.Printf("can not marshal dead letter event: %s\n", err)
+		return
+	}
+
+	message := louie_kafka.Message{Key: []byte(entity.Id.Hex()), Value: deadLetterEvent}
+
+	if err := d.MessageBus.Publish(context.Background(), d.DeadLetterTopic, message); err != nil {
+		log.Printf("publishing dead letter to dead letter topic failed: %s\n", err)
+	}
+}
+
+func (d *DeadLetterSer) GetOpen() ([]DeadLetterEntry, error) {
+
+	openEntities, err := d.DeadLetterRepository.GetOpen(100)
+
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetterEntries := make([]DeadLetterEntry, 0, len(openEntities))
+
+	for _, entity := range openEntities {
+		deadLetterEntries = append(deadLetterEntries, DeadLetterEntry{
+			Id:        entity.Id.Hex(),
+			Payload:   entity.Payload,
+			Reason:    entity.Reason,
+			Detail:    entity.Detail,
+			CreatedAt: entity.CreatedAt.Local().Format(repository.GermanDateTimeFormat),
+		})
+	}
+
+	return deadLetterEntries, nil
+}
+
+func (d *DeadLetterSer) CountOpen() int64 {
+	count, _ := d.DeadLetterRepository.CountOpen()
+
+	return count
+}
+
+// Replay marks the dead letter as replayed and routes the raw payload into the processing again.
+// If the event fails again, it is quarantined as a new dead letter.
+func (d *DeadLetterSer) Replay(ctx context.Context, id string) error {
+
+	entity, err := d.DeadLetterRepository.Get(id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := d.DeadLetterRepository.UpdateStatus(id, repository.DeadLetterReplayed); err != nil {
+		return err
+	}
+
+	if err := d.Redeliverer.Redeliver(ctx, []byte(entity.Payload)); err != nil {
+		log.Printf("replay of dead letter %s failed: %s\n", id, err)
+		return err
+	}
+
+	return nil
+}
+
+func (d *DeadLetterSer) Discard(id string) error {
+	return d.DeadLetterRepository.UpdateStatus(id, repository.DeadLetterDiscarded)
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"louie-web-administrator/louie_kafka"
+)
+
+type testDeadLetterService struct {
+	mock.Mock
+}
+
+func (testDeadLetterService *testDeadLetterService) Quarantine(message []byte, reason louie_kafka.DeadLetterReason, detail string) {
+	testDeadLetterService.Called(message, reason, detail)
+}
+
+func (testDeadLetterService *testDeadLetterService) GetOpen() ([]DeadLetterEntry, error) {
+	args := testDeadLetterService.Called()
+	return args.Get(0).([]DeadLetterEntry), args.Error(1)
+}
+
+func (testDeadLetterService *testDeadLetterService) CountOpen() int64 {
+	args := testDeadLetterService.Called()
+	return args.Get(0).(int64)
+}
+
+func (testDeadLetterService *testDeadLetterService) Replay(ctx context.Context, id string) error {
+	args := testDeadLetterService.Called(id)
+	return args.Error(0)
+}
+
+func (testDeadLetterService *testDeadLetterService) Discard(id string) error {
+	args := testDeadLetterService.Called(id)
+	return args.Error(0)
+}
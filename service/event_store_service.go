@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/repository"
+	"time"
+)
+
+type EventStoreService interface {
+	louie_kafka.EventRecorder
+	GetEvents(filter repository.EventFilter) ([]EventEntry, error)
+}
+
+type EventEntry struct {
+	Id        string
+	EventId   string
+	Event     string
+	Direction string
+	Sender    string
+	GameId    string
+	Outcome   string
+	Detail    string
+	Payload   string
+	CreatedAt string
+}
+
+// EventStoreSer keeps every consumed and published louie event as audit trail. Heartbeats are frequent, so
+// they are kept shorter than the other events.
+type EventStoreSer struct {
+	EventRepository repository.EventRepository
+	// Retention is the time the events are kept. Events are kept forever without retention.
+	Retention time.Duration
+	// HeartbeatRetention is the time the heartbeats are kept. Heartbeats are not stored without retention.
+	HeartbeatRetention time.Duration
+	now                func() time.Time
+}
+
+func NewEventStoreService(eventRepository repository.EventRepository, retention time.Duration, heartbeatRetention time.Duration) *EventStoreSer {
+	return &EventStoreSer{
+		EventRepository:    eventRepository,
+		Retention:          retention,
+		HeartbeatRetention: heartbeatRetention,
+		now:                time.Now,
+	}
+}
+
+func (e *EventStoreSer) Record(event louie_kafka.RecordedEvent) {
+
+	entity := repository.EventEntity{
+		Id:        primitive.NewObjectID(),
+		Direction: event.Direction.String(),
+		Topic:     event.Topic,
+		GameId:    event.Result.GameId,
+		Outcome:   event.Result.Outcome.String(),
+		Detail:    event.Result.Detail,
+		Payload:   string(event.Value),
+		CreatedAt: e.now().UTC(),
+	}
+
+	if envelope, err := louie_kafka.DecodeEnvelope(event.Value); err == nil {
+		entity.Event = envelope.Event.String()
+		entity.Sender = envelope.Sender
+	}
+
+	if entity.Event == louie_kafka.Heartbeat.String() && e.HeartbeatRetention <= 0 {
+		return
+	}
+
+	entity.EventId, _ = louie_kafka.EventId(event.Value)
+
+	// events, which were not applied to a game (e.g. rejected ones), are assigned to the game they name.
+	if entity.GameId == "" {
+		entity.GameId, _ = louie_kafka.GameIdOf(event.Value)
+	}
+
+	if err := e.EventRepository.Add(entity); err != nil {
+		log.Printf("storing %s event %s failed: %s\n", entity.Direction, entity.Event, err)
+	}
+}
+
+// GetEvents returns the newest events first.
+func (e *EventStoreSer) GetEvents(filter repository.EventFilter) ([]EventEntry, error) {
+
+	entities, err := e.EventRepository.Find(filter, 200)
+
+	if err != nil {
+		return nil, err
+	}
+
+	eventEntries := make([]EventEntry, 0, len(entities))
+
+	for _, entity := range entities {
+		eventEntries = append(eventEntries, EventEntry{
+			Id:        entity.Id.Hex(),
+			EventId:   entity.EventId,
+			Event:     entity.Event,
+			Direction: entity.Direction,
+			Sender:    entity.Sender,
+			GameId:    entity.GameId,
+			Outcome:   entity.Outcome,
+			Detail:    entity.Detail,
+			Payload:   entity.Payload,
+			CreatedAt: entity.CreatedAt.Local().Format(repository.GermanDateTimeFormat),
+		})
+	}
+
+	return eventEntries, nil
+}
+
+// RunEventRetention removes the events and the heartbeats older than their retention with every interval.
+func (e *EventStoreSer) RunEventRetention(ctx context.Context, interval time.Duration) {
+
+	if e.Retention <= 0 && e.HeartbeatRetention <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			e.removeExpiredEvents()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (e *EventStoreSer) removeExpiredEvents() {
+
+	if e.HeartbeatRetention > 0 {
+		removed, err := e.EventRepository.RemoveEventOlderThan(louie_kafka.Heartbeat.String(), e.now().UTC().Add(-e.HeartbeatRetention))
+
+		if err != nil {
+			log.Printf("removing expired heartbeats failed: %s\n", err)
+		} else if removed > 0 {
+			log.Printf("removed %d heartbeats older than %s\n", removed, e.HeartbeatRetention)
+		}
+	}
+
+	if e.Retention <= 0 {
+		return
+	}
+
+	removed, err := e.EventRepository.RemoveOlderThan(e.now().UTC().Add(-e.Retention))
+
+	if err != nil {
+		log.Printf("removing expired events failed: %s\n", err)
+		return
+	}
+
+	if removed > 0 {
+		log.Printf("removed %d events older than %s\n", removed, e.Retention)
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"github.com/stretchr/testify/assert"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/repository"
+	"testing"
+	"time"
+)
+
+func Test_EventStore_KeepsHeartbeatsShorter(t *testing.T) {
+
+	now := time.Date(2024, 1, 12, 10, 15, 0, 0, time.UTC)
+	eventStoreService := NewEventStoreService(repository.NewMemoryEventRepo(), 720*time.Hour, 24*time.Hour)
+	eventStoreService.now = func() time.Time { return now }
+
+	for _, value := range []string{
+		`{"event":"HEARTBEAT","sender":"c-library"}`,
+		`{"event":"COIN_DROP","sender":"c-library","name":"tobi","coins":2}`,
+	} {
+		eventStoreService.Record(louie_kafka.RecordedEvent{
+			Direction: louie_kafka.DirectionInbound,
+			Value:     []byte(value),
+			Result:    louie_kafka.ProcessingResult{Outcome: louie_kafka.OutcomeProcessed},
+		})
+	}
+
+	events, _ := eventStoreService.GetEvents(repository.EventFilter{})
+
+	assert.Len(t, events, 2)
+
+	now = now.Add(25 * time.Hour)
+	eventStoreService.removeExpiredEvents()
+
+	events, _ = eventStoreService.GetEvents(repository.EventFilter{})
+
+	assert.Len(t, events, 1)
+	assert.Equal(t, louie_kafka.CoinDrop.String(), events[0].Event)
+}
+
+func Test_EventStore_SkipsHeartbeatsWithoutRetention(t *testing.T) {
+
+	eventStoreService := NewEventStoreService(repository.NewMemoryEventRepo(), 0, 0)
+
+	eventStoreService.Record(louie_kafka.RecordedEvent{
+		Direction: louie_kafka.DirectionInbound,
+		Value:     []byte(`{"event":"HEARTBEAT","sender":"c-library"}`),
+		Result:    louie_kafka.ProcessingResult{Outcome: louie_kafka.OutcomeProcessed},
+	})
+
+	events, _ := eventStoreService.GetEvents(repository.EventFilter{})
+
+	assert.Empty(t, events)
+}
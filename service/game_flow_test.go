@@ -0,0 +1,1141 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/websocket"
+	"testing"
+	"time"
+)
+
+const (
+	flowInboundTopic  = "LOUIE_INBOUND"
+	flowOutboundTopic = "LOUIE_OUTBOUND"
+)
+
+var (
+	flowTable       = Table{Sender: "c-library", Name: "Louie", KiName: repository.KiName}
+	flowSecondTable = Table{Sender: "d-library", Name: "Louie 2", KiName: "Louki Louie 2"}
+)
+
+type gameFlow struct {
+	bus               *louie_kafka.MemoryBus
+	userRepository    *repository.MemoryUserRepo
+	userService       *UserSer
+	gameService       *GameSer
+	deadLetterService *DeadLetterSer
+	eventStoreService *EventStoreSer
+	matchQueueService *MatchQueueSer
+	dashboardChannels map[string]chan *websocket.DashboardSignal
+	adminUiChannel    chan websocket.AdminUiEvent
+}
+
+// initGameFlow wires the services like main.go, but with the in-memory repositories and message bus.
+func initGameFlow(ctx context.Context, t *testing.T) *gameFlow {
+
+	userRepository := repository.NewMemoryUserRepo()
+	outboxRepository := repository.NewMemoryOutboxRepo()
+	gameRepository := repository.NewMemoryGameRepo(outboxRepository)
+	bus := louie_kafka.NewMemoryBus()
+
+	gameEventsChannel := make(chan louie_kafka.ConsumedMessage)
+	technicalEventsChannel := make(chan louie_kafka.ConsumedMessage)
+	dashboardChannels := map[string]chan *websocket.DashboardSignal{
+		flowTable.Sender:       make(chan *websocket.DashboardSignal, 100),
+		flowSecondTable.Sender: make(chan *websocket.DashboardSignal, 100),
+	}
+	adminUiChannel := make(chan websocket.AdminUiEvent, 100)
+	tableService := NewTableService([]Table{flowTable, flowSecondTable})
+
+	adminUiWebsocket := websocket.InitAdminUiWebsocket(adminUiChannel)
+
+	outboxService := NewOutboxService(outboxRepository, bus, louie_kafka.Topics{Inbound: flowInboundTopic, Outbound: flowOutboundTopic}, adminUiWebsocket, 3, "louie-web-administrator", false)
+	userService := &UserSer{UserRepository: userRepository}
+	gameService := &GameSer{
+		UserRepository: userRepository,
+		GameRepository: gameRepository,
+		OutboxService:  outboxService,
+	}
+	deadLetterService := &DeadLetterSer{
+		DeadLetterRepository: repository.NewMemoryDeadLetterRepo(),
+		AdminUiSocket:        adminUiWebsocket,
+		MessageBus:           bus,
+	}
+	eventStoreService := NewEventStoreService(repository.NewMemoryEventRepo(), 0, 0)
+	outboxService.Recorder = eventStoreService
+
+	eventQueues := louie_kafka.NewEventQueues(
+		gameEventsChannel, louie_kafka.QueueConfig{Capacity: 100, Policy: louie_kafka.OverflowDropOldestCoinDrop},
+		technicalEventsChannel, louie_kafka.QueueConfig{Capacity: 100, Policy: louie_kafka.OverflowBlock},
+	)
+	eventQueues.Run(ctx)
+
+	router := &louie_kafka.Router{
+		GameEvents:      gameEventsChannel,
+		TechnicalEvents: technicalEventsChannel,
+		DeadLetterSink:  deadLetterService,
+		Sender:          "louie-web-administrator",
+		Recorder:        eventStoreService,
+		Queues:          eventQueues,
+	}
+	deadLetterService.Redeliverer = router
+
+	if err := bus.Subscribe(ctx, flowInboundTopic, router); err != nil {
+		t.Fatalf("subscribing to in-memory bus failed: %s", err)
+	}
+
+	outboxService.RunOutboxRelay(ctx, 10*time.Millisecond)
+
+	dashboardWebsocket := websocket.InitGameDashboardSocket(dashboardChannels, flowTable.Sender, gameService.GetCurrentDashboardState)
+
+	RunTechnicalEventHandler(technicalEventsChannel, adminUiChannel, outboxService, tableService)
+
+	matchQueueService := NewMatchQueueService(repository.NewMemoryMatchRepo(), userRepository, gameService, tableService, adminUiWebsocket, 0)
+	// the cool-down is not awaited, the next match is announced right after the finished game.
+	matchQueueService.schedule = func(_ time.Duration, announce func()) { announce() }
+
+	stateChanger := GameStateChecker{
+		UserService:         userService,
+		GameService:         gameService,
+		GameDashboardSocket: *dashboardWebsocket,
+		AdminUiSocket:       *adminUiWebsocket,
+		DeadLetterService:   deadLetterService,
+		Tables:              tableService,
+		MatchQueue:          matchQueueService,
+	}
+	stateChanger.RunGameStateChecker(gameEventsChannel)
+
+	userService.InitOrRefreshKiUsers(tableService.GetAll())
+
+	return &gameFlow{
+		bus:               bus,
+		userRepository:    userRepository,
+		userService:       userService,
+		gameService:       gameService,
+		deadLetterService: deadLetterService,
+		eventStoreService: eventStoreService,
+		matchQueueService: matchQueueService,
+		dashboardChannels: dashboardChannels,
+		adminUiChannel:    adminUiChannel,
+	}
+}
+
+func Test_GameFlow_AnnounceToGameDone(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	// --- announce ---
+	flow.registerActivePlayer(t, "tobi", "1")
+	flow.registerActivePlayer(t, "willi", "2")
+	flow.registerActivePlayer(t, "jann", "3")
+
+	activeUsers, _ := flow.userService.GetAllActive(flowTable.Sender)
+	gameId, err := flow.gameService.CreateGame(flowTable, repository.DefaultGameSettings(), activeUsers)
+
+	assert.Nil(t, err)
+
+	// --- PLAYERS_CAN_BE_RECEIVED ---
+	flow.publishFromLouie(t, louie_kafka.PlayersCanBeReceivedEvent{Event: louie_kafka.PlayersCanBeReceived, Sender: "c-library"})
+
+	assert.Equal(t, 3, flow.expectAdminUiEvent(t, websocket.Ready).Player1Coins)
+
+	playersReady := flow.expectPublishedPlayersReady(t)
+
+	assert.Equal(t, []louie_kafka.PlayerDisplayName{{DisplayName: "tobi", Seat: 1}, {DisplayName: "willi", Seat: 2}, {DisplayName: "jann", Seat: 3}}, playersReady.Players)
+	assert.Equal(t, repository.DefaultSeats, playersReady.Seats)
+	assert.Equal(t, gameId.Hex(), playersReady.GameId)
+
+	// --- PLAYERS_CONFIRM ---
+	flow.publishFromLouie(t, louie_kafka.DefaultEvent{Event: louie_kafka.PlayersConfirm})
+
+	assert.Equal(t, string(repository.GameActive), flow.expectDashboardSignal(t).DashboardGame.State)
+	flow.expectAdminUiEvent(t, websocket.Active)
+
+	// --- COIN_DROP ---
+	flow.publishFromLouie(t, louie_kafka.CoinDropEvent{Event: louie_kafka.CoinDrop, Sender: "c-library", Name: "willi", Coins: 2, GameId: gameId.Hex()})
+
+	assert.Equal(t, 2, flow.expectDashboardSignal(t).DashboardGame.Player2Coins)
+	assert.Equal(t, 2, flow.expectAdminUiEvent(t, websocket.Active).Player2Coins)
+
+	// --- GAME_DONE ---
+	gameDone, _ := json.Marshal(map[string]interface{}{
+		"event":          louie_kafka.GameDone,
+		"sender":         "c-library",
+		"duration":       42.0,
+		"winning_player": map[string]string{"name": "willi"},
+	})
+	flow.publishRawFromLouie(t, gameDone)
+
+	finishedSignal := flow.expectDashboardSignal(t)
+
+	assert.Equal(t, string(repository.GameFinished), finishedSignal.DashboardGame.State)
+	assert.Equal(t, "willi", finishedSignal.DashboardRanking[0].DisplayName)
+	flow.expectAdminUiEvent(t, websocket.Finished)
+
+	winner, _ := flow.userRepository.GetByDisplayName("willi")
+
+	assert.Equal(t, 1, winner.GamesWon)
+	assert.Equal(t, 1, winner.PlayedGames)
+	assert.Equal(t, 42.0, winner.BestDuration)
+
+	loser, _ := flow.userRepository.GetByDisplayName("tobi")
+
+	assert.Equal(t, 0, loser.GamesWon)
+	assert.Equal(t, 1, loser.PlayedGames)
+}
+
+func Test_GameFlow_FinishedGamesAreArchived(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+	flow.registerActivePlayer(t, "willi", "2")
+
+	activeUsers, _ := flow.userService.GetAllActive(flowTable.Sender)
+	firstGameId, _ := flow.gameService.CreateGame(flowTable, repository.DefaultGameSettings(), activeUsers)
+
+	_, err := flow.gameService.CreateGame(flowTable, repository.DefaultGameSettings(), activeUsers)
+
+	assert.ErrorIs(t, err, ErrGameRunning)
+
+	_, _ = flow.gameService.UpdateGameState(firstGameId.Hex(), repository.GameActive)
+
+	gameDone, _ := json.Marshal(map[string]interface{}{
+		"event":          louie_kafka.GameDone,
+		"sender":         "c-library",
+		"duration":       42.0,
+		"winning_player": map[string]string{"name": "willi"},
+	})
+	flow.publishRawFromLouie(t, gameDone)
+	flow.expectAdminUiEvent(t, websocket.Finished)
+
+	// the finished game stays the current game until the next game is announced.
+	finishedGame, _ := flow.gameService.GetCurrentGame(flowTable.Sender)
+
+	assert.Equal(t, firstGameId.Hex(), finishedGame.Id)
+	assert.Equal(t, "willi", finishedGame.Winner)
+	assert.NotEmpty(t, finishedGame.FinishedAt)
+
+	secondGameId, err := flow.gameService.CreateGame(flowTable, repository.DefaultGameSettings(), activeUsers)
+
+	assert.Nil(t, err)
+
+	currentGame, _ := flow.gameService.GetCurrentGame(flowTable.Sender)
+
+	assert.Equal(t, secondGameId.Hex(), currentGame.Id)
+	assert.Empty(t, currentGame.FinishedAt)
+
+	games, _ := flow.gameService.GetGames(repository.GameFilter{Table: flowTable.Sender}, 1)
+
+	assert.Len(t, games, 2)
+	assert.Equal(t, secondGameId.Hex(), games[0].Id)
+	assert.Equal(t, firstGameId.Hex(), games[1].Id)
+	assert.Equal(t, 42.0, games[1].Duration)
+	assert.Equal(t, "willi", games[1].Player2)
+
+	assert.Equal(t, int64(1), flow.gameService.CountGames(repository.GameFilter{State: repository.GameFinished, Player: "willi"}))
+	assert.Equal(t, int64(0), flow.gameService.CountGames(repository.GameFilter{Table: flowSecondTable.Sender}))
+}
+
+func Test_GameFlow_SeatsAndStartingCoins(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+	flow.registerActivePlayer(t, "willi", "2")
+
+	activeUsers, _ := flow.userService.GetAllActive(flowTable.Sender)
+
+	// the ki takes the fourth place at louie.
+	_, err := flow.gameService.CreateGame(flowTable, repository.GameSettings{Seats: 4, StartingCoins: 3}, activeUsers)
+
+	assert.ErrorIs(t, err, ErrInvalidGameSettings)
+
+	_, err = flow.gameService.CreateGame(flowTable, repository.GameSettings{Seats: 1, StartingCoins: 3}, activeUsers)
+
+	assert.ErrorIs(t, err, ErrInvalidGameSettings)
+
+	_, err = flow.gameService.CreateGame(flowTable, repository.GameSettings{Seats: 2, StartingCoins: 0}, activeUsers)
+
+	assert.ErrorIs(t, err, ErrInvalidGameSettings)
+
+	gameId, err := flow.gameService.CreateGame(flowTable, repository.GameSettings{Seats: 2, StartingCoins: 5}, activeUsers)
+
+	assert.Nil(t, err)
+
+	flow.publishFromLouie(t, louie_kafka.PlayersCanBeReceivedEvent{Event: louie_kafka.PlayersCanBeReceived, Sender: "c-library"})
+
+	readyEvent := flow.expectAdminUiEvent(t, websocket.Ready)
+
+	assert.Equal(t, 5, readyEvent.Player1Coins)
+	assert.Equal(t, 5, readyEvent.KiCoins)
+	assert.Equal(t, 0, readyEvent.Player3Coins)
+
+	playersReady := flow.expectPublishedPlayersReady(t)
+
+	assert.Equal(t, gameId.Hex(), playersReady.GameId)
+	assert.Equal(t, 2, playersReady.Seats)
+	assert.Equal(t, 5, playersReady.StartingCoins)
+	assert.Equal(t, []louie_kafka.PlayerDisplayName{{DisplayName: "tobi", Seat: 1}, {DisplayName: "willi", Seat: 2}}, playersReady.Players)
+}
+
+func Test_GameFlow_FourPlayersWithoutKi(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+	flow.registerActivePlayer(t, "willi", "2")
+	flow.registerActivePlayer(t, "jann", "3")
+	flow.registerActivePlayer(t, "emil", "4")
+
+	activeUsers, _ := flow.userService.GetAllActive(flowTable.Sender)
+
+	_, err := flow.gameService.CreateGame(flowTable, repository.GameSettings{Seats: 4, StartingCoins: 3}, activeUsers)
+
+	assert.ErrorIs(t, err, ErrInvalidGameSettings)
+
+	gameId, err := flow.gameService.CreateGame(flowTable, repository.GameSettings{Seats: 4, StartingCoins: 3, WithoutKi: true}, activeUsers)
+
+	assert.Nil(t, err)
+
+	flow.publishFromLouie(t, louie_kafka.PlayersCanBeReceivedEvent{Event: louie_kafka.PlayersCanBeReceived, Sender: "c-library"})
+
+	readyEvent := flow.expectAdminUiEvent(t, websocket.Ready)
+
+	assert.Equal(t, 0, readyEvent.KiCoins)
+	assert.Equal(t, 3, readyEvent.Player4Coins)
+
+	playersReady := flow.expectPublishedPlayersReady(t)
+
+	assert.True(t, playersReady.WithoutKi)
+	assert.Len(t, playersReady.Players, 4)
+	assert.Equal(t, louie_kafka.PlayerDisplayName{DisplayName: "emil", Seat: 4}, playersReady.Players[3])
+
+	flow.publishFromLouie(t, louie_kafka.DefaultEvent{Event: louie_kafka.PlayersConfirm})
+
+	dashboardGame := flow.expectDashboardSignal(t).DashboardGame
+
+	assert.False(t, dashboardGame.WithKi)
+	assert.Empty(t, dashboardGame.KiName)
+
+	// the ki sits out, so its coins are rejected.
+	flow.publishFromLouie(t, louie_kafka.CoinDropEvent{Event: louie_kafka.CoinDrop, Sender: "c-library", Name: flowTable.KiName, Coins: 2, GameId: gameId.Hex()})
+	flow.expectAdminUiEvent(t, websocket.DeadLetterStatus)
+
+	flow.publishFromLouie(t, louie_kafka.CoinDropEvent{Event: louie_kafka.CoinDrop, Sender: "c-library", Name: "emil", Coins: 2, GameId: gameId.Hex()})
+
+	assert.Equal(t, 2, flow.expectDashboardSignal(t).DashboardGame.Player4Coins)
+
+	gameDone, _ := json.Marshal(map[string]interface{}{
+		"event":          louie_kafka.GameDone,
+		"sender":         "c-library",
+		"duration":       42.0,
+		"winning_player": map[string]string{"name": "emil"},
+		"game_id":        gameId.Hex(),
+	})
+	flow.publishRawFromLouie(t, gameDone)
+	flow.expectAdminUiEvent(t, websocket.Finished)
+
+	ki, _ := flow.userRepository.GetByDisplayName(flowTable.KiName)
+
+	assert.Equal(t, 0, ki.PlayedGames)
+
+	rankings, _ := flow.gameService.GetRankingsSorted()
+
+	assert.Len(t, rankings, 4)
+	assert.Equal(t, "emil", rankings[0].DisplayName)
+}
+
+func Test_GameFlow_MatchQueue(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+	flow.registerActivePlayer(t, "willi", "2")
+	flow.registerActivePlayer(t, "jann", "")
+	flow.registerActivePlayer(t, "emil", "")
+
+	_, err := flow.matchQueueService.Enqueue(flowTable, []string{"tobi", "unknown"}, repository.DefaultGameSettings())
+
+	assert.ErrorIs(t, err, ErrInvalidMatch)
+
+	_, err = flow.matchQueueService.Enqueue(flowTable, []string{"tobi", "tobi"}, repository.DefaultGameSettings())
+
+	assert.ErrorIs(t, err, ErrInvalidMatch)
+
+	firstMatchId, _ := flow.matchQueueService.Enqueue(flowTable, []string{"tobi", "willi"}, repository.DefaultGameSettings())
+	secondMatchId, _ := flow.matchQueueService.Enqueue(flowTable, []string{"jann"}, repository.DefaultGameSettings())
+	thirdMatchId, _ := flow.matchQueueService.Enqueue(flowTable, []string{"tobi"}, repository.DefaultGameSettings())
+
+	assert.Nil(t, flow.matchQueueService.Move(secondMatchId.Hex(), -1))
+	assert.Nil(t, flow.matchQueueService.Edit(secondMatchId.Hex(), []string{"jann", "emil"}, repository.GameSettings{StartingCoins: 5}))
+	assert.Nil(t, flow.matchQueueService.Skip(thirdMatchId.Hex()))
+	assert.ErrorIs(t, flow.matchQueueService.Skip(thirdMatchId.Hex()), ErrMatchNotQueued)
+
+	matches, _ := flow.matchQueueService.GetQueued(flowTable.Sender)
+
+	assert.Equal(t, []MatchEntry{
+		{Id: secondMatchId.Hex(), Players: "jann, emil", Seats: 2, StartingCoins: 5},
+		{Id: firstMatchId.Hex(), Players: "tobi, willi", Seats: 2, StartingCoins: 3},
+	}, matches)
+
+	gameId, err := flow.matchQueueService.AnnounceNext(flowTable)
+
+	assert.Nil(t, err)
+
+	_, err = flow.matchQueueService.AnnounceNext(flowTable)
+
+	assert.ErrorIs(t, err, ErrGameRunning)
+
+	currentGame, _ := flow.gameService.GetCurrentGame(flowTable.Sender)
+
+	assert.Equal(t, gameId.Hex(), currentGame.Id)
+	assert.Equal(t, "jann", currentGame.Player1)
+	assert.Equal(t, "emil", currentGame.Player2)
+
+	tobi, _ := flow.userRepository.GetByDisplayName("tobi")
+
+	assert.Equal(t, repository.UserWaiting, tobi.State)
+
+	_, _ = flow.gameService.UpdateGameState(gameId.Hex(), repository.GameActive)
+
+	gameDone, _ := json.Marshal(map[string]interface{}{
+		"event":          louie_kafka.GameDone,
+		"sender":         "c-library",
+		"duration":       42.0,
+		"winning_player": map[string]string{"name": "emil"},
+		"game_id":        gameId.Hex(),
+	})
+	flow.publishRawFromLouie(t, gameDone)
+	flow.expectAdminUiEvent(t, websocket.Finished)
+
+	announced := flow.expectAdminUiEvent(t, websocket.MatchAnnounced)
+	nextGame, _ := flow.gameService.GetCurrentGame(flowTable.Sender)
+
+	assert.Equal(t, nextGame.Id, announced.GameId)
+	assert.Equal(t, repository.GameAnnounced, nextGame.State)
+	assert.Equal(t, "tobi", nextGame.Player1)
+	assert.Equal(t, "willi", nextGame.Player2)
+
+	jann, _ := flow.userRepository.GetByDisplayName("jann")
+
+	assert.Equal(t, repository.UserWaiting, jann.State)
+
+	matches, _ = flow.matchQueueService.GetQueued(flowTable.Sender)
+
+	assert.Empty(t, matches)
+}
+
+func Test_GameFlow_MatchWithBusyPlayerChangesNoUser(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+	flow.registerActivePlayer(t, "willi", "")
+	flow.registerActivePlayerAt(t, flowSecondTable, "jann", "1")
+
+	_, err := flow.matchQueueService.Enqueue(flowTable, []string{"willi", "jann"}, repository.DefaultGameSettings())
+
+	assert.Nil(t, err)
+
+	_, err = flow.matchQueueService.AnnounceNext(flowTable)
+
+	assert.ErrorIs(t, err, ErrPlayerBusy)
+
+	// the removed player is found before any user is changed.
+	_ = flow.userRepository.Remove("jann")
+
+	_, err = flow.matchQueueService.AnnounceNext(flowTable)
+
+	assert.ErrorIs(t, err, ErrInvalidMatch)
+
+	tobi, _ := flow.userRepository.GetByDisplayName("tobi")
+
+	assert.Equal(t, repository.UserActive, tobi.State)
+	assert.Equal(t, "1", tobi.Pos)
+
+	matches, _ := flow.matchQueueService.GetQueued(flowTable.Sender)
+
+	assert.Len(t, matches, 1)
+}
+
+func Test_GameFlow_MatchOfOfflineLouieChangesNoUser(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+	flow.registerActivePlayer(t, "willi", "")
+
+	_, err := flow.matchQueueService.Enqueue(flowTable, []string{"willi"}, repository.DefaultGameSettings())
+
+	assert.Nil(t, err)
+
+	presenceService := NewPresenceService([]Device{{Name: "Louie", Sender: flowTable.Sender, Table: true}}, time.Second, time.Second, websocket.InitAdminUiWebsocket(flow.adminUiChannel))
+	presenceService.startedAt = time.Now().Add(-time.Minute)
+	flow.gameService.Presence = presenceService
+
+	_, err = flow.matchQueueService.AnnounceNext(flowTable)
+
+	assert.ErrorIs(t, err, ErrLouieOffline)
+
+	tobi, _ := flow.userRepository.GetByDisplayName("tobi")
+	willi, _ := flow.userRepository.GetByDisplayName("willi")
+
+	assert.Equal(t, repository.UserActive, tobi.State)
+	assert.Equal(t, "1", tobi.Pos)
+	assert.Equal(t, "", willi.Pos)
+
+	matches, _ := flow.matchQueueService.GetQueued(flowTable.Sender)
+
+	assert.Len(t, matches, 1)
+}
+
+func Test_GameFlow_ConcurrentAnnouncementsCreateOneGame(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "")
+
+	_, err := flow.matchQueueService.Enqueue(flowTable, []string{"tobi"}, repository.DefaultGameSettings())
+
+	assert.Nil(t, err)
+
+	// the admin ui announces the match, while the cool-down ends.
+	announcements := make(chan error, 2)
+
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := flow.matchQueueService.AnnounceNext(flowTable)
+			announcements <- err
+		}()
+	}
+
+	first, second := <-announcements, <-announcements
+
+	if first != nil {
+		first, second = second, first
+	}
+
+	assert.Nil(t, first)
+	assert.ErrorIs(t, second, ErrQueueEmpty)
+
+	matches, _ := flow.matchQueueService.GetQueued(flowTable.Sender)
+
+	assert.Empty(t, matches)
+}
+
+func Test_GameFlow_MatchQueueResumesAfterRestart(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+
+	activeUsers, _ := flow.userService.GetAllActive(flowTable.Sender)
+	gameId, _ := flow.gameService.CreateGame(flowTable, repository.DefaultGameSettings(), activeUsers)
+
+	_, _ = flow.gameService.UpdateGameState(gameId.Hex(), repository.GameActive)
+	_, _ = flow.gameService.FinishGame(gameId.Hex(), 42.0, "tobi")
+
+	flow.registerActivePlayer(t, "willi", "")
+
+	_, err := flow.matchQueueService.Enqueue(flowTable, []string{"willi"}, repository.DefaultGameSettings())
+
+	assert.Nil(t, err)
+
+	// the service restarts during the cool-down of the finished game.
+	var delays []time.Duration
+	var announcements []func()
+
+	flow.matchQueueService.CoolDown = time.Hour
+	flow.matchQueueService.schedule = func(delay time.Duration, announce func()) {
+		delays = append(delays, delay)
+		announcements = append(announcements, announce)
+	}
+	flow.matchQueueService.ResumeAfterRestart()
+
+	assert.Len(t, delays, 1)
+	assert.True(t, delays[0] > 59*time.Minute && delays[0] <= time.Hour)
+
+	announcements[0]()
+
+	announced := flow.expectAdminUiEvent(t, websocket.MatchAnnounced)
+	nextGame, _ := flow.gameService.GetCurrentGame(flowTable.Sender)
+
+	assert.Equal(t, nextGame.Id, announced.GameId)
+	assert.Equal(t, "willi", nextGame.Player1)
+}
+
+func Test_GameFlow_RedeliveredGameDoneIsCountedOnce(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+	flow.registerActivePlayer(t, "willi", "2")
+
+	activeUsers, _ := flow.userService.GetAllActive(flowTable.Sender)
+	gameId, _ := flow.gameService.CreateGame(flowTable, repository.DefaultGameSettings(), activeUsers)
+
+	_, _ = flow.gameService.UpdateGameState(gameId.Hex(), repository.GameActive)
+
+	gameDone, _ := louie_kafka.NewEnvelopeMessage(
+		"65a1f0c2e4b0a1b2c3d4e5f6",
+		louie_kafka.GameDone,
+		"c-library",
+		time.Now(),
+		[]byte(`{"duration":42.0,"winning_player":{"name":"willi"}}`),
+	)
+
+	flow.publishRawFromLouie(t, gameDone)
+	flow.expectAdminUiEvent(t, websocket.Finished)
+
+	// redelivery after a crash or rebalance. The invalid COIN_DROP afterwards marks the end of the processing.
+	invalidCoinDrop := `{"event":"COIN_DROP","sender":"c-library","name":"tobi","coins":-1}`
+
+	flow.publishRawFromLouie(t, gameDone)
+	flow.publishRawFromLouie(t, []byte(invalidCoinDrop))
+	flow.expectAdminUiEvent(t, websocket.DeadLetterStatus)
+
+	deadLetters, _ := flow.deadLetterService.GetOpen()
+
+	assert.Len(t, deadLetters, 1)
+	assert.Equal(t, invalidCoinDrop, deadLetters[0].Payload)
+
+	winner, _ := flow.userRepository.GetByDisplayName("willi")
+
+	assert.Equal(t, 1, winner.GamesWon)
+	assert.Equal(t, 1, winner.PlayedGames)
+
+	currentGame, _ := flow.gameService.GetCurrentGame(flowTable.Sender)
+
+	assert.Equal(t, []string{"65a1f0c2e4b0a1b2c3d4e5f6"}, currentGame.ProcessedEvents)
+}
+
+func Test_GameFlow_EventsAreStoredWithOutcome(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+
+	activeUsers, _ := flow.userService.GetAllActive(flowTable.Sender)
+	gameId, _ := flow.gameService.CreateGame(flowTable, repository.DefaultGameSettings(), activeUsers)
+
+	flow.publishFromLouie(t, louie_kafka.PlayersCanBeReceivedEvent{Event: louie_kafka.PlayersCanBeReceived, Sender: "c-library"})
+	flow.expectAdminUiEvent(t, websocket.Ready)
+	flow.expectPublishedPlayersReady(t)
+
+	// GAME_DONE is not allowed before PLAYERS_CONFIRM
+	flow.publishRawFromLouie(t, []byte(`{"event":"GAME_DONE","sender":"c-library","duration":42.0,"winning_player":{"name":"tobi"}}`))
+	flow.expectAdminUiEvent(t, websocket.DeadLetterStatus)
+
+	assert.Eventually(t, func() bool {
+		events, _ := flow.eventStoreService.GetEvents(repository.EventFilter{GameId: gameId.Hex()})
+		return len(events) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	events, err := flow.eventStoreService.GetEvents(repository.EventFilter{GameId: gameId.Hex()})
+
+	assert.Nil(t, err)
+
+	outcomes := make(map[string]string)
+
+	for _, event := range events {
+		outcomes[event.Direction+" "+event.Event] = event.Outcome
+	}
+
+	assert.Equal(t, map[string]string{
+		"inbound PLAYERS_CAN_BE_RECEIVED": "processed",
+		"outbound PLAYERS_READY":          "published",
+		"inbound GAME_DONE":               "rejected",
+	}, outcomes)
+
+	gameDoneEvents, _ := flow.eventStoreService.GetEvents(repository.EventFilter{Event: louie_kafka.GameDone.String()})
+
+	assert.Len(t, gameDoneEvents, 1)
+	assert.Equal(t, "c-library", gameDoneEvents[0].Sender)
+}
+
+func Test_GameFlow_HistoryRecordsTransitions(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+
+	activeUsers, _ := flow.userService.GetAllActive(flowTable.Sender)
+	_, _ = flow.gameService.CreateGame(flowTable, repository.DefaultGameSettings(), activeUsers)
+
+	flow.publishFromLouie(t, louie_kafka.PlayersCanBeReceivedEvent{Event: louie_kafka.PlayersCanBeReceived, Sender: "c-library"})
+	flow.expectAdminUiEvent(t, websocket.Ready)
+
+	// the game is not active yet
+	flow.publishRawFromLouie(t, []byte(`{"event":"GAME_DONE","sender":"c-library","duration":42.0,"winning_player":{"name":"tobi"}}`))
+	flow.expectAdminUiEvent(t, websocket.DeadLetterStatus)
+
+	flow.publishFromLouie(t, louie_kafka.DefaultEvent{Event: louie_kafka.PlayersConfirm})
+	flow.expectAdminUiEvent(t, websocket.Active)
+	flow.publishFromLouie(t, louie_kafka.CoinDropEvent{Event: louie_kafka.CoinDrop, Sender: "c-library", Name: "tobi", Coins: 2})
+	flow.expectAdminUiEvent(t, websocket.Active)
+	flow.publishRawFromLouie(t, []byte(`{"event":"GAME_DONE","sender":"c-library","duration":42.0,"winning_player":{"name":"tobi"}}`))
+	flow.expectAdminUiEvent(t, websocket.Finished)
+
+	// the transition is recorded after the admin ui is informed
+	var currentGame *GameEntry
+	assert.Eventually(t, func() bool {
+		currentGame, _ = flow.gameService.GetCurrentGame(flowTable.Sender)
+		return len(currentGame.History) == 5
+	}, time.Second, 10*time.Millisecond)
+
+	transitions := make([]string, 0, len(currentGame.History))
+	for _, transition := range currentGame.History {
+		transitions = append(transitions, fmt.Sprintf("%s %s->%s %t", transition.Event, transition.From, transition.To, transition.Rejected))
+	}
+
+	assert.Equal(t, []string{
+		"ADMIN_ANNOUNCE ->announced false",
+		"PLAYERS_CAN_BE_RECEIVED announced->ready false",
+		"GAME_DONE ready->ready true",
+		"PLAYERS_CONFIRM ready->active false",
+		"GAME_DONE active->finished false",
+	}, transitions)
+	assert.Equal(t, "current game state is \"ready\"", currentGame.History[2].Detail)
+}
+
+func Test_GameFlow_PauseAndResume(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+
+	activeUsers, _ := flow.userService.GetAllActive(flowTable.Sender)
+	gameId, _ := flow.gameService.CreateGame(flowTable, repository.DefaultGameSettings(), activeUsers)
+
+	flow.publishFromLouie(t, louie_kafka.PlayersCanBeReceivedEvent{Event: louie_kafka.PlayersCanBeReceived, Sender: "c-library"})
+	flow.expectAdminUiEvent(t, websocket.Ready)
+	flow.publishFromLouie(t, louie_kafka.DefaultEvent{Event: louie_kafka.PlayersConfirm})
+	flow.expectDashboardSignal(t)
+
+	// --- paused by louie ---
+	flow.publishFromLouie(t, louie_kafka.PauseGameEvent{Event: louie_kafka.PauseGame, Sender: "c-library", GameId: gameId.Hex()})
+
+	pausedSignal := flow.expectDashboardSignal(t)
+
+	assert.True(t, pausedSignal.DashboardGame.Paused)
+	assert.Equal(t, string(repository.GamePaused), pausedSignal.DashboardGame.State)
+	flow.expectAdminUiEvent(t, websocket.Paused)
+
+	flow.publishFromLouie(t, louie_kafka.CoinDropEvent{Event: louie_kafka.CoinDrop, Sender: "c-library", Name: "tobi", Coins: 2})
+
+	assert.Equal(t, int64(1), flow.expectAdminUiEvent(t, websocket.DeadLetterStatus).DeadLetters)
+
+	// --- resumed by louie ---
+	flow.publishFromLouie(t, louie_kafka.PauseGameEvent{Event: louie_kafka.ResumeGame, Sender: "c-library", GameId: gameId.Hex()})
+
+	assert.False(t, flow.expectDashboardSignal(t).DashboardGame.Paused)
+
+	flow.publishFromLouie(t, louie_kafka.CoinDropEvent{Event: louie_kafka.CoinDrop, Sender: "c-library", Name: "tobi", Coins: 2})
+
+	assert.Equal(t, 2, flow.expectDashboardSignal(t).DashboardGame.Player1Coins)
+
+	// --- paused by the admin ---
+	pausedGame, err := flow.gameService.PauseGame(gameId.Hex())
+
+	assert.Nil(t, err)
+	assert.Equal(t, repository.GamePaused, pausedGame.State)
+	assert.Contains(t, string(flow.expectPublished(t, louie_kafka.PauseGame)), gameId.Hex())
+
+	_, err = flow.gameService.PauseGame(gameId.Hex())
+
+	assert.ErrorIs(t, err, ErrGameNotPausable)
+
+	resumedGame, err := flow.gameService.ResumeGame(gameId.Hex())
+
+	assert.Nil(t, err)
+	assert.Equal(t, repository.GameActive, resumedGame.State)
+	flow.expectPublished(t, louie_kafka.ResumeGame)
+}
+
+func Test_GameFlow_PauseAndResumeTwiceWithLegacyEvents(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+
+	activeUsers, _ := flow.userService.GetAllActive(flowTable.Sender)
+	gameId, _ := flow.gameService.CreateGame(flowTable, repository.DefaultGameSettings(), activeUsers)
+
+	flow.publishFromLouie(t, louie_kafka.PlayersCanBeReceivedEvent{Event: louie_kafka.PlayersCanBeReceived, Sender: "c-library"})
+	flow.expectAdminUiEvent(t, websocket.Ready)
+	flow.publishFromLouie(t, louie_kafka.DefaultEvent{Event: louie_kafka.PlayersConfirm})
+	flow.expectDashboardSignal(t)
+
+	// the legacy events have no id and no timestamp, so both pauses have the same id.
+	pause := fmt.Sprintf(`{"event":"PAUSE_GAME","sender":"c-library","game_id":"%s"}`, gameId.Hex())
+	resume := fmt.Sprintf(`{"event":"RESUME_GAME","sender":"c-library","game_id":"%s"}`, gameId.Hex())
+
+	for i := 0; i < 2; i++ {
+		flow.publishRawFromLouie(t, []byte(pause))
+
+		assert.True(t, flow.expectDashboardSignal(t).DashboardGame.Paused)
+
+		flow.publishRawFromLouie(t, []byte(resume))
+
+		assert.False(t, flow.expectDashboardSignal(t).DashboardGame.Paused)
+	}
+
+	game, _ := flow.gameService.GetCurrentGame(flowTable.Sender)
+	deadLetters, _ := flow.deadLetterService.GetOpen()
+
+	assert.Equal(t, repository.GameActive, game.State)
+	assert.Empty(t, deadLetters)
+}
+
+func Test_GameFlow_AbortKeepsGameWithoutStatistics(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+
+	activeUsers, _ := flow.userService.GetAllActive(flowTable.Sender)
+	gameId, _ := flow.gameService.CreateGame(flowTable, repository.DefaultGameSettings(), activeUsers)
+
+	_, _ = flow.gameService.UpdateGameState(gameId.Hex(), repository.GameActive)
+
+	_, err := flow.gameService.AbortGame(gameId.Hex(), " ")
+
+	assert.ErrorIs(t, err, ErrAbortReasonMissing)
+
+	abortedGame, err := flow.gameService.AbortGame(gameId.Hex(), "player left")
+
+	assert.Nil(t, err)
+	assert.Equal(t, repository.GameAborted, abortedGame.State)
+	assert.Equal(t, "player left", abortedGame.AbortReason)
+	assert.Contains(t, string(flow.expectPublished(t, louie_kafka.AbortGame)), "player left")
+
+	_, err = flow.gameService.AbortGame(gameId.Hex(), "dispute")
+
+	assert.ErrorIs(t, err, ErrGameNotAbortable)
+
+	// a late GAME_DONE of louie does not count the aborted game.
+	gameDone, _ := json.Marshal(map[string]interface{}{
+		"event":          louie_kafka.GameDone,
+		"sender":         "c-library",
+		"duration":       42.0,
+		"winning_player": map[string]string{"name": "tobi"},
+	})
+	flow.publishRawFromLouie(t, gameDone)
+	flow.expectAdminUiEvent(t, websocket.DeadLetterStatus)
+
+	player, _ := flow.userRepository.GetByDisplayName("tobi")
+
+	assert.Equal(t, 0, player.GamesWon)
+	assert.Equal(t, 0, player.PlayedGames)
+
+	currentGame, _ := flow.gameService.GetCurrentGame(flowTable.Sender)
+
+	assert.Equal(t, repository.GameAborted, currentGame.State)
+	assert.Equal(t, louie_kafka.AbortGame.String(), currentGame.History[1].Event)
+	assert.Equal(t, "player left", currentGame.History[1].Detail)
+
+	dashboardState, _ := flow.gameService.GetCurrentDashboardState(flowTable.Sender)
+
+	assert.Equal(t, "player left", dashboardState.DashboardGame.AbortReason)
+}
+
+func Test_GameFlow_ResetByAdmin(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+	flow.registerActivePlayer(t, "willi", "2")
+
+	activeUsers, _ := flow.userService.GetAllActive(flowTable.Sender)
+	gameId, _ := flow.gameService.CreateGame(flowTable, repository.DefaultGameSettings(), activeUsers)
+
+	flow.publishFromLouie(t, louie_kafka.PlayersCanBeReceivedEvent{Event: louie_kafka.PlayersCanBeReceived, Sender: "c-library"})
+	flow.expectAdminUiEvent(t, websocket.Ready)
+	flow.publishFromLouie(t, louie_kafka.DefaultEvent{Event: louie_kafka.PlayersConfirm})
+	flow.expectAdminUiEvent(t, websocket.Active)
+	flow.publishFromLouie(t, louie_kafka.CoinDropEvent{Event: louie_kafka.CoinDrop, Sender: "c-library", Name: "willi", Coins: 1})
+	flow.expectAdminUiEvent(t, websocket.Active)
+
+	resetGame, err := flow.gameService.ResetGame(gameId.Hex())
+
+	assert.Nil(t, err)
+	assert.Equal(t, repository.GameAnnounced, resetGame.State)
+	assert.Equal(t, 3, resetGame.Player2Coins)
+	assert.Equal(t, 0, resetGame.Player3Coins)
+
+	published := flow.expectPublished(t, louie_kafka.ResetGame)
+
+	assert.Contains(t, string(published), gameId.Hex())
+
+	// louie starts the round again
+	flow.publishFromLouie(t, louie_kafka.PlayersCanBeReceivedEvent{Event: louie_kafka.PlayersCanBeReceived, Sender: "c-library"})
+	flow.expectAdminUiEvent(t, websocket.Ready)
+
+	player, _ := flow.userRepository.GetByDisplayName("willi")
+
+	assert.Equal(t, 0, player.PlayedGames)
+}
+
+func Test_GameFlow_ResetByLouie(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+
+	activeUsers, _ := flow.userService.GetAllActive(flowTable.Sender)
+	_, _ = flow.gameService.CreateGame(flowTable, repository.DefaultGameSettings(), activeUsers)
+
+	flow.publishFromLouie(t, louie_kafka.PlayersCanBeReceivedEvent{Event: louie_kafka.PlayersCanBeReceived, Sender: "c-library"})
+	flow.expectAdminUiEvent(t, websocket.Ready)
+
+	flow.publishFromLouie(t, louie_kafka.ResetGameEvent{Event: louie_kafka.ResetGame, Sender: "c-library"})
+
+	assert.Equal(t, 3, flow.expectAdminUiEvent(t, websocket.Announced).Player1Coins)
+	assert.Nil(t, flow.expectDashboardSignal(t).DashboardGame)
+
+	currentGame, _ := flow.gameService.GetCurrentGame(flowTable.Sender)
+
+	assert.Equal(t, repository.GameAnnounced, currentGame.State)
+}
+
+func Test_GameFlow_InvalidEventIsQuarantined(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.publishRawFromLouie(t, []byte(`{"event":"COIN_DROP","coins":-1}`))
+
+	assert.Equal(t, int64(1), flow.expectAdminUiEvent(t, websocket.DeadLetterStatus).DeadLetters)
+}
+
+func Test_GameFlow_TablesArePlayedIndependently(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := initGameFlow(ctx, t)
+	defer flow.bus.Close()
+
+	flow.registerActivePlayer(t, "tobi", "1")
+	flow.registerActivePlayerAt(t, flowSecondTable, "willi", "1")
+
+	activeUsers, _ := flow.userService.GetAllActive(flowSecondTable.Sender)
+
+	assert.Len(t, activeUsers, 2)
+
+	gameId, err := flow.gameService.CreateGame(flowSecondTable, repository.DefaultGameSettings(), activeUsers)
+
+	assert.Nil(t, err)
+
+	// the louie of the first table has no game
+	flow.publishFromLouie(t, louie_kafka.PlayersCanBeReceivedEvent{Event: louie_kafka.PlayersCanBeReceived, Sender: flowSecondTable.Sender})
+
+	readyEvent := flow.expectAdminUiEvent(t, websocket.Ready)
+
+	assert.Equal(t, flowSecondTable.Sender, readyEvent.Table)
+
+	playersReady := flow.expectPublishedPlayersReady(t)
+
+	assert.Equal(t, flowSecondTable.Sender, playersReady.Table)
+	assert.Equal(t, "willi", playersReady.Players[0].DisplayName)
+
+	flow.publishFromLouie(t, louie_kafka.DefaultEvent{Event: louie_kafka.PlayersConfirm})
+	flow.publishRawFromLouie(t, []byte(`{"event":"PLAYERS_CONFIRM","sender":"d-library"}`))
+
+	assert.Equal(t, string(repository.GameActive), flow.expectDashboardSignalOf(t, flowSecondTable).DashboardGame.State)
+
+	firstTableGame, _ := flow.gameService.GetCurrentGame(flowTable.Sender)
+	secondTableGame, _ := flow.gameService.GetCurrentGame(flowSecondTable.Sender)
+
+	assert.Nil(t, firstTableGame)
+	assert.Equal(t, gameId.Hex(), secondTableGame.Id)
+	assert.Equal(t, flowSecondTable.KiName, secondTableGame.KiName)
+}
+
+func (flow *gameFlow) registerActivePlayer(t *testing.T, displayName string, position string) {
+	flow.registerActivePlayerAt(t, flowTable, displayName, position)
+}
+
+func (flow *gameFlow) registerActivePlayerAt(t *testing.T, table Table, displayName string, position string) {
+
+	result, err := flow.userRepository.Create(repository.RegisteredUser{DisplayName: displayName, Email: displayName + "@louie.de", Table: table.Sender})
+
+	if err != nil {
+		t.Fatalf("creating user %s failed: %s", displayName, err)
+	}
+
+	user, _ := flow.userRepository.GetByDisplayName(displayName)
+
+	assert.Equal(t, user.Id, result.InsertedID)
+
+	_, _ = flow.userRepository.UpdateState(user.Id.Hex(), string(repository.UserActive))
+	_, _ = flow.userRepository.UpdatePosition(user.Id.Hex(), position)
+}
+
+func (flow *gameFlow) publishFromLouie(t *testing.T, event interface{}) {
+
+	value, err := json.Marshal(event)
+
+	if err != nil {
+		t.Fatalf("marshal event failed: %s", err)
+	}
+
+	flow.publishRawFromLouie(t, value)
+}
+
+func (flow *gameFlow) publishRawFromLouie(t *testing.T, value []byte) {
+	if err := flow.bus.Publish(context.Background(), flowInboundTopic, louie_kafka.Message{Value: value}); err != nil {
+		t.Fatalf("publishing event failed: %s", err)
+	}
+}
+
+// expectAdminUiEvent skips admin ui events of other types, e.g. the outbox status.
+func (flow *gameFlow) expectAdminUiEvent(t *testing.T, eventType websocket.AdminUiEventType) websocket.AdminUiEvent {
+
+	timeout := time.After(2 * time.Second)
+
+	for {
+		select {
+		case adminUiEvent := <-flow.adminUiChannel:
+			if adminUiEvent.EventType == eventType {
+				return adminUiEvent
+			}
+		case <-timeout:
+			t.Fatalf("no admin ui event %s received", eventType)
+		}
+	}
+}
+
+func (flow *gameFlow) expectDashboardSignal(t *testing.T) *websocket.DashboardSignal {
+	return flow.expectDashboardSignalOf(t, flowTable)
+}
+
+func (flow *gameFlow) expectDashboardSignalOf(t *testing.T, table Table) *websocket.DashboardSignal {
+
+	select {
+	case dashboardSignal := <-flow.dashboardChannels[table.Sender]:
+		return dashboardSignal
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no dashboard signal received")
+	}
+
+	return nil
+}
+
+func (flow *gameFlow) expectPublished(t *testing.T, eventType louie_kafka.EventType) []byte {
+
+	timeout := time.After(2 * time.Second)
+
+	for {
+		for _, message := range flow.bus.Published(flowOutboundTopic) {
+			var event louie_kafka.DefaultEvent
+
+			if err := json.Unmarshal(message.Value, &event); err == nil && event.Event == eventType {
+				return message.Value
+			}
+		}
+
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-timeout:
+			t.Fatalf("%s was not published", eventType)
+		}
+	}
+}
+
+func (flow *gameFlow) expectPublishedPlayersReady(t *testing.T) louie_kafka.PlayersReadyEvent {
+
+	timeout := time.After(2 * time.Second)
+
+	for {
+		for _, message := range flow.bus.Published(flowOutboundTopic) {
+			var playersReady louie_kafka.PlayersReadyEvent
+
+			if err := json.Unmarshal(message.Value, &playersReady); err == nil && playersReady.Event == louie_kafka.PlayersReady {
+				return playersReady
+			}
+		}
+
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-timeout:
+			t.Fatalf("PLAYERS_READY was not published")
+		}
+	}
+}
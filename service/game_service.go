@@ -1,9 +1,9 @@
 package service
 
 import (
-	"context"
 	"encoding/json"
-	"github.com/segmentio/kafka-go"
+	"errors"
+	"fmt"
 	"github.com/thoas/go-funk"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -19,19 +19,53 @@ import (
 )
 
 type GameService interface {
-	SendPlayerReadyMessageToKafka(playerDisplayNames []louie_kafka.PlayerDisplayName)
-	CreateGame(gameMembers []repository.RegisteredUser) (*primitive.ObjectID, error)
+	SetGameReady(gameId string, playerDisplayNames []louie_kafka.PlayerDisplayName) (*GameEntry, error)
+	CreateGame(table Table, settings repository.GameSettings, gameMembers []repository.RegisteredUser) (*primitive.ObjectID, error)
 	RemoveGame(gameId string) (*mongo.DeleteResult, error)
 	UpdateGameState(gameId string, state repository.GameState) (*GameEntry, error)
 	GetRankingsSorted() ([]Ranking, error)
-	GetCurrentGame() (*GameEntry, error)
-	GetCurrentDashboardState() (*websocket.DashboardSignal, error)
-	UpdateGameDuration(gameId string, duration float64)
-	UpdateCoins(player string, coins int) bool
+	GetCurrentGame(table string) (*GameEntry, error)
+	GetCurrentDashboardState(table string) (*websocket.DashboardSignal, error)
+	FinishGame(gameId string, duration float64, winner string) (*GameEntry, error)
+	UpdateCoins(table string, player string, coins int) bool
+	MarkEventProcessed(gameId string, eventId string)
+	ResetGame(gameId string) (*GameEntry, error)
+	ResetGameByLouie(gameId string) (*GameEntry, error)
+	RecordTransition(gameId string, transition repository.GameTransition)
+	ResendPlayersReady(gameId string) error
+	AbortGame(gameId string, reason string) (*GameEntry, error)
+	PauseGame(gameId string) (*GameEntry, error)
+	ResumeGame(gameId string) (*GameEntry, error)
+}
+
+var (
+	ErrGameNotResettable  = errors.New("only the current, unfinished game can be reset")
+	ErrLouieOffline       = errors.New("louie is offline")
+	ErrGameNotFound       = errors.New("game not found")
+	ErrGameNotReady       = errors.New("only a ready game waits for the players")
+	ErrGameNotAbortable   = errors.New("only the current, unfinished game can be aborted")
+	ErrAbortReasonMissing = errors.New("a game can only be aborted with a reason")
+	ErrGameNotPausable    = errors.New("only an active game can be paused")
+	ErrGameNotPaused      = errors.New("only a paused game can be resumed")
+	ErrGameRunning        = errors.New("a game is running at the table")
+	// ErrInvalidGameSettings is wrapped with the reason, e.g. too many seats.
+	ErrInvalidGameSettings = errors.New("invalid game settings")
+)
+
+// AbortReasons are offered by the admin ui for aborting a game.
+var AbortReasons = []string{"hardware fault", "player left", "dispute"}
+
+// GamePageSize is the number of games of one page of the archive.
+const GamePageSize = int64(10)
+
+// LouiePresence tells, whether the louie of a table is connected to the message bus.
+type LouiePresence interface {
+	IsOffline(sender string) bool
 }
 
 type GameEntry struct {
 	Id           string
+	Table        string
 	Duration     float64
 	KiName       string
 	KiCoins      int
@@ -41,42 +75,369 @@ type GameEntry struct {
 	Player2Coins int
 	Player3      string
 	Player3Coins int
-	State        repository.GameState
+	Player4      string
+	Player4Coins int
+	// Seats is the number of human players and StartingCoins are the coins of every participant at the start.
+	Seats         int
+	StartingCoins int
+	State         repository.GameState
+	// AbortReason tells, why an aborted game ended without result.
+	AbortReason string
+	Winner      string
+	// CreatedAt and FinishedAt are formatted for the admin ui. FinishedAt is empty for running games.
+	CreatedAt  string
+	FinishedAt string
+	// ProcessedEvents are the ids of the louie events, which already changed the game.
+	ProcessedEvents []string
+	// History lists the state transitions of the game, the oldest first.
+	History []GameTransitionEntry
+	// PlayingTime are the seconds the game was active. Paused times are not counted.
+	PlayingTime float64
+	// LastChange is the time of the last accepted entry of the history, e.g. the last state change, reset or
+	// watchdog action, or of the last COIN_DROP.
+	LastChange time.Time
+}
+
+// HasSeat tells, whether the player takes part in the game. The ki only takes part, if it does not sit out.
+func (game *GameEntry) HasSeat(player string) bool {
+
+	if player == "" {
+		return false
+	}
+
+	for _, seated := range []string{game.KiName, game.Player1, game.Player2, game.Player3, game.Player4} {
+		if strings.ToLower(seated) == strings.ToLower(player) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type GameTransitionEntry struct {
+	CreatedAt string
+	From      repository.GameState
+	To        repository.GameState
+	Event     string
+	Rejected  bool
+	Detail    string
 }
 
 type GameSer struct {
 	UserRepository repository.UserRepository
 	GameRepository repository.GameRepository
-	KafkaProducer  louie_kafka.KafkaProducer
+	OutboxService  OutboxService
+	// Presence blocks the creation of games, while the louie of the table is offline. Optional.
+	Presence LouiePresence
+	// Defaults are the seats and starting coins offered by the announce form. Optional.
+	Defaults repository.GameSettings
+}
+
+// DefaultSettings returns the configured seats and starting coins, or the classic louie setup.
+func (g *GameSer) DefaultSettings() repository.GameSettings {
+
+	defaults := repository.DefaultGameSettings()
+
+	if g.Defaults.Seats > 0 {
+		defaults.Seats = g.Defaults.Seats
+	}
+
+	if g.Defaults.StartingCoins > 0 {
+		defaults.StartingCoins = g.Defaults.StartingCoins
+	}
+
+	return defaults
+}
+
+// SetGameReady switches the game to "ready" and stores the PLAYERS_READY event for louie in the
+// outbox in the same step. The outbox relay publishes the event afterwards.
+func (g *GameSer) SetGameReady(gameId string, playerDisplayNames []louie_kafka.PlayerDisplayName) (*GameEntry, error) {
+
+	game, err := g.GameRepository.Get(gameId)
+
+	if err != nil || game == nil {
+		log.Printf("can not find game %s to set ready: %s\n", gameId, err)
+		return nil, ErrGameNotFound
+	}
+
+	playersReady, err := json.Marshal(newPlayersReadyEvent(game, playerDisplayNames))
+
+	if err != nil {
+		log.Printf("can not marshal player ready kafka message: %s\n", err)
+		return nil, err
+	}
+
+	currentGame, err := g.GameRepository.UpdateStateWithOutboxEntry(
+		gameId,
+		repository.GameReady,
+		repository.NewOutboxEntity(louie_kafka.PlayersReady.String(), playersReady),
+	)
+
+	if err != nil {
+		log.Printf("update game state to ready failed %s\n", err)
+		return nil, err
+	}
+
+	g.OutboxService.Notify()
+
+	return toGameEntry(currentGame), nil
+}
+
+// ResendPlayersReady stores the PLAYERS_READY event of a ready game once more, e.g. if louie did not confirm
+// the players in time.
+func (g *GameSer) ResendPlayersReady(gameId string) error {
+
+	game, err := g.GameRepository.Get(gameId)
+
+	if err != nil || game == nil {
+		log.Printf("can not find game %s to resend the players: %s\n", gameId, err)
+		return ErrGameNotFound
+	}
+
+	if game.State != repository.GameReady {
+		return ErrGameNotReady
+	}
+
+	return g.OutboxService.Enqueue(louie_kafka.PlayersReady, newPlayersReadyEvent(game, seatedPlayers(game.Player1, game.Player2, game.Player3, game.Player4)))
+}
+
+func newPlayersReadyEvent(game *repository.GameEntity, playerDisplayNames []louie_kafka.PlayerDisplayName) louie_kafka.PlayersReadyEvent {
+	return louie_kafka.PlayersReadyEvent{
+		Event:         louie_kafka.PlayersReady,
+		Players:       playerDisplayNames,
+		Timestamp:     strconv.FormatInt(time.Now().UnixMilli(), 10),
+		GameId:        game.Id.Hex(),
+		Table:         game.Table,
+		Seats:         game.SeatsOrDefault(),
+		StartingCoins: game.StartingCoinsOrDefault(),
+		WithoutKi:     game.KiName == "",
+	}
+}
+
+// seatedPlayers lists the players of the occupied seats with their seat, starting with the first seat.
+func seatedPlayers(players ...string) []louie_kafka.PlayerDisplayName {
+
+	seated := make([]louie_kafka.PlayerDisplayName, 0, len(players))
+
+	for i, player := range players {
+		if player != "" {
+			seated = append(seated, louie_kafka.PlayerDisplayName{DisplayName: player, Seat: i + 1})
+		}
+	}
+
+	return seated
+}
+
+// AbortGame ends the current game without result and stores the ABORT_GAME event for louie in the outbox in the
+// same step. The game is kept with the reason, but the statistics of the players are not touched.
+func (g *GameSer) AbortGame(gameId string, reason string) (*GameEntry, error) {
+
+	reason = strings.TrimSpace(reason)
+
+	if reason == "" {
+		return nil, ErrAbortReasonMissing
+	}
+
+	game, err := g.checkCurrentAndUnfinished(gameId, ErrGameNotAbortable)
+
+	if err != nil {
+		return nil, err
+	}
+
+	abortGame, err := json.Marshal(louie_kafka.AbortGameEvent{
+		Event:  louie_kafka.AbortGame,
+		GameId: gameId,
+		Table:  game.Table,
+		Reason: reason,
+	})
+
+	if err != nil {
+		log.Printf("can not marshal abort game kafka message: %s\n", err)
+		return nil, err
+	}
+
+	currentGame, err := g.GameRepository.AbortWithOutboxEntry(gameId, reason, repository.NewOutboxEntity(louie_kafka.AbortGame.String(), abortGame))
+
+	if err != nil {
+		log.Printf("abort game failed %s\n", err)
+		return nil, err
+	}
+
+	g.RecordTransition(gameId, repository.GameTransition{From: game.State, To: repository.GameAborted, Event: louie_kafka.AbortGame.String(), Detail: reason})
+	g.OutboxService.Notify()
+
+	return toGameEntry(currentGame), nil
+}
+
+// PauseGame pauses the active game and stores the PAUSE_GAME event for louie in the outbox in the same step.
+func (g *GameSer) PauseGame(gameId string) (*GameEntry, error) {
+	return g.changeStateByAdmin(gameId, louie_kafka.PauseGame, AdminPause, ErrGameNotPausable)
+}
+
+// ResumeGame continues the paused game and stores the RESUME_GAME event for louie in the outbox in the same step.
+func (g *GameSer) ResumeGame(gameId string) (*GameEntry, error) {
+	return g.changeStateByAdmin(gameId, louie_kafka.ResumeGame, AdminResume, ErrGameNotPaused)
+}
+
+// changeStateByAdmin does the transition of the louie event for the admin ui and informs louie with the same event.
+// The transition table of the game state machine decides, whether the current game allows the event.
+func (g *GameSer) changeStateByAdmin(gameId string, event louie_kafka.EventType, historyEvent string, errNotAllowed error) (*GameEntry, error) {
+
+	game, err := g.checkCurrentAndUnfinished(gameId, errNotAllowed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	transition, ok := findTransition(game.State, event)
+
+	if !ok {
+		return nil, errNotAllowed
+	}
+
+	payload, err := json.Marshal(louie_kafka.PauseGameEvent{
+		Event:  event,
+		GameId: gameId,
+		Table:  game.Table,
+	})
+
+	if err != nil {
+		log.Printf("can not marshal %s kafka message: %s\n", event, err)
+		return nil, err
+	}
+
+	currentGame, err := g.GameRepository.UpdateStateWithOutboxEntry(gameId, transition.To, repository.NewOutboxEntity(event.String(), payload))
+
+	if err != nil {
+		log.Printf("update game state to %s failed %s\n", transition.To, err)
+		return nil, err
+	}
+
+	g.RecordTransition(gameId, repository.GameTransition{From: game.State, To: transition.To, Event: historyEvent})
+	g.OutboxService.Notify()
+
+	return toGameEntry(currentGame), nil
 }
 
-func (g *GameSer) SendPlayerReadyMessageToKafka(playerDisplayNames []louie_kafka.PlayerDisplayName) {
+// ResetGame moves the game back to "announced" and stores the RESET_GAME event for louie in the outbox in
+// the same step. The statistics of the players are not touched.
+func (g *GameSer) ResetGame(gameId string) (*GameEntry, error) {
+
+	game, err := g.checkCurrentAndUnfinished(gameId, ErrGameNotResettable)
 
-	playerCanBeReceived, err := json.Marshal(louie_kafka.PlayersReadyEvent{
-		Event:     louie_kafka.PlayersReady,
-		Players:   playerDisplayNames,
-		Timestamp: strconv.FormatInt(time.Now().UnixMilli(), 10),
+	if err != nil {
+		return nil, err
+	}
+
+	resetGame, err := json.Marshal(louie_kafka.ResetGameEvent{
+		Event:  louie_kafka.ResetGame,
+		GameId: gameId,
+		Table:  game.Table,
 	})
 
-	messages := []kafka.Message{
-		{Value: playerCanBeReceived},
+	if err != nil {
+		log.Printf("can not marshal reset game kafka message: %s\n", err)
+		return nil, err
+	}
+
+	currentGame, err := g.GameRepository.ResetWithOutboxEntry(gameId, repository.NewOutboxEntity(louie_kafka.ResetGame.String(), resetGame))
+
+	if err != nil {
+		log.Printf("reset game failed %s\n", err)
+		return nil, err
+	}
+
+	g.RecordTransition(gameId, repository.GameTransition{From: game.State, To: currentGame.State, Event: AdminReset})
+	g.OutboxService.Notify()
+
+	return toGameEntry(currentGame), nil
+}
+
+// ResetGameByLouie moves the game back to "announced" after louie reset the round.
+func (g *GameSer) ResetGameByLouie(gameId string) (*GameEntry, error) {
+
+	if _, err := g.checkCurrentAndUnfinished(gameId, ErrGameNotResettable); err != nil {
+		return nil, err
+	}
+
+	currentGame, err := g.GameRepository.Reset(gameId)
+
+	if err != nil {
+		log.Printf("reset game failed %s\n", err)
+		return nil, err
+	}
+
+	return toGameEntry(currentGame), nil
+}
+
+// checkCurrentAndUnfinished returns the game, if it is the current game of its table and neither finished nor
+// aborted. Otherwise, the given error is returned.
+func (g *GameSer) checkCurrentAndUnfinished(gameId string, errNotAllowed error) (*repository.GameEntity, error) {
+
+	game, err := g.GameRepository.Get(gameId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if game == nil {
+		return nil, errNotAllowed
 	}
 
+	currentGame, err := g.GameRepository.GetCurrent(game.Table)
+
 	if err != nil {
-		log.Printf("sending player ready message to kafka failed %s\n", err)
+		return nil, err
+	}
+
+	if currentGame == nil || currentGame.Id.Hex() != gameId || isGameOver(currentGame.State) {
+		return nil, errNotAllowed
 	}
 
-	g.KafkaProducer.WriteKafkaMessage(context.Background(), &messages)
+	return currentGame, nil
 }
 
-func (g *GameSer) CreateGame(gameMembers []repository.RegisteredUser) (*primitive.ObjectID, error) {
+func isGameOver(state repository.GameState) bool {
+	return state == repository.GameFinished || state == repository.GameAborted
+}
+
+// CreateGame announces a game with the given seats and starting coins. Every player needs a position within the
+// seats. A game without ki gets no ki name, the ki user is not related to the game.
+func (g *GameSer) CreateGame(table Table, settings repository.GameSettings, gameMembers []repository.RegisteredUser) (*primitive.ObjectID, error) {
+
+	if g.IsTableOffline(table.Sender) {
+		return nil, ErrLouieOffline
+	}
+
+	kiName := table.KiName
+
+	if settings.WithoutKi {
+		kiName = ""
+		gameMembers = filterNonKiUsers(gameMembers)
+	}
 
-	gameId, err := g.GameRepository.CreateGame(gameMembers)
+	if err := validateGameSettings(settings, kiName != "", gameMembers); err != nil {
+		return nil, err
+	}
+
+	currentGame, err := g.GameRepository.GetCurrent(table.Sender)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if currentGame != nil && !currentGame.State.IsOver() {
+		return nil, ErrGameRunning
+	}
+
+	gameId, err := g.GameRepository.CreateGame(table.Sender, kiName, settings, gameMembers)
+
+	if err != nil {
+		return nil, err
+	}
+
+	g.RecordTransition(gameId.Hex(), repository.GameTransition{To: repository.GameAnnounced, Event: AdminAnnounce})
+
 	for _, user := range gameMembers {
 		_, err := g.UserRepository.UpdateGameRelationship(&user.Id, gameId)
 		if err != nil {
@@ -88,6 +449,52 @@ func (g *GameSer) CreateGame(gameMembers []repository.RegisteredUser) (*primitiv
 	return gameId, nil
 }
 
+// SeatOptions returns the possible number of human seats. The fourth seat is only free, if the ki sits out.
+func SeatOptions() []int {
+
+	options := make([]int, 0, repository.MaxSeats)
+
+	for seats := 1; seats <= repository.MaxSeats; seats++ {
+		options = append(options, seats)
+	}
+
+	return options
+}
+
+func maxSeats(withKi bool) int {
+
+	if withKi {
+		return repository.MaxSeatsWithKi
+	}
+
+	return repository.MaxSeats
+}
+
+func validateGameSettings(settings repository.GameSettings, withKi bool, gameMembers []repository.RegisteredUser) error {
+
+	maxSeats := maxSeats(withKi)
+
+	if settings.Seats < 1 || settings.Seats > maxSeats {
+		return fmt.Errorf("%w: the game has 1 to %d seats", ErrInvalidGameSettings, maxSeats)
+	}
+
+	if settings.StartingCoins < 1 {
+		return fmt.Errorf("%w: the starting coins have to be positive", ErrInvalidGameSettings)
+	}
+
+	for _, member := range filterNonKiUsers(gameMembers) {
+		if seat, err := strconv.Atoi(member.Pos); err != nil || seat < 1 || seat > settings.Seats {
+			return fmt.Errorf("%w: %s has no position within the %d seats", ErrInvalidGameSettings, member.DisplayName, settings.Seats)
+		}
+	}
+
+	return nil
+}
+
+func (g *GameSer) IsTableOffline(table string) bool {
+	return g.Presence != nil && g.Presence.IsOffline(table)
+}
+
 func (g *GameSer) GetRankingsSorted() ([]Ranking, error) {
 
 	users, err := g.UserRepository.GetAll()
@@ -160,33 +567,45 @@ func (g *GameSer) UpdateGameState(gameId string, state repository.GameState) (*G
 	}
 
 	gameEntry := GameEntry{
-		Id:           currentGame.Id.Hex(),
-		Duration:     currentGame.Duration,
-		KiName:       currentGame.KiName,
-		KiCoins:      currentGame.KiCoins,
-		Player1:      currentGame.Player1,
-		Player1Coins: currentGame.Player1Coins,
-		Player2:      currentGame.Player2,
-		Player2Coins: currentGame.Player2Coins,
-		Player3:      currentGame.Player3,
-		Player3Coins: currentGame.Player3Coins,
-		State:        currentGame.State,
+		Id:            currentGame.Id.Hex(),
+		Table:         currentGame.Table,
+		Duration:      currentGame.Duration,
+		KiName:        currentGame.KiName,
+		KiCoins:       currentGame.KiCoins,
+		Player1:       currentGame.Player1,
+		Player1Coins:  currentGame.Player1Coins,
+		Player2:       currentGame.Player2,
+		Player2Coins:  currentGame.Player2Coins,
+		Player3:       currentGame.Player3,
+		Player3Coins:  currentGame.Player3Coins,
+		Player4:       currentGame.Player4,
+		Player4Coins:  currentGame.Player4Coins,
+		Seats:         currentGame.SeatsOrDefault(),
+		StartingCoins: currentGame.StartingCoinsOrDefault(),
+		State:         currentGame.State,
+		AbortReason:   currentGame.AbortReason,
+		PlayingTime:   playingTime(currentGame, time.Now()),
 	}
 
 	return &gameEntry, nil
 }
 
-func (g *GameSer) UpdateCoins(player string, coins int) bool {
+func (g *GameSer) UpdateCoins(table string, player string, coins int) bool {
 
 	if coins < 0 {
 		log.Printf("coins negative. ignore\n")
 		return false
 	}
 
-	currentGame, err := g.GameRepository.GetCurrent()
+	currentGame, err := g.GameRepository.GetCurrent(table)
 
-	if err != nil {
-		log.Printf("can not find current game %s\n", err)
+	if err != nil || currentGame == nil {
+		log.Printf("can not find current game of table %s: %s\n", table, err)
+		return false
+	}
+
+	if player == "" {
+		log.Printf("coins without player. ignore\n")
 		return false
 	}
 
@@ -208,29 +627,93 @@ func (g *GameSer) UpdateCoins(player string, coins int) bool {
 			log.Printf("update coins of player 3 failed %s\n", err)
 			return false
 		}
+	} else if strings.ToLower(player) == strings.ToLower(currentGame.Player4) {
+		_, err := g.GameRepository.UpdateCoins(currentGame.Id.Hex(), repository.Player4CoinMarker, coins)
+		if err != nil {
+			log.Printf("update coins of player 4 failed %s\n", err)
+			return false
+		}
 	} else if strings.ToLower(player) == strings.ToLower(currentGame.KiName) {
 		_, err := g.GameRepository.UpdateCoins(currentGame.Id.Hex(), repository.KiCoinMarker, coins)
 		if err != nil {
 			log.Printf("update coins of ki failed %s\n", err)
 			return false
 		}
+	} else {
+		// e.g. the ki of the table, while it sits out.
+		log.Printf("%s has no seat in game %s. ignore\n", player, currentGame.Id.Hex())
+		return false
 	}
 
 	return true
 }
 
-func (g *GameSer) UpdateGameDuration(gameId string, duration float64) {
+// FinishGame ends the game with the duration and the winner of GAME_DONE. The game is kept in the archive.
+func (g *GameSer) FinishGame(gameId string, duration float64, winner string) (*GameEntry, error) {
+
+	game, err := g.GameRepository.Finish(gameId, duration, winner)
+
+	if err != nil {
+		log.Printf("finish game failed %s\n", err)
+		return nil, err
+	}
+
+	return toGameEntry(game), nil
+}
+
+// GetGames returns one page of the archived games, which match the filter. The latest game comes first.
+func (g *GameSer) GetGames(filter repository.GameFilter, page int64) ([]GameEntry, error) {
 
-	_, err := g.GameRepository.UpdateDuration(gameId, duration)
+	games, err := g.GameRepository.Find(filter, page, GamePageSize)
 
 	if err != nil {
-		log.Printf("update game duration failed %s\n", err)
+		log.Printf("get games failed %s\n", err)
+		return nil, err
+	}
+
+	entries := make([]GameEntry, 0, len(games))
+
+	for i := range games {
+		entries = append(entries, *toGameEntry(&games[i]))
+	}
+
+	return entries, nil
+}
+
+func (g *GameSer) CountGames(filter repository.GameFilter) int64 {
+
+	count, err := g.GameRepository.Count(filter)
+
+	if err != nil {
+		log.Printf("count games failed %s\n", err)
+		return 0
+	}
+
+	return count
+}
+
+func (g *GameSer) MarkEventProcessed(gameId string, eventId string) {
+
+	if err := g.GameRepository.AddProcessedEvent(gameId, eventId); err != nil {
+		log.Printf("marking event %s as processed failed %s\n", eventId, err)
+	}
+}
+
+// RecordTransition appends the transition to the history of the game. A missing timestamp is set to now.
+func (g *GameSer) RecordTransition(gameId string, transition repository.GameTransition) {
+
+	if transition.CreatedAt.IsZero() {
+		transition.CreatedAt = time.Now()
+	}
+
+	if err := g.GameRepository.AddTransition(gameId, transition); err != nil {
+		log.Printf("recording transition %s of game %s failed %s\n", transition.Event, gameId, err)
 	}
 }
 
-func (g *GameSer) GetCurrentGame() (*GameEntry, error) {
+func (g *GameSer) GetCurrentGame(table string) (*GameEntry, error) {
 
-	game, err := g.GameRepository.GetCurrent()
+	game, err := g.GameRepository.GetCurrent(table)
 
 	if err != nil {
 		log.Printf("get current game failed %s\n", err)
@@ -242,24 +725,38 @@ func (g *GameSer) GetCurrentGame() (*GameEntry, error) {
 	}
 
 	gameEntry := GameEntry{
-		Id:           game.Id.Hex(),
-		KiName:       game.KiName,
-		KiCoins:      game.KiCoins,
-		Player1:      game.Player1,
-		Player1Coins: game.Player1Coins,
-		Player2:      game.Player2,
-		Player2Coins: game.Player2Coins,
-		Player3:      game.Player3,
-		Player3Coins: game.Player3Coins,
-		State:        game.State,
+		Id:            game.Id.Hex(),
+		Table:         game.Table,
+		KiName:        game.KiName,
+		KiCoins:       game.KiCoins,
+		Player1:       game.Player1,
+		Player1Coins:  game.Player1Coins,
+		Player2:       game.Player2,
+		Player2Coins:  game.Player2Coins,
+		Player3:       game.Player3,
+		Player3Coins:  game.Player3Coins,
+		Player4:       game.Player4,
+		Player4Coins:  game.Player4Coins,
+		Seats:         game.SeatsOrDefault(),
+		StartingCoins: game.StartingCoinsOrDefault(),
+		State:         game.State,
+		AbortReason:   game.AbortReason,
+		Winner:        game.Winner,
+		CreatedAt:     formatCreatedAt(game),
+		FinishedAt:    formatFinishedAt(game),
+
+		ProcessedEvents: game.ProcessedEvents,
+		History:         toGameTransitionEntries(game.History),
+		PlayingTime:     playingTime(game, time.Now()),
+		LastChange:      lastChange(game),
 	}
 
 	return &gameEntry, nil
 }
 
-func (g *GameSer) GetCurrentDashboardState() (*websocket.DashboardSignal, error) {
+func (g *GameSer) GetCurrentDashboardState(table string) (*websocket.DashboardSignal, error) {
 
-	game, err := g.GameRepository.GetCurrent()
+	game, err := g.GameRepository.GetCurrent(table)
 
 	if err != nil {
 		log.Printf("get current game failed %s\n", err)
@@ -270,43 +767,181 @@ func (g *GameSer) GetCurrentDashboardState() (*websocket.DashboardSignal, error)
 
 	if game == nil || game.State == repository.GameAnnounced || game.State == repository.GameReady {
 		return &websocket.DashboardSignal{
+			Table:            table,
 			DashboardGame:    nil,
 			DashboardRanking: ToDashboardRanking(ranking),
 		}, nil
 	}
 
 	gameEntry := GameEntry{
-		Id:           game.Id.Hex(),
-		KiName:       game.KiName,
-		KiCoins:      game.KiCoins,
-		Player1:      game.Player1,
-		Player1Coins: game.Player1Coins,
-		Player2:      game.Player2,
-		Player2Coins: game.Player2Coins,
-		Player3:      game.Player3,
-		Player3Coins: game.Player3Coins,
-		State:        game.State,
+		Id:            game.Id.Hex(),
+		Table:         game.Table,
+		KiName:        game.KiName,
+		KiCoins:       game.KiCoins,
+		Player1:       game.Player1,
+		Player1Coins:  game.Player1Coins,
+		Player2:       game.Player2,
+		Player2Coins:  game.Player2Coins,
+		Player3:       game.Player3,
+		Player3Coins:  game.Player3Coins,
+		Player4:       game.Player4,
+		Player4Coins:  game.Player4Coins,
+		Seats:         game.SeatsOrDefault(),
+		StartingCoins: game.StartingCoinsOrDefault(),
+		State:         game.State,
+		AbortReason:   game.AbortReason,
+		PlayingTime:   playingTime(game, time.Now()),
 	}
 
 	return &websocket.DashboardSignal{
+		Table:            table,
 		DashboardGame:    ToDashboardGameFromGameEntry(&gameEntry),
 		DashboardRanking: ToDashboardRanking(ranking),
 	}, nil
 }
 
+func toGameEntry(game *repository.GameEntity) *GameEntry {
+	return &GameEntry{
+		Id:            game.Id.Hex(),
+		Table:         game.Table,
+		Duration:      game.Duration,
+		KiName:        game.KiName,
+		KiCoins:       game.KiCoins,
+		Player1:       game.Player1,
+		Player1Coins:  game.Player1Coins,
+		Player2:       game.Player2,
+		Player2Coins:  game.Player2Coins,
+		Player3:       game.Player3,
+		Player3Coins:  game.Player3Coins,
+		Player4:       game.Player4,
+		Player4Coins:  game.Player4Coins,
+		Seats:         game.SeatsOrDefault(),
+		StartingCoins: game.StartingCoinsOrDefault(),
+		State:         game.State,
+		AbortReason:   game.AbortReason,
+		Winner:        game.Winner,
+		CreatedAt:     formatCreatedAt(game),
+		FinishedAt:    formatFinishedAt(game),
+		History:       toGameTransitionEntries(game.History),
+		PlayingTime:   playingTime(game, time.Now()),
+		LastChange:    lastChange(game),
+	}
+}
+
+// playingTime is the game clock of the server. It sums up the times between entering and leaving the active state
+// in the history, so paused times are not counted. A reset starts the clock again.
+func playingTime(game *repository.GameEntity, now time.Time) float64 {
+
+	var played time.Duration
+	var activeSince *time.Time
+
+	for _, transition := range game.History {
+
+		if transition.Rejected {
+			continue
+		}
+
+		switch {
+		case transition.To == repository.GameAnnounced:
+			played = 0
+			activeSince = nil
+		case transition.To == repository.GameActive && activeSince == nil:
+			since := transition.CreatedAt
+			activeSince = &since
+		case transition.To != repository.GameActive && activeSince != nil:
+			played += transition.CreatedAt.Sub(*activeSince)
+			activeSince = nil
+		}
+	}
+
+	if activeSince != nil && game.State == repository.GameActive {
+		played += now.Sub(*activeSince)
+	}
+
+	return played.Seconds()
+}
+
+// lastChange is the time of the last accepted entry of the history or of the last COIN_DROP, whichever is later.
+// Games without history fall back to their creation time.
+func lastChange(game *repository.GameEntity) time.Time {
+
+	change := game.Id.Timestamp()
+
+	for i := len(game.History) - 1; i >= 0; i-- {
+		if !game.History[i].Rejected {
+			change = game.History[i].CreatedAt
+			break
+		}
+	}
+
+	if game.LastActivityAt.After(change) {
+		return game.LastActivityAt
+	}
+
+	return change
+}
+
+// formatCreatedAt falls back to the creation time of the id for games, which were created before the
+// archive existed.
+func formatCreatedAt(game *repository.GameEntity) string {
+
+	createdAt := game.CreatedAt
+
+	if createdAt.IsZero() {
+		createdAt = game.Id.Timestamp()
+	}
+
+	return createdAt.Local().Format(repository.GermanDateTimeFormat)
+}
+
+func formatFinishedAt(game *repository.GameEntity) string {
+
+	if game.FinishedAt == nil {
+		return ""
+	}
+
+	return game.FinishedAt.Local().Format(repository.GermanDateTimeFormat)
+}
+
+func toGameTransitionEntries(history []repository.GameTransition) []GameTransitionEntry {
+
+	entries := make([]GameTransitionEntry, 0, len(history))
+
+	for _, transition := range history {
+		entries = append(entries, GameTransitionEntry{
+			CreatedAt: transition.CreatedAt.Local().Format(repository.GermanDateTimeFormat),
+			From:      transition.From,
+			To:        transition.To,
+			Event:     transition.Event,
+			Rejected:  transition.Rejected,
+			Detail:    transition.Detail,
+		})
+	}
+
+	return entries
+}
+
 func ToDashboardGameFromGameEntry(game *GameEntry) *websocket.DashboardGame {
 	return &websocket.DashboardGame{
-		DocId:        game.Id,
-		Duration:     int(math.Trunc(game.Duration)),
-		KiName:       game.KiName,
-		KiCoins:      game.KiCoins,
-		Player1:      game.Player1,
-		Player1Coins: game.Player1Coins,
-		Player2:      game.Player2,
-		Player2Coins: game.Player2Coins,
-		Player3:      game.Player3,
-		Player3Coins: game.Player3Coins,
-		State:        string(game.State),
+		DocId:         game.Id,
+		Duration:      int(math.Trunc(game.Duration)),
+		WithKi:        game.KiName != "",
+		KiName:        game.KiName,
+		KiCoins:       game.KiCoins,
+		Player1:       game.Player1,
+		Player1Coins:  game.Player1Coins,
+		Player2:       game.Player2,
+		Player2Coins:  game.Player2Coins,
+		Player3:       game.Player3,
+		Player3Coins:  game.Player3Coins,
+		Player4:       game.Player4,
+		Player4Coins:  game.Player4Coins,
+		Seats:         game.Seats,
+		StartingCoins: game.StartingCoins,
+		State:         string(game.State),
+		Paused:        game.State == repository.GamePaused,
+		PlayingTime:   int(math.Trunc(game.PlayingTime)),
+		AbortReason:   game.AbortReason,
 	}
 }
 
@@ -13,13 +13,20 @@ type testGameService struct {
 	mock.Mock
 }
 
-func (testGameService *testGameService) SendPlayerReadyMessageToKafka(playerDisplayNames []louie_kafka.PlayerDisplayName) {
-	testGameService.Called(playerDisplayNames)
-	return
+func (testGameService *testGameService) SetGameReady(gameId string, playerDisplayNames []louie_kafka.PlayerDisplayName) (*GameEntry, error) {
+	args := testGameService.Called(gameId, playerDisplayNames)
+
+	get := args.Get(0)
+
+	if get != nil {
+		return get.(*GameEntry), args.Error(1)
+	} else {
+		return nil, args.Error(1)
+	}
 }
 
-func (testGameService *testGameService) CreateGame(gameMembers []repository.RegisteredUser) (*primitive.ObjectID, error) {
-	args := testGameService.Called(gameMembers)
+func (testGameService *testGameService) CreateGame(table Table, settings repository.GameSettings, gameMembers []repository.RegisteredUser) (*primitive.ObjectID, error) {
+	args := testGameService.Called(table, settings, gameMembers)
 	return args.Get(0).(*primitive.ObjectID), args.Error(1)
 }
 
@@ -40,8 +47,8 @@ func (testGameService *testGameService) UpdateGameState(gameId string, state rep
 	}
 }
 
-func (testGameService *testGameService) GetCurrentGame() (*GameEntry, error) {
-	args := testGameService.Called()
+func (testGameService *testGameService) GetCurrentGame(table string) (*GameEntry, error) {
+	args := testGameService.Called(table)
 
 	get := args.Get(0)
 
@@ -52,17 +59,52 @@ func (testGameService *testGameService) GetCurrentGame() (*GameEntry, error) {
 	}
 }
 
-func (testGameService *testGameService) UpdateGameDuration(gameId string, duration float64) {
-	testGameService.Called(gameId, duration)
-	return
+func (testGameService *testGameService) FinishGame(gameId string, duration float64, winner string) (*GameEntry, error) {
+	args := testGameService.Called(gameId, duration, winner)
+
+	get := args.Get(0)
+
+	if get != nil {
+		return get.(*GameEntry), args.Error(1)
+	} else {
+		return nil, args.Error(1)
+	}
 }
-func (testGameService *testGameService) UpdateCoins(player string, coins int) bool {
-	args := testGameService.Called(player, coins)
+func (testGameService *testGameService) UpdateCoins(table string, player string, coins int) bool {
+	args := testGameService.Called(table, player, coins)
 	return args.Get(0).(bool)
 }
 
-func (testGameService *testGameService) GetCurrentDashboardState() (*websocket.DashboardSignal, error) {
-	args := testGameService.Called()
+func (testGameService *testGameService) ResetGame(gameId string) (*GameEntry, error) {
+	args := testGameService.Called(gameId)
+
+	get := args.Get(0)
+
+	if get != nil {
+		return get.(*GameEntry), args.Error(1)
+	} else {
+		return nil, args.Error(1)
+	}
+}
+
+func (testGameService *testGameService) ResetGameByLouie(gameId string) (*GameEntry, error) {
+	args := testGameService.Called(gameId)
+
+	get := args.Get(0)
+
+	if get != nil {
+		return get.(*GameEntry), args.Error(1)
+	} else {
+		return nil, args.Error(1)
+	}
+}
+
+func (testGameService *testGameService) MarkEventProcessed(gameId string, eventId string) {
+	testGameService.Called(gameId, eventId)
+}
+
+func (testGameService *testGameService) GetCurrentDashboardState(table string) (*websocket.DashboardSignal, error) {
+	args := testGameService.Called(table)
 	return args.Get(0).(*websocket.DashboardSignal), args.Error(1)
 }
 
@@ -70,3 +112,48 @@ func (testGameService *testGameService) GetRankingsSorted() ([]Ranking, error) {
 	args := testGameService.Called()
 	return args.Get(0).([]Ranking), args.Error(1)
 }
+
+func (testGameService *testGameService) RecordTransition(gameId string, transition repository.GameTransition) {
+	testGameService.Called(gameId, transition)
+}
+
+func (testGameService *testGameService) ResendPlayersReady(gameId string) error {
+	args := testGameService.Called(gameId)
+	return args.Error(0)
+}
+
+func (testGameService *testGameService) AbortGame(gameId string, reason string) (*GameEntry, error) {
+	args := testGameService.Called(gameId, reason)
+
+	get := args.Get(0)
+
+	if get != nil {
+		return get.(*GameEntry), args.Error(1)
+	} else {
+		return nil, args.Error(1)
+	}
+}
+
+func (testGameService *testGameService) PauseGame(gameId string) (*GameEntry, error) {
+	args := testGameService.Called(gameId)
+
+	get := args.Get(0)
+
+	if get != nil {
+		return get.(*GameEntry), args.Error(1)
+	} else {
+		return nil, args.Error(1)
+	}
+}
+
+func (testGameService *testGameService) ResumeGame(gameId string) (*GameEntry, error) {
+	args := testGameService.Called(gameId)
+
+	get := args.Get(0)
+
+	if get != nil {
+		return get.(*GameEntry), args.Error(1)
+	} else {
+		return nil, args.Error(1)
+	}
+}
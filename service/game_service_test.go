@@ -5,6 +5,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"louie-web-administrator/repository"
 	"testing"
+	"time"
 )
 
 func Test_GetRankings_DurationLowerThanOneSecond(t *testing.T) {
@@ -146,3 +147,28 @@ func Test_GetRankings_Error(t *testing.T) {
 	assert.Error(t, errors.New("new error"), err)
 	assert.Equal(t, []Ranking{}, rankings)
 }
+
+func Test_PlayingTime_ExcludesPauses(t *testing.T) {
+
+	start := time.Date(2024, 1, 12, 10, 0, 0, 0, time.UTC)
+
+	game := &repository.GameEntity{
+		State: repository.GameActive,
+		History: []repository.GameTransition{
+			{To: repository.GameAnnounced, CreatedAt: start},
+			{From: repository.GameAnnounced, To: repository.GameReady, CreatedAt: start.Add(time.Minute)},
+			{From: repository.GameReady, To: repository.GameActive, CreatedAt: start.Add(2 * time.Minute)},
+			{From: repository.GameActive, To: repository.GameActive, Event: WatchdogTimeout, CreatedAt: start.Add(3 * time.Minute)},
+			{From: repository.GameActive, To: repository.GamePaused, CreatedAt: start.Add(4 * time.Minute)},
+			{From: repository.GamePaused, To: repository.GamePaused, Rejected: true, CreatedAt: start.Add(5 * time.Minute)},
+			{From: repository.GamePaused, To: repository.GameActive, CreatedAt: start.Add(10 * time.Minute)},
+		},
+	}
+
+	assert.Equal(t, 150.0, playingTime(game, start.Add(10*time.Minute+30*time.Second)))
+
+	game.State = repository.GamePaused
+	game.History = append(game.History, repository.GameTransition{From: repository.GameActive, To: repository.GamePaused, CreatedAt: start.Add(11 * time.Minute)})
+
+	assert.Equal(t, 180.0, playingTime(game, start.Add(time.Hour)))
+}
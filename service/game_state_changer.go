@@ -2,7 +2,8 @@ package service
 
 import (
 	"encoding/json"
-	"github.com/segmentio/kafka-go"
+	"fmt"
+	"github.com/thoas/go-funk"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"log"
 	"louie-web-administrator/louie_kafka"
@@ -16,221 +17,113 @@ type GameStateChecker struct {
 	GameService         GameService
 	GameDashboardSocket websocket.GameDashboardSocket
 	AdminUiSocket       websocket.AdminUiWebsocket
+	DeadLetterService   DeadLetterService
+	// Tables assigns the events by their sender to the louie tables.
+	Tables *TableSer
+	// RequireGameId quarantines correlated events without game id. Otherwise, they are accepted for
+	// senders, which do not echo the game id yet.
+	RequireGameId bool
+	// MatchQueue announces the next match after a finished game. Optional.
+	MatchQueue MatchQueue
 }
 
 func (changer *GameStateChecker) RunGameStateChecker(
-	kafkaMessageChannel chan kafka.Message,
+	kafkaMessageChannel chan louie_kafka.ConsumedMessage,
 ) {
 	go changer.receiveFromKafkaChannel(kafkaMessageChannel)
 }
-func (changer *GameStateChecker) receiveFromKafkaChannel(kafkaMessageChannel chan kafka.Message) {
+func (changer *GameStateChecker) receiveFromKafkaChannel(kafkaMessageChannel chan louie_kafka.ConsumedMessage) {
 
 	for message := range kafkaMessageChannel {
-		changer.checkAndUpdateGameState(message.Value)
+		message.SetResult(changer.processGameEvent(message.Value))
+		message.Done()
 	}
 }
+
+// checkAndUpdateGameState processes the event and reports, whether it was accepted. Duplicates are
+// accepted as well.
 func (changer *GameStateChecker) checkAndUpdateGameState(message []byte) bool {
+	return changer.processGameEvent(message).Outcome != louie_kafka.OutcomeRejected
+}
+
+// processGameEvent processes every event only once per game. Events, which already changed the
+// current game of the table (e.g. redelivered after a crash or rebalance), are skipped. The state change
+// itself is driven by the transition table of the game state machine.
+func (changer *GameStateChecker) processGameEvent(message []byte) louie_kafka.ProcessingResult {
 
-	eventSuccessfulProcessed := false
 	var tmpReceivedEvent louie_kafka.DefaultEvent
 
 	_ = json.Unmarshal(message, &tmpReceivedEvent)
 
-	switch tmpReceivedEvent.Event {
-
-	case louie_kafka.PlayersCanBeReceived:
-		eventSuccessfulProcessed = changer.playersCanBeReceivedEvent()
-
-	case louie_kafka.PlayersConfirm:
-		eventSuccessfulProcessed = changer.playersConfirmedEvent()
-
-	case louie_kafka.GameDone:
-		eventSuccessfulProcessed = changer.gameDoneEvent(message)
-
-	case louie_kafka.CoinDrop:
-		eventSuccessfulProcessed = changer.coinDropEvent(message)
-	}
-
-	return eventSuccessfulProcessed
-}
-
-func (changer *GameStateChecker) playersCanBeReceivedEvent() bool {
-
-	var updatedGame *GameEntry
+	table := changer.tableOf(message)
+	eventId, _ := louie_kafka.EventId(message)
+	currentGame, _ := changer.GameService.GetCurrentGame(table)
 
-	currentGame, _ := changer.GameService.GetCurrentGame()
+	result := louie_kafka.ProcessingResult{Outcome: louie_kafka.OutcomeRejected, Detail: louie_kafka.InvalidStateTransition.String()}
 
-	if currentGame == nil {
-		return false
+	if currentGame != nil {
+		result.GameId = currentGame.Id
 	}
 
-	if ok := changer.playersCanBeReceived(currentGame); ok {
-		updatedGame, _ = changer.GameService.UpdateGameState(currentGame.Id, repository.GameReady)
-	} else {
-		return false
+	if currentGame != nil && funk.ContainsString(currentGame.ProcessedEvents, eventId) {
+		log.Printf("event %s (%s) is already processed for game %s. skip\n", tmpReceivedEvent.Event, eventId, currentGame.Id)
+		return louie_kafka.ProcessingResult{Outcome: louie_kafka.OutcomeDuplicate, GameId: currentGame.Id}
 	}
 
-	if updatedGame != nil {
-		changer.GameService.SendPlayerReadyMessageToKafka(
-			[]louie_kafka.PlayerDisplayName{
-				{DisplayName: currentGame.Player1},
-				{DisplayName: currentGame.Player2},
-				{DisplayName: currentGame.Player3},
-			})
-
-		changer.AdminUiSocket.SendToAdminUi(toAdminUiEvent(updatedGame))
-	} else {
-		return false
+	if louie_kafka.IsGameCorrelated(tmpReceivedEvent.Event) && !changer.belongsToGame(message, currentGame) {
+		return louie_kafka.ProcessingResult{Outcome: louie_kafka.OutcomeRejected, Detail: louie_kafka.GameMismatch.String()}
 	}
 
-	return true
-}
-
-func (changer *GameStateChecker) playersCanBeReceived(currentGame *GameEntry) bool {
-
-	if currentGame.State != repository.GameAnnounced {
-		log.Printf("get \"PLAYERS_CAN_BE_RECEIVED\" from Louie. Current game state is not \"announced\" ignore\n")
-		return false
-	}
-	log.Printf("get \"PLAYERS_CAN_BE_RECEIVED\" from Louie. Update state and send players to louie\n")
-
-	return true
-}
-
-func (changer *GameStateChecker) playersConfirmedEvent() bool {
-
-	var updatedGame *GameEntry
-
-	currentGame, _ := changer.GameService.GetCurrentGame()
-
-	if currentGame == nil {
-		return false
-	}
-
-	if ok := changer.playersConfirmed(currentGame); ok {
-
-		ranking, _ := changer.GameService.GetRankingsSorted()
-		dashboardRanking := ToDashboardRanking(ranking)
-		updatedGame, _ = changer.GameService.UpdateGameState(currentGame.Id, repository.GameActive)
-
-		if updatedGame != nil {
-			changer.GameDashboardSocket.SendToDashboard(
-				&websocket.DashboardSignal{
-					DashboardGame:    ToDashboardGameFromGameEntry(updatedGame),
-					DashboardRanking: dashboardRanking,
-				},
-			)
-		}
+	transition, err := changer.fire(table, currentGame, tmpReceivedEvent.Event, message)
 
-		changer.AdminUiSocket.SendToAdminUi(toAdminUiEvent(updatedGame))
-	} else {
-		return false
+	if err != nil {
+		return result
 	}
 
-	return true
-}
-func (changer *GameStateChecker) playersConfirmed(currentGame *GameEntry) bool {
-
-	if currentGame.State != repository.GameReady {
-		log.Printf("get \"PLAYERS_CONFIRMED\" from Louie. Current game state is not \"ready\" ignore\n")
-		return false
+	if !transition.Repeatable {
+		changer.GameService.MarkEventProcessed(currentGame.Id, eventId)
 	}
-	log.Printf("get \"PLAYERS_CONFIRMED\" from Louie. Update state and send game to dashboard\n")
 
-	return true
+	return louie_kafka.ProcessingResult{Outcome: louie_kafka.OutcomeProcessed, GameId: currentGame.Id}
 }
 
-func (changer *GameStateChecker) gameDoneEvent(message []byte) bool {
-
-	var updatedGame *GameEntry
-
-	currentGame, _ := changer.GameService.GetCurrentGame()
+// belongsToGame checks the game id echoed by louie against the current game. Events of another game
+// (e.g. late events of a removed game) are quarantined.
+func (changer *GameStateChecker) belongsToGame(message []byte, currentGame *GameEntry) bool {
 
-	if currentGame == nil {
-		return false
-	}
-
-	if ok := changer.gameDone(currentGame, message); ok {
-		updatedGame, _ = changer.GameService.UpdateGameState(currentGame.Id, repository.GameFinished)
-
-		if updatedGame != nil {
-			gameDoneEvent := changer.unmarshalGameDoneEvent(message)
-			currentGameId, _ := changer.parseGameId(currentGame.Id)
-
-			changer.updatePlayerStatistic(*currentGameId, gameDoneEvent)
-			changer.GameService.UpdateGameDuration(currentGame.Id, gameDoneEvent.Duration)
+	gameId, _ := louie_kafka.GameIdOf(message)
 
-			ranking, _ := changer.GameService.GetRankingsSorted()
-			dashboardRanking := ToDashboardRanking(ranking)
-
-			changer.GameDashboardSocket.SendToDashboard(
-				&websocket.DashboardSignal{
-					DashboardGame:    ToDashboardGameFromGameEntry(updatedGame),
-					DashboardRanking: dashboardRanking,
-				})
-			changer.AdminUiSocket.SendToAdminUi(toAdminUiEvent(updatedGame))
+	if gameId == "" {
+		if changer.RequireGameId {
+			changer.DeadLetterService.Quarantine(message, louie_kafka.GameMismatch, "event has no game id")
+			return false
 		}
-	} else {
-		return false
+		return true
 	}
 
-	return true
-}
-
-func (changer *GameStateChecker) gameDone(currentGame *GameEntry, message []byte) bool {
-
-	changer.unmarshalGameDoneEvent(message)
-
-	if currentGame.State != repository.GameActive {
-		log.Printf("get \"GAME_DONE\" from Louie. Current game state is not \"active\" ignore\n")
+	if currentGame == nil || currentGame.Id != gameId {
+		changer.DeadLetterService.Quarantine(message, louie_kafka.GameMismatch, fmt.Sprintf("event belongs to game %s", gameId))
 		return false
 	}
-	log.Printf("get \"GAME_DONE\" from Louie. Update state and player statistics\n")
 
 	return true
 }
 
-func (changer *GameStateChecker) coinDropEvent(message []byte) bool {
-
-	var updatedGame *GameEntry
-
-	ranking, _ := changer.GameService.GetRankingsSorted()
-	dashboardRanking := ToDashboardRanking(ranking)
+// tableOf returns the sender of the louie table, which sent the event.
+func (changer *GameStateChecker) tableOf(message []byte) string {
 
-	currentGame, _ := changer.GameService.GetCurrentGame()
+	envelope, err := louie_kafka.DecodeEnvelope(message)
 
-	if currentGame == nil {
-		return false
-	}
-
-	if ok := changer.coinDrop(currentGame); ok {
-		coinDropEvent := changer.unmarshalCoinDropEvent(message)
-		if ok := changer.GameService.UpdateCoins(coinDropEvent.Name, coinDropEvent.Coins); ok {
-			game, _ := changer.GameService.GetCurrentGame()
-			updatedGame = game
-			changer.GameDashboardSocket.SendToDashboard(
-				&websocket.DashboardSignal{
-					DashboardGame:    ToDashboardGameFromGameEntry(updatedGame),
-					DashboardRanking: dashboardRanking,
-				})
-		}
-	}
-
-	if updatedGame != nil {
-		changer.AdminUiSocket.SendToAdminUi(toAdminUiEvent(updatedGame))
+	if err != nil {
+		return changer.Tables.Default().Sender
 	}
 
-	return true
+	return changer.Tables.ForSender(envelope.Sender).Sender
 }
-func (changer *GameStateChecker) coinDrop(currentGame *GameEntry) bool {
 
-	if currentGame.State != repository.GameActive {
-		log.Printf("get \"COIN_DROP\" from Louie. Current game state is not \"active\" ignore\n")
-		return false
-	}
-	log.Printf("get \"COIN_DROP\" from Louie. Update player coins\n")
-
-	return true
+// rejectTransition quarantines an event, which does not fit to the current game state.
+func (changer *GameStateChecker) rejectTransition(message []byte, detail string) {
+	changer.DeadLetterService.Quarantine(message, louie_kafka.InvalidStateTransition, detail)
 }
 
 func (changer *GameStateChecker) parseGameId(gameId string) (*primitive.ObjectID, error) {
@@ -247,7 +140,7 @@ func (changer *GameStateChecker) parseGameId(gameId string) (*primitive.ObjectID
 func (changer *GameStateChecker) unmarshalGameDoneEvent(message []byte) *louie_kafka.GameDoneEvent {
 	var gameDoneEvent *louie_kafka.GameDoneEvent
 
-	err := json.Unmarshal(message, &gameDoneEvent)
+	err := louie_kafka.UnmarshalPayload(message, &gameDoneEvent)
 
 	if err != nil {
 		log.Printf("failures during unmarshal game done event: %s\n", err)
@@ -259,7 +152,7 @@ func (changer *GameStateChecker) unmarshalGameDoneEvent(message []byte) *louie_k
 func (changer *GameStateChecker) unmarshalCoinDropEvent(message []byte) *louie_kafka.CoinDropEvent {
 	var coinDropEvent *louie_kafka.CoinDropEvent
 
-	err := json.Unmarshal(message, &coinDropEvent)
+	err := louie_kafka.UnmarshalPayload(message, &coinDropEvent)
 
 	if err != nil {
 		log.Printf("failures during unmarshal coin drop event: %s\n", err)
@@ -283,7 +176,7 @@ func (changer *GameStateChecker) updatePlayerStatistic(currentGameId primitive.O
 
 		user.PlayedGames += 1
 
-		_, err := changer.UserService.UpdateStatistic(user)
+		_, err := changer.UserService.UpdateStatistic(user, currentGameId)
 		if err != nil {
 			log.Printf("updating user statistic failed: %s\n", err)
 		}
@@ -300,9 +193,12 @@ func toAdminUiEvent(game *GameEntry) *websocket.AdminUiEvent {
 
 	return &websocket.AdminUiEvent{
 		EventType:    adminUiEventType,
+		Table:        game.Table,
 		KiCoins:      game.KiCoins,
 		Player1Coins: game.Player1Coins,
 		Player2Coins: game.Player2Coins,
 		Player3Coins: game.Player3Coins,
+		Player4Coins: game.Player4Coins,
+		Message:      game.AbortReason,
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"louie-web-administrator/louie_kafka"
 	"louie-web-administrator/repository"
@@ -25,6 +26,9 @@ var (
 	player3Coins = 3
 
 	gameState = repository.GameAnnounced
+
+	testTable  = Table{Sender: "c-library", Name: "Louie", KiName: kiName}
+	testTables = NewTableService([]Table{testTable})
 )
 
 func Test_CheckAndUpdateGameState_SwitchToReady(t *testing.T) {
@@ -32,12 +36,14 @@ func Test_CheckAndUpdateGameState_SwitchToReady(t *testing.T) {
 	gameService := initMockedGameService()
 
 	testUserService := new(TestUserService)
+	deadLetterService := initMockedDeadLetterService()
 
 	dashboardSocketChannel := make(chan *websocket.DashboardSignal, 100)
 	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
 
 	gameDashboardSocket := websocket.GameDashboardSocket{
-		GameDashboardChannel:     dashboardSocketChannel,
+		GameDashboardChannels:    map[string]chan *websocket.DashboardSignal{testTable.Sender: dashboardSocketChannel},
+		DefaultTable:             testTable.Sender,
 		GetCurrentDashboardState: gameService.GetCurrentDashboardState,
 	}
 
@@ -48,6 +54,8 @@ func Test_CheckAndUpdateGameState_SwitchToReady(t *testing.T) {
 		GameService:         gameService,
 		GameDashboardSocket: gameDashboardSocket,
 		AdminUiSocket:       *adminUiWebsocket,
+		DeadLetterService:   deadLetterService,
+		Tables:              testTables,
 	}
 
 	playersCanBeReceived, _ := json.Marshal(louie_kafka.PlayersCanBeReceivedEvent{
@@ -66,10 +74,11 @@ func Test_CheckAndUpdateGameState_SwitchToReady(t *testing.T) {
 
 func Test_CheckAndUpdateGameState_SwitchToReady_NoCurrentGameExists(t *testing.T) {
 
-	testGameService := new(testGameService)
-	testGameService.On("GetCurrentGame").Return(nil, nil)
+	testGameService := newTestGameService()
+	testGameService.On("GetCurrentGame", testTable.Sender).Return(nil, nil)
 
 	testUserService := new(TestUserService)
+	deadLetterService := initMockedDeadLetterService()
 
 	dashboardSocketChannel := make(chan *websocket.DashboardSignal, 100)
 	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
@@ -77,7 +86,8 @@ func Test_CheckAndUpdateGameState_SwitchToReady_NoCurrentGameExists(t *testing.T
 	adminUiWebsocket := websocket.InitAdminUiWebsocket(adminUiChannel)
 
 	gameDashboardSocket := websocket.GameDashboardSocket{
-		GameDashboardChannel:     dashboardSocketChannel,
+		GameDashboardChannels:    map[string]chan *websocket.DashboardSignal{testTable.Sender: dashboardSocketChannel},
+		DefaultTable:             testTable.Sender,
 		GetCurrentDashboardState: testGameService.GetCurrentDashboardState,
 	}
 
@@ -86,6 +96,8 @@ func Test_CheckAndUpdateGameState_SwitchToReady_NoCurrentGameExists(t *testing.T
 		GameService:         testGameService,
 		GameDashboardSocket: gameDashboardSocket,
 		AdminUiSocket:       *adminUiWebsocket,
+		DeadLetterService:   deadLetterService,
+		Tables:              testTables,
 	}
 
 	playersCanBeReceived, _ := json.Marshal(louie_kafka.PlayersCanBeReceivedEvent{
@@ -96,12 +108,13 @@ func Test_CheckAndUpdateGameState_SwitchToReady_NoCurrentGameExists(t *testing.T
 	eventProcessed := gameStateChanger.checkAndUpdateGameState(playersCanBeReceived)
 
 	assert.False(t, eventProcessed)
+	deadLetterService.AssertCalled(t, "Quarantine", playersCanBeReceived, louie_kafka.InvalidStateTransition, mock.Anything)
 }
 
 func Test_CheckAndUpdateGameState_SwitchToReady_CurrentGameNotInAnnouncedState(t *testing.T) {
 
-	testGameService := new(testGameService)
-	testGameService.On("GetCurrentGame").Return(&GameEntry{
+	testGameService := newTestGameService()
+	testGameService.On("GetCurrentGame", testTable.Sender).Return(&GameEntry{
 		Id:           gameId,
 		Duration:     gameDuration,
 		KiName:       kiName,
@@ -116,6 +129,7 @@ func Test_CheckAndUpdateGameState_SwitchToReady_CurrentGameNotInAnnouncedState(t
 	}, nil)
 
 	testUserService := new(TestUserService)
+	deadLetterService := initMockedDeadLetterService()
 
 	dashboardSocketChannel := make(chan *websocket.DashboardSignal, 100)
 	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
@@ -123,7 +137,8 @@ func Test_CheckAndUpdateGameState_SwitchToReady_CurrentGameNotInAnnouncedState(t
 	adminUiWebsocket := websocket.InitAdminUiWebsocket(adminUiChannel)
 
 	gameDashboardSocket := websocket.GameDashboardSocket{
-		GameDashboardChannel:     dashboardSocketChannel,
+		GameDashboardChannels:    map[string]chan *websocket.DashboardSignal{testTable.Sender: dashboardSocketChannel},
+		DefaultTable:             testTable.Sender,
 		GetCurrentDashboardState: testGameService.GetCurrentDashboardState,
 	}
 
@@ -132,6 +147,8 @@ func Test_CheckAndUpdateGameState_SwitchToReady_CurrentGameNotInAnnouncedState(t
 		GameService:         testGameService,
 		GameDashboardSocket: gameDashboardSocket,
 		AdminUiSocket:       *adminUiWebsocket,
+		DeadLetterService:   deadLetterService,
+		Tables:              testTables,
 	}
 
 	playersCanBeReceived, _ := json.Marshal(louie_kafka.PlayersCanBeReceivedEvent{
@@ -142,12 +159,13 @@ func Test_CheckAndUpdateGameState_SwitchToReady_CurrentGameNotInAnnouncedState(t
 	eventProcessed := gameStateChanger.checkAndUpdateGameState(playersCanBeReceived)
 
 	assert.False(t, eventProcessed)
+	deadLetterService.AssertCalled(t, "Quarantine", playersCanBeReceived, louie_kafka.InvalidStateTransition, mock.Anything)
 }
 
 func Test_CheckAndUpdateGameState_SwitchToReady_FailureDuringUpdate(t *testing.T) {
 
-	testGameService := new(testGameService)
-	testGameService.On("GetCurrentGame").Return(&GameEntry{
+	testGameService := newTestGameService()
+	testGameService.On("GetCurrentGame", testTable.Sender).Return(&GameEntry{
 		Id:           gameId,
 		Duration:     gameDuration,
 		KiName:       kiName,
@@ -161,15 +179,17 @@ func Test_CheckAndUpdateGameState_SwitchToReady_FailureDuringUpdate(t *testing.T
 		State:        repository.GameAnnounced,
 	}, nil)
 
-	testGameService.On("UpdateGameState", gameId, repository.GameReady).Return(nil, errors.New("new error"))
+	testGameService.On("SetGameReady", gameId, mock.Anything).Return(nil, errors.New("new error"))
 
 	testUserService := new(TestUserService)
+	deadLetterService := initMockedDeadLetterService()
 
 	dashboardSocketChannel := make(chan *websocket.DashboardSignal, 100)
 	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
 
 	gameDashboardSocket := websocket.GameDashboardSocket{
-		GameDashboardChannel:     dashboardSocketChannel,
+		GameDashboardChannels:    map[string]chan *websocket.DashboardSignal{testTable.Sender: dashboardSocketChannel},
+		DefaultTable:             testTable.Sender,
 		GetCurrentDashboardState: testGameService.GetCurrentDashboardState,
 	}
 
@@ -180,6 +200,8 @@ func Test_CheckAndUpdateGameState_SwitchToReady_FailureDuringUpdate(t *testing.T
 		GameService:         testGameService,
 		GameDashboardSocket: gameDashboardSocket,
 		AdminUiSocket:       *adminUiWebsocket,
+		DeadLetterService:   deadLetterService,
+		Tables:              testTables,
 	}
 
 	playersCanBeReceived, _ := json.Marshal(louie_kafka.PlayersCanBeReceivedEvent{
@@ -194,16 +216,18 @@ func Test_CheckAndUpdateGameState_SwitchToReady_FailureDuringUpdate(t *testing.T
 
 func Test_CheckAndUpdateGameState_SwitchToActive_NoCurrentGame(t *testing.T) {
 
-	testGameService := new(testGameService)
-	testGameService.On("GetCurrentGame").Return(nil, nil)
+	testGameService := newTestGameService()
+	testGameService.On("GetCurrentGame", testTable.Sender).Return(nil, nil)
 
 	testUserService := new(TestUserService)
+	deadLetterService := initMockedDeadLetterService()
 
 	dashboardSocketChannel := make(chan *websocket.DashboardSignal, 100)
 	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
 
 	gameDashboardSocket := websocket.GameDashboardSocket{
-		GameDashboardChannel:     dashboardSocketChannel,
+		GameDashboardChannels:    map[string]chan *websocket.DashboardSignal{testTable.Sender: dashboardSocketChannel},
+		DefaultTable:             testTable.Sender,
 		GetCurrentDashboardState: testGameService.GetCurrentDashboardState,
 	}
 
@@ -214,6 +238,8 @@ func Test_CheckAndUpdateGameState_SwitchToActive_NoCurrentGame(t *testing.T) {
 		GameService:         testGameService,
 		GameDashboardSocket: gameDashboardSocket,
 		AdminUiSocket:       *adminUiWebsocket,
+		DeadLetterService:   deadLetterService,
+		Tables:              testTables,
 	}
 
 	playersConfirmed, _ := json.Marshal(louie_kafka.PlayersCanBeReceivedEvent{
@@ -224,12 +250,13 @@ func Test_CheckAndUpdateGameState_SwitchToActive_NoCurrentGame(t *testing.T) {
 	eventProcessed := gameStateChanger.checkAndUpdateGameState(playersConfirmed)
 
 	assert.False(t, eventProcessed)
+	deadLetterService.AssertCalled(t, "Quarantine", playersConfirmed, louie_kafka.InvalidStateTransition, mock.Anything)
 }
 
 func Test_CheckAndUpdateGameState_SwitchToActive_CurrentGameNotInReadyState(t *testing.T) {
 
-	testGameService := new(testGameService)
-	testGameService.On("GetCurrentGame").Return(&GameEntry{
+	testGameService := newTestGameService()
+	testGameService.On("GetCurrentGame", testTable.Sender).Return(&GameEntry{
 		Id:           gameId,
 		Duration:     gameDuration,
 		KiName:       kiName,
@@ -244,12 +271,14 @@ func Test_CheckAndUpdateGameState_SwitchToActive_CurrentGameNotInReadyState(t *t
 	}, nil)
 
 	testUserService := new(TestUserService)
+	deadLetterService := initMockedDeadLetterService()
 
 	dashboardSocketChannel := make(chan *websocket.DashboardSignal, 100)
 	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
 
 	gameDashboardSocket := websocket.GameDashboardSocket{
-		GameDashboardChannel:     dashboardSocketChannel,
+		GameDashboardChannels:    map[string]chan *websocket.DashboardSignal{testTable.Sender: dashboardSocketChannel},
+		DefaultTable:             testTable.Sender,
 		GetCurrentDashboardState: testGameService.GetCurrentDashboardState,
 	}
 
@@ -260,6 +289,8 @@ func Test_CheckAndUpdateGameState_SwitchToActive_CurrentGameNotInReadyState(t *t
 		GameService:         testGameService,
 		GameDashboardSocket: gameDashboardSocket,
 		AdminUiSocket:       *adminUiWebsocket,
+		DeadLetterService:   deadLetterService,
+		Tables:              testTables,
 	}
 
 	playersConfirmed, _ := json.Marshal(louie_kafka.PlayersCanBeReceivedEvent{
@@ -270,12 +301,16 @@ func Test_CheckAndUpdateGameState_SwitchToActive_CurrentGameNotInReadyState(t *t
 	eventProcessed := gameStateChanger.checkAndUpdateGameState(playersConfirmed)
 
 	assert.False(t, eventProcessed)
+	deadLetterService.AssertCalled(t, "Quarantine", playersConfirmed, louie_kafka.InvalidStateTransition, mock.Anything)
+	testGameService.AssertCalled(t, "RecordTransition", gameId, mock.MatchedBy(func(transition repository.GameTransition) bool {
+		return transition.Rejected && transition.From == repository.GameFinished && transition.To == repository.GameFinished
+	}))
 }
 
 func Test_CheckAndUpdateGameState_SwitchToActive(t *testing.T) {
 
-	testGameService := new(testGameService)
-	testGameService.On("GetCurrentGame").Return(&GameEntry{
+	testGameService := newTestGameService()
+	testGameService.On("GetCurrentGame", testTable.Sender).Return(&GameEntry{
 		Id:           gameId,
 		Duration:     gameDuration,
 		KiName:       kiName,
@@ -313,13 +348,17 @@ func Test_CheckAndUpdateGameState_SwitchToActive(t *testing.T) {
 		State:        repository.GameActive,
 	}, nil)
 
+	testGameService.On("MarkEventProcessed", gameId, mock.Anything).Return()
+
 	testUserService := new(TestUserService)
+	deadLetterService := initMockedDeadLetterService()
 
 	dashboardSocketChannel := make(chan *websocket.DashboardSignal, 100)
 	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
 
 	gameDashboardSocket := websocket.GameDashboardSocket{
-		GameDashboardChannel:     dashboardSocketChannel,
+		GameDashboardChannels:    map[string]chan *websocket.DashboardSignal{testTable.Sender: dashboardSocketChannel},
+		DefaultTable:             testTable.Sender,
 		GetCurrentDashboardState: testGameService.GetCurrentDashboardState,
 	}
 
@@ -330,6 +369,8 @@ func Test_CheckAndUpdateGameState_SwitchToActive(t *testing.T) {
 		GameService:         testGameService,
 		GameDashboardSocket: gameDashboardSocket,
 		AdminUiSocket:       *adminUiWebsocket,
+		DeadLetterService:   deadLetterService,
+		Tables:              testTables,
 	}
 
 	playersConfirmed, _ := json.Marshal(louie_kafka.PlayersCanBeReceivedEvent{
@@ -342,11 +383,17 @@ func Test_CheckAndUpdateGameState_SwitchToActive(t *testing.T) {
 	dashboardSignal := <-dashboardSocketChannel
 
 	assert.True(t, eventProcessed)
+	testGameService.AssertCalled(t, "MarkEventProcessed", gameId, mock.Anything)
+	testGameService.AssertCalled(t, "RecordTransition", gameId, mock.MatchedBy(func(transition repository.GameTransition) bool {
+		return !transition.Rejected && transition.From == repository.GameReady && transition.To == repository.GameActive
+	}))
 	assert.Equal(t, websocket.AdminUiEvent{EventType: "active", KiCoins: 3, Player1Coins: 3, Player2Coins: 3, Player3Coins: 3}, adminSignal)
 	assert.Equal(t, &websocket.DashboardSignal{
+		Table: testTable.Sender,
 		DashboardGame: &websocket.DashboardGame{
 			DocId:        gameId,
 			Duration:     int(math.Trunc(gameDuration)),
+			WithKi:       true,
 			KiName:       kiName,
 			KiCoins:      kiCoins,
 			Player1:      player1Name,
@@ -362,16 +409,18 @@ func Test_CheckAndUpdateGameState_SwitchToActive(t *testing.T) {
 }
 func Test_CheckAndUpdateGameState_SwitchToFinished_NoCurrentGame(t *testing.T) {
 
-	testGameService := new(testGameService)
-	testGameService.On("GetCurrentGame").Return(nil, nil)
+	testGameService := newTestGameService()
+	testGameService.On("GetCurrentGame", testTable.Sender).Return(nil, nil)
 
 	testUserService := new(TestUserService)
+	deadLetterService := initMockedDeadLetterService()
 
 	dashboardSocketChannel := make(chan *websocket.DashboardSignal, 100)
 	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
 
 	gameDashboardSocket := websocket.GameDashboardSocket{
-		GameDashboardChannel:     dashboardSocketChannel,
+		GameDashboardChannels:    map[string]chan *websocket.DashboardSignal{testTable.Sender: dashboardSocketChannel},
+		DefaultTable:             testTable.Sender,
 		GetCurrentDashboardState: testGameService.GetCurrentDashboardState,
 	}
 
@@ -382,6 +431,8 @@ func Test_CheckAndUpdateGameState_SwitchToFinished_NoCurrentGame(t *testing.T) {
 		GameService:         testGameService,
 		GameDashboardSocket: gameDashboardSocket,
 		AdminUiSocket:       *adminUiWebsocket,
+		DeadLetterService:   deadLetterService,
+		Tables:              testTables,
 	}
 
 	gameDone, _ := json.Marshal(louie_kafka.PlayersCanBeReceivedEvent{
@@ -392,12 +443,13 @@ func Test_CheckAndUpdateGameState_SwitchToFinished_NoCurrentGame(t *testing.T) {
 	eventProcessed := gameStateChanger.checkAndUpdateGameState(gameDone)
 
 	assert.False(t, eventProcessed)
+	deadLetterService.AssertCalled(t, "Quarantine", gameDone, louie_kafka.InvalidStateTransition, mock.Anything)
 }
 
 func Test_CheckAndUpdateGameState_SwitchToFinished_CurrentGameNotInActiveState(t *testing.T) {
 
-	testGameService := new(testGameService)
-	testGameService.On("GetCurrentGame").Return(&GameEntry{
+	testGameService := newTestGameService()
+	testGameService.On("GetCurrentGame", testTable.Sender).Return(&GameEntry{
 		Id:           gameId,
 		Duration:     gameDuration,
 		KiName:       kiName,
@@ -412,12 +464,14 @@ func Test_CheckAndUpdateGameState_SwitchToFinished_CurrentGameNotInActiveState(t
 	}, nil)
 
 	testUserService := new(TestUserService)
+	deadLetterService := initMockedDeadLetterService()
 
 	dashboardSocketChannel := make(chan *websocket.DashboardSignal, 100)
 	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
 
 	gameDashboardSocket := websocket.GameDashboardSocket{
-		GameDashboardChannel:     dashboardSocketChannel,
+		GameDashboardChannels:    map[string]chan *websocket.DashboardSignal{testTable.Sender: dashboardSocketChannel},
+		DefaultTable:             testTable.Sender,
 		GetCurrentDashboardState: testGameService.GetCurrentDashboardState,
 	}
 
@@ -428,6 +482,8 @@ func Test_CheckAndUpdateGameState_SwitchToFinished_CurrentGameNotInActiveState(t
 		GameService:         testGameService,
 		GameDashboardSocket: gameDashboardSocket,
 		AdminUiSocket:       *adminUiWebsocket,
+		DeadLetterService:   deadLetterService,
+		Tables:              testTables,
 	}
 
 	gameDone, _ := json.Marshal(louie_kafka.PlayersCanBeReceivedEvent{
@@ -438,12 +494,146 @@ func Test_CheckAndUpdateGameState_SwitchToFinished_CurrentGameNotInActiveState(t
 	eventProcessed := gameStateChanger.checkAndUpdateGameState(gameDone)
 
 	assert.False(t, eventProcessed)
+	deadLetterService.AssertCalled(t, "Quarantine", gameDone, louie_kafka.InvalidStateTransition, mock.Anything)
 }
 
-func initMockedGameService() *testGameService {
+func Test_CheckAndUpdateGameState_SkipsProcessedEvent(t *testing.T) {
+
+	playersConfirmed, _ := json.Marshal(louie_kafka.PlayersCanBeReceivedEvent{
+		Event:  louie_kafka.PlayersConfirm,
+		Sender: "webserver",
+	})
+	eventId, _ := louie_kafka.EventId(playersConfirmed)
+
+	testGameService := newTestGameService()
+	testGameService.On("GetCurrentGame", testTable.Sender).Return(&GameEntry{
+		Id:              gameId,
+		State:           repository.GameActive,
+		ProcessedEvents: []string{eventId},
+	}, nil)
+
+	deadLetterService := initMockedDeadLetterService()
+
+	gameStateChanger := GameStateChecker{
+		UserService:       new(TestUserService),
+		GameService:       testGameService,
+		AdminUiSocket:     *websocket.InitAdminUiWebsocket(make(chan websocket.AdminUiEvent, 10)),
+		DeadLetterService: deadLetterService,
+		Tables:            testTables,
+	}
+
+	eventProcessed := gameStateChanger.checkAndUpdateGameState(playersConfirmed)
+
+	assert.True(t, eventProcessed)
+	testGameService.AssertNotCalled(t, "UpdateGameState", mock.Anything, mock.Anything)
+	testGameService.AssertNotCalled(t, "MarkEventProcessed", mock.Anything, mock.Anything)
+	deadLetterService.AssertNotCalled(t, "Quarantine", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_CheckAndUpdateGameState_QuarantinesEventOfOtherGame(t *testing.T) {
+
+	testGameService := newTestGameService()
+	testGameService.On("GetCurrentGame", testTable.Sender).Return(&GameEntry{Id: gameId, State: repository.GameActive}, nil)
+
+	deadLetterService := initMockedDeadLetterService()
+
+	gameStateChanger := GameStateChecker{
+		UserService:       new(TestUserService),
+		GameService:       testGameService,
+		AdminUiSocket:     *websocket.InitAdminUiWebsocket(make(chan websocket.AdminUiEvent, 10)),
+		DeadLetterService: deadLetterService,
+		Tables:            testTables,
+	}
+
+	coinDrop, _ := json.Marshal(louie_kafka.CoinDropEvent{
+		Event:  louie_kafka.CoinDrop,
+		Sender: "c-library",
+		Name:   player1Name,
+		Coins:  2,
+		GameId: "65a1f0c2e4b0a1b2c3d4e5f6",
+	})
+
+	eventProcessed := gameStateChanger.checkAndUpdateGameState(coinDrop)
+
+	assert.False(t, eventProcessed)
+	testGameService.AssertNotCalled(t, "UpdateCoins", mock.Anything, mock.Anything)
+	deadLetterService.AssertCalled(t, "Quarantine", coinDrop, louie_kafka.GameMismatch, mock.Anything)
+}
+
+func Test_CheckAndUpdateGameState_RequireGameId(t *testing.T) {
+
+	testGameService := newTestGameService()
+	testGameService.On("GetCurrentGame", testTable.Sender).Return(&GameEntry{Id: gameId, State: repository.GameReady}, nil)
+
+	deadLetterService := initMockedDeadLetterService()
+
+	gameStateChanger := GameStateChecker{
+		UserService:       new(TestUserService),
+		GameService:       testGameService,
+		AdminUiSocket:     *websocket.InitAdminUiWebsocket(make(chan websocket.AdminUiEvent, 10)),
+		DeadLetterService: deadLetterService,
+		Tables:            testTables,
+		RequireGameId:     true,
+	}
+
+	playersConfirmed, _ := json.Marshal(louie_kafka.PlayersCanBeReceivedEvent{
+		Event:  louie_kafka.PlayersConfirm,
+		Sender: "c-library",
+	})
+
+	eventProcessed := gameStateChanger.checkAndUpdateGameState(playersConfirmed)
+
+	assert.False(t, eventProcessed)
+	testGameService.AssertNotCalled(t, "UpdateGameState", mock.Anything, mock.Anything)
+	deadLetterService.AssertCalled(t, "Quarantine", playersConfirmed, louie_kafka.GameMismatch, "event has no game id")
+}
+
+func Test_FindTransition(t *testing.T) {
+
+	transition, ok := findTransition(repository.GameActive, louie_kafka.CoinDrop)
+
+	assert.True(t, ok)
+	assert.Equal(t, repository.GameActive, transition.To)
+	assert.True(t, transition.Silent)
+
+	transition, ok = findTransition(repository.GameReady, louie_kafka.ResetGame)
+
+	assert.True(t, ok)
+	assert.Equal(t, repository.GameAnnounced, transition.To)
+	assert.True(t, transition.Repeatable)
+
+	_, ok = findTransition(repository.GameFinished, louie_kafka.ResetGame)
+
+	assert.False(t, ok)
+
+	_, ok = findTransition(repository.GameAnnounced, louie_kafka.GameDone)
+
+	assert.False(t, ok)
+}
+
+func initMockedDeadLetterService() *testDeadLetterService {
+	deadLetterService := new(testDeadLetterService)
+
+	deadLetterService.On("Quarantine", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	return deadLetterService
+}
+
+// newTestGameService accepts every recorded transition. Tests of the history assert the calls.
+func newTestGameService() *testGameService {
 	testGameService := new(testGameService)
 
-	testGameService.On("GetCurrentDashboardState").Return(
+	testGameService.On("RecordTransition", mock.Anything, mock.Anything).Return()
+
+	return testGameService
+}
+
+func initMockedGameService() *testGameService {
+	testGameService := newTestGameService()
+
+	testGameService.On("MarkEventProcessed", gameId, mock.Anything).Return()
+
+	testGameService.On("GetCurrentDashboardState", testTable.Sender).Return(
 		websocket.DashboardSignal{
 			DashboardGame: ToDashboardGameFromGameEntry(&GameEntry{
 				Id:           gameId,
@@ -461,11 +651,9 @@ func initMockedGameService() *testGameService {
 			DashboardRanking: nil,
 		}, nil)
 
-	testGameService.On("SendPlayerReadyMessageToKafka", []louie_kafka.PlayerDisplayName{
-		{"tobi"}, {"willi"}, {"jann"},
-	}).Return()
-
-	testGameService.On("UpdateGameState", gameId, repository.GameReady).Return(&GameEntry{
+	testGameService.On("SetGameReady", gameId, []louie_kafka.PlayerDisplayName{
+		{DisplayName: "tobi", Seat: 1}, {DisplayName: "willi", Seat: 2}, {DisplayName: "jann", Seat: 3},
+	}).Return(&GameEntry{
 		Id:           gameId,
 		Duration:     gameDuration,
 		KiName:       kiName,
@@ -479,7 +667,7 @@ func initMockedGameService() *testGameService {
 		State:        repository.GameReady,
 	}, nil)
 
-	testGameService.On("GetCurrentGame").Return(&GameEntry{
+	testGameService.On("GetCurrentGame", testTable.Sender).Return(&GameEntry{
 		Id:           gameId,
 		Duration:     gameDuration,
 		KiName:       kiName,
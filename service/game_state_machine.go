@@ -0,0 +1,336 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/websocket"
+	"time"
+)
+
+// events of the game history, which are not sent by louie.
+const (
+	AdminAnnounce   = "ADMIN_ANNOUNCE"
+	AdminReset      = "ADMIN_RESET"
+	AdminPause      = "ADMIN_PAUSE"
+	AdminResume     = "ADMIN_RESUME"
+	WatchdogTimeout = "WATCHDOG_TIMEOUT"
+)
+
+// gameTransition is one row of the state machine of a game. The event moves the game from one of the
+// From states to the To state, if the guard accepts the event. The effect changes the game and informs
+// louie, the dashboard and the admin ui.
+type gameTransition struct {
+	From   []repository.GameState
+	Event  louie_kafka.EventType
+	To     repository.GameState
+	Guard  func(changer *GameStateChecker, game *GameEntry, message []byte) error
+	Effect func(changer *GameStateChecker, table string, game *GameEntry, message []byte) error
+	// Repeatable transitions are not marked as processed, so louie can send the event again for the game.
+	Repeatable bool
+	// Silent transitions keep the state and are not written to the history, e.g. every COIN_DROP.
+	Silent bool
+}
+
+var errNoCurrentGame = errors.New("no current game exists")
+
+var gameTransitions = []gameTransition{
+	{
+		From:   []repository.GameState{repository.GameAnnounced},
+		Event:  louie_kafka.PlayersCanBeReceived,
+		To:     repository.GameReady,
+		Effect: setPlayersReady,
+	},
+	{
+		From:   []repository.GameState{repository.GameReady},
+		Event:  louie_kafka.PlayersConfirm,
+		To:     repository.GameActive,
+		Effect: startGame,
+	},
+	{
+		From:   []repository.GameState{repository.GameActive},
+		Event:  louie_kafka.CoinDrop,
+		To:     repository.GameActive,
+		Guard:  coinDropOfSeatedPlayer,
+		Effect: updateCoins,
+		Silent: true,
+	},
+	{
+		From:   []repository.GameState{repository.GameActive},
+		Event:  louie_kafka.GameDone,
+		To:     repository.GameFinished,
+		Effect: finishGame,
+	},
+	{
+		// the legacy PAUSE_GAME and RESUME_GAME have no id, so every pause of a game has the same id. The From state
+		// already rejects a second pause or resume, so they are not marked.
+		From:       []repository.GameState{repository.GameActive},
+		Event:      louie_kafka.PauseGame,
+		To:         repository.GamePaused,
+		Effect:     pauseGame,
+		Repeatable: true,
+	},
+	{
+		From:       []repository.GameState{repository.GamePaused},
+		Event:      louie_kafka.ResumeGame,
+		To:         repository.GameActive,
+		Effect:     resumeGame,
+		Repeatable: true,
+	},
+	{
+		// a reset is idempotent and removes the processed events of the game, so it is not marked.
+		From:       []repository.GameState{repository.GameAnnounced, repository.GameReady, repository.GameActive, repository.GamePaused},
+		Event:      louie_kafka.ResetGame,
+		To:         repository.GameAnnounced,
+		Effect:     resetGameByLouie,
+		Repeatable: true,
+	},
+}
+
+// findTransition returns the transition of the event, which starts at the given state.
+func findTransition(state repository.GameState, event louie_kafka.EventType) (*gameTransition, bool) {
+
+	for i, transition := range gameTransitions {
+		if transition.Event != event {
+			continue
+		}
+
+		for _, from := range transition.From {
+			if from == state {
+				return &gameTransitions[i], true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// fire runs the transition of the event on the current game of the table. Events without a transition
+// from the current state or rejected by the guard are quarantined. Every attempt, which is not silent or
+// is rejected, is appended to the history of the game.
+func (changer *GameStateChecker) fire(table string, game *GameEntry, event louie_kafka.EventType, message []byte) (*gameTransition, error) {
+
+	if game == nil {
+		changer.rejectTransition(message, errNoCurrentGame.Error())
+		return nil, errNoCurrentGame
+	}
+
+	transition, ok := findTransition(game.State, event)
+
+	if !ok {
+		err := fmt.Errorf("current game state is \"%s\"", game.State)
+		log.Printf("get \"%s\" from Louie. No transition from \"%s\". ignore\n", event, game.State)
+		changer.rejectTransition(message, err.Error())
+		changer.recordRejected(game, event, err)
+		return nil, err
+	}
+
+	if transition.Guard != nil {
+		if err := transition.Guard(changer, game, message); err != nil {
+			log.Printf("get \"%s\" from Louie. Rejected by guard: %s\n", event, err)
+			changer.rejectTransition(message, err.Error())
+			changer.recordRejected(game, event, err)
+			return nil, err
+		}
+	}
+
+	log.Printf("get \"%s\" from Louie. Switch game %s from \"%s\" to \"%s\"\n", event, game.Id, game.State, transition.To)
+
+	// failures of the effect are technical, so the event is not quarantined and can be redelivered.
+	if err := transition.Effect(changer, table, game, message); err != nil {
+		log.Printf("switching game %s to \"%s\" failed: %s\n", game.Id, transition.To, err)
+		changer.recordRejected(game, event, err)
+		return nil, err
+	}
+
+	if !transition.Silent {
+		changer.GameService.RecordTransition(game.Id, repository.GameTransition{
+			From:      game.State,
+			To:        transition.To,
+			Event:     event.String(),
+			CreatedAt: time.Now(),
+		})
+	}
+
+	return transition, nil
+}
+
+func (changer *GameStateChecker) recordRejected(game *GameEntry, event louie_kafka.EventType, err error) {
+	changer.GameService.RecordTransition(game.Id, repository.GameTransition{
+		From:      game.State,
+		To:        game.State,
+		Event:     event.String(),
+		Rejected:  true,
+		Detail:    err.Error(),
+		CreatedAt: time.Now(),
+	})
+}
+
+func setPlayersReady(changer *GameStateChecker, _ string, game *GameEntry, _ []byte) error {
+
+	updatedGame, err := changer.GameService.SetGameReady(
+		game.Id,
+		seatedPlayers(game.Player1, game.Player2, game.Player3, game.Player4))
+
+	if err != nil {
+		return err
+	}
+
+	changer.AdminUiSocket.SendToAdminUi(toAdminUiEvent(updatedGame))
+
+	return nil
+}
+
+func startGame(changer *GameStateChecker, table string, game *GameEntry, _ []byte) error {
+	return changer.updateStateAndSignal(table, game, repository.GameActive)
+}
+
+func pauseGame(changer *GameStateChecker, table string, game *GameEntry, _ []byte) error {
+	return changer.updateStateAndSignal(table, game, repository.GamePaused)
+}
+
+func resumeGame(changer *GameStateChecker, table string, game *GameEntry, _ []byte) error {
+	return changer.updateStateAndSignal(table, game, repository.GameActive)
+}
+
+// updateStateAndSignal changes the state of the game and sends the changed game to the dashboard and the admin ui.
+func (changer *GameStateChecker) updateStateAndSignal(table string, game *GameEntry, state repository.GameState) error {
+
+	ranking, _ := changer.GameService.GetRankingsSorted()
+
+	updatedGame, err := changer.GameService.UpdateGameState(game.Id, state)
+
+	if err != nil {
+		return err
+	}
+
+	changer.GameDashboardSocket.SendToDashboard(
+		&websocket.DashboardSignal{
+			Table:            table,
+			DashboardGame:    ToDashboardGameFromGameEntry(updatedGame),
+			DashboardRanking: ToDashboardRanking(ranking),
+		},
+	)
+	changer.AdminUiSocket.SendToAdminUi(toAdminUiEvent(updatedGame))
+
+	return nil
+}
+
+func coinsNotNegative(changer *GameStateChecker, _ *GameEntry, message []byte) error {
+
+	coinDropEvent := changer.unmarshalCoinDropEvent(message)
+
+	if coinDropEvent == nil {
+		return errors.New("coin drop can not be read")
+	}
+
+	if coinDropEvent.Coins < 0 {
+		return fmt.Errorf("coins of %s are negative", coinDropEvent.Name)
+	}
+
+	return nil
+}
+
+// coinDropOfSeatedPlayer rejects coins of players without seat, e.g. of the ki, while it sits out.
+func coinDropOfSeatedPlayer(changer *GameStateChecker, game *GameEntry, message []byte) error {
+
+	if err := coinsNotNegative(changer, game, message); err != nil {
+		return err
+	}
+
+	coinDropEvent := changer.unmarshalCoinDropEvent(message)
+
+	if !game.HasSeat(coinDropEvent.Name) {
+		return fmt.Errorf("%s has no seat in the game", coinDropEvent.Name)
+	}
+
+	return nil
+}
+
+func updateCoins(changer *GameStateChecker, table string, _ *GameEntry, message []byte) error {
+
+	coinDropEvent := changer.unmarshalCoinDropEvent(message)
+
+	if ok := changer.GameService.UpdateCoins(table, coinDropEvent.Name, coinDropEvent.Coins); !ok {
+		return fmt.Errorf("coins of %s can not be updated", coinDropEvent.Name)
+	}
+
+	ranking, _ := changer.GameService.GetRankingsSorted()
+	updatedGame, err := changer.GameService.GetCurrentGame(table)
+
+	if err != nil || updatedGame == nil {
+		return errNoCurrentGame
+	}
+
+	changer.GameDashboardSocket.SendToDashboard(
+		&websocket.DashboardSignal{
+			Table:            table,
+			DashboardGame:    ToDashboardGameFromGameEntry(updatedGame),
+			DashboardRanking: ToDashboardRanking(ranking),
+		})
+	changer.AdminUiSocket.SendToAdminUi(toAdminUiEvent(updatedGame))
+
+	return nil
+}
+
+func finishGame(changer *GameStateChecker, table string, game *GameEntry, message []byte) error {
+
+	gameDoneEvent := changer.unmarshalGameDoneEvent(message)
+
+	if gameDoneEvent == nil {
+		return errors.New("game done can not be read")
+	}
+
+	currentGameId, err := changer.parseGameId(game.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if gameDoneEvent.Duration <= 0 {
+		// louie did not measure the game, so the game clock of the server is used.
+		gameDoneEvent.Duration = game.PlayingTime
+	}
+
+	// if finishing the game fails afterwards, the redelivered GAME_DONE updates the statistics again. They are not
+	// counted twice, because the statistic of a player is only updated for games, which are not in its counted_games.
+	changer.updatePlayerStatistic(*currentGameId, gameDoneEvent)
+
+	updatedGame, err := changer.GameService.FinishGame(game.Id, gameDoneEvent.Duration, gameDoneEvent.WinningPlayer.Name)
+
+	if err != nil {
+		return err
+	}
+
+	ranking, _ := changer.GameService.GetRankingsSorted()
+
+	changer.GameDashboardSocket.SendToDashboard(
+		&websocket.DashboardSignal{
+			Table:            table,
+			DashboardGame:    ToDashboardGameFromGameEntry(updatedGame),
+			DashboardRanking: ToDashboardRanking(ranking),
+		})
+	changer.AdminUiSocket.SendToAdminUi(toAdminUiEvent(updatedGame))
+
+	if changer.MatchQueue != nil {
+		changer.MatchQueue.GameFinished(table)
+	}
+
+	return nil
+}
+
+func resetGameByLouie(changer *GameStateChecker, table string, game *GameEntry, _ []byte) error {
+
+	updatedGame, err := changer.GameService.ResetGameByLouie(game.Id)
+
+	if err != nil {
+		return err
+	}
+
+	changer.GameDashboardSocket.RemoveGameFromDashboard(table)
+	changer.AdminUiSocket.SendToAdminUi(toAdminUiEvent(updatedGame))
+
+	return nil
+}
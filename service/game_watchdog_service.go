@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/websocket"
+	"time"
+)
+
+// WatchdogAction is done by the watchdog, if a game stays too long in a state. Every action alerts the admin ui.
+type WatchdogAction string
+
+const (
+	WatchdogAlert              WatchdogAction = "alert"
+	WatchdogPrompt             WatchdogAction = "prompt"
+	WatchdogResendPlayersReady WatchdogAction = "resend_players_ready"
+	WatchdogAbort              WatchdogAction = "abort"
+)
+
+func (c WatchdogAction) String() string {
+	return string(c)
+}
+
+func ParseWatchdogAction(value string) (WatchdogAction, error) {
+	switch WatchdogAction(value) {
+	case WatchdogAlert, WatchdogPrompt, WatchdogResendPlayersReady, WatchdogAbort:
+		return WatchdogAction(value), nil
+	}
+
+	return "", fmt.Errorf("unknown watchdog action \"%s\"", value)
+}
+
+// StateTimeout is the time a game may stay in a state without change. A timeout of 0 disables the watchdog for
+// the state.
+type StateTimeout struct {
+	Timeout time.Duration
+	Action  WatchdogAction
+}
+
+// GameWatchdogSer checks the current games of the tables for states, which louie did not leave in time, e.g. a
+// missing PLAYERS_CONFIRM or GAME_DONE. The action is written to the history of the game, so the next action
+// follows after another timeout at the earliest.
+type GameWatchdogSer struct {
+	GameService         GameService
+	Tables              *TableSer
+	Timeouts            map[repository.GameState]StateTimeout
+	AdminUiSocket       *websocket.AdminUiWebsocket
+	GameDashboardSocket *websocket.GameDashboardSocket
+	now                 func() time.Time
+}
+
+func NewGameWatchdogService(
+	gameService GameService,
+	tables *TableSer,
+	timeouts map[repository.GameState]StateTimeout,
+	adminUiSocket *websocket.AdminUiWebsocket,
+	gameDashboardSocket *websocket.GameDashboardSocket,
+) *GameWatchdogSer {
+	return &GameWatchdogSer{
+		GameService:         gameService,
+		Tables:              tables,
+		Timeouts:            timeouts,
+		AdminUiSocket:       adminUiSocket,
+		GameDashboardSocket: gameDashboardSocket,
+		now:                 time.Now,
+	}
+}
+
+// RunGameWatchdog checks the current games with every interval.
+func (w *GameWatchdogSer) RunGameWatchdog(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.checkGames()
+			}
+		}
+	}()
+}
+
+func (w *GameWatchdogSer) checkGames() {
+	for _, table := range w.Tables.GetAll() {
+		w.checkGame(table)
+	}
+}
+
+func (w *GameWatchdogSer) checkGame(table Table) {
+
+	game, err := w.GameService.GetCurrentGame(table.Sender)
+
+	if err != nil || game == nil {
+		return
+	}
+
+	timeout, ok := w.Timeouts[game.State]
+
+	if !ok || timeout.Timeout <= 0 || w.now().Sub(game.LastChange) < timeout.Timeout {
+		return
+	}
+
+	detail := fmt.Sprintf("game is %s for more than %s, action: %s", game.State, timeout.Timeout, timeout.Action)
+	log.Printf("watchdog of table %s: %s\n", table.Sender, detail)
+
+	w.GameService.RecordTransition(game.Id, repository.GameTransition{
+		From:      game.State,
+		To:        game.State,
+		Event:     WatchdogTimeout,
+		Detail:    detail,
+		CreatedAt: w.now(),
+	})
+
+	message := fmt.Sprintf("%s: the game is %s for more than %s.", table.Name, game.State, timeout.Timeout)
+
+	switch timeout.Action {
+	case WatchdogResendPlayersReady:
+		if err := w.GameService.ResendPlayersReady(game.Id); err != nil {
+			message += fmt.Sprintf(" Resending the players failed: %s", err)
+		} else {
+			message += " The players are sent to louie again."
+		}
+	case WatchdogAbort:
+		if err := w.abort(table, game, detail); err != nil {
+			message += fmt.Sprintf(" Aborting the game failed: %s", err)
+		} else {
+			message += " The game is aborted."
+		}
+	case WatchdogPrompt:
+		message += " Please check louie and reset the game, if it does not continue."
+	}
+
+	w.AdminUiSocket.SendToAdminUi(&websocket.AdminUiEvent{
+		EventType: websocket.WatchdogAlert,
+		Table:     table.Sender,
+		Message:   message,
+		GameId:    game.Id,
+		Prompt:    timeout.Action == WatchdogPrompt,
+	})
+}
+
+func (w *GameWatchdogSer) abort(table Table, game *GameEntry, reason string) error {
+
+	abortedGame, err := w.GameService.AbortGame(game.Id, reason)
+
+	if err != nil {
+		return err
+	}
+
+	if dashboardState, err := w.GameService.GetCurrentDashboardState(table.Sender); err == nil {
+		w.GameDashboardSocket.SendToDashboard(dashboardState)
+	}
+	w.AdminUiSocket.SendToAdminUi(toAdminUiEvent(abortedGame))
+
+	return nil
+}
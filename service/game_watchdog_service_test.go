@@ -0,0 +1,182 @@
+package service
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/websocket"
+	"testing"
+	"time"
+)
+
+type watchdogTest struct {
+	watchdogService  *GameWatchdogSer
+	gameService      *GameSer
+	outboxRepository *repository.MemoryOutboxRepo
+	adminUiChannel   chan websocket.AdminUiEvent
+	gameId           string
+}
+
+func initWatchdogTest(t *testing.T, timeouts map[repository.GameState]StateTimeout) *watchdogTest {
+
+	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
+	adminUiWebsocket := websocket.InitAdminUiWebsocket(adminUiChannel)
+	outboxRepository := repository.NewMemoryOutboxRepo()
+
+	gameService := &GameSer{
+		UserRepository: repository.NewMemoryUserRepo(),
+		GameRepository: repository.NewMemoryGameRepo(outboxRepository),
+		OutboxService:  NewOutboxService(outboxRepository, louie_kafka.NewMemoryBus(), louie_kafka.Topics{}, adminUiWebsocket, 3, "louie-web-administrator", false),
+	}
+
+	gameId, err := gameService.CreateGame(testTable, repository.DefaultGameSettings(), []repository.RegisteredUser{{DisplayName: "tobi", Pos: "1"}})
+
+	assert.NoError(t, err)
+
+	dashboardSocket := websocket.InitGameDashboardSocket(
+		map[string]chan *websocket.DashboardSignal{testTable.Sender: make(chan *websocket.DashboardSignal, 10)},
+		testTable.Sender,
+		gameService.GetCurrentDashboardState,
+	)
+
+	return &watchdogTest{
+		watchdogService:  NewGameWatchdogService(gameService, testTables, timeouts, adminUiWebsocket, dashboardSocket),
+		gameService:      gameService,
+		outboxRepository: outboxRepository,
+		adminUiChannel:   adminUiChannel,
+		gameId:           gameId.Hex(),
+	}
+}
+
+func (w *watchdogTest) after(duration time.Duration) {
+	now := time.Now().Add(duration)
+	w.watchdogService.now = func() time.Time { return now }
+}
+
+func Test_GameWatchdog_AlertsOnlyAfterTimeout(t *testing.T) {
+
+	test := initWatchdogTest(t, map[repository.GameState]StateTimeout{
+		repository.GameAnnounced: {Timeout: 10 * time.Minute, Action: WatchdogPrompt},
+	})
+
+	test.after(5 * time.Minute)
+	test.watchdogService.checkGames()
+
+	assert.Len(t, test.adminUiChannel, 0)
+
+	test.after(11 * time.Minute)
+	test.watchdogService.checkGames()
+
+	alert := <-test.adminUiChannel
+
+	assert.Equal(t, websocket.WatchdogAlert, alert.EventType)
+	assert.Equal(t, test.gameId, alert.GameId)
+	assert.True(t, alert.Prompt)
+
+	// the recorded action restarts the timeout
+	test.watchdogService.checkGames()
+
+	assert.Len(t, test.adminUiChannel, 0)
+
+	game, _ := test.gameService.GetCurrentGame(testTable.Sender)
+
+	assert.Equal(t, WatchdogTimeout, game.History[len(game.History)-1].Event)
+	assert.Equal(t, repository.GameAnnounced, game.State)
+}
+
+func Test_GameWatchdog_ResendsPlayersReady(t *testing.T) {
+
+	test := initWatchdogTest(t, map[repository.GameState]StateTimeout{
+		repository.GameReady: {Timeout: 2 * time.Minute, Action: WatchdogResendPlayersReady},
+	})
+
+	_, err := test.gameService.SetGameReady(test.gameId, []louie_kafka.PlayerDisplayName{{DisplayName: "tobi"}})
+
+	assert.NoError(t, err)
+
+	test.after(3 * time.Minute)
+	test.watchdogService.checkGames()
+
+	assert.Equal(t, websocket.WatchdogAlert, (<-test.adminUiChannel).EventType)
+
+	pending, _ := test.outboxRepository.GetPending(10)
+
+	assert.Len(t, pending, 2)
+	assert.Equal(t, louie_kafka.PlayersReady.String(), pending[1].Event)
+	assert.Contains(t, pending[1].Payload, test.gameId)
+}
+
+func Test_GameWatchdog_AbortsGame(t *testing.T) {
+
+	test := initWatchdogTest(t, map[repository.GameState]StateTimeout{
+		repository.GameActive: {Timeout: 30 * time.Minute, Action: WatchdogAbort},
+	})
+
+	_, err := test.gameService.UpdateGameState(test.gameId, repository.GameActive)
+
+	assert.NoError(t, err)
+
+	test.after(31 * time.Minute)
+	test.watchdogService.checkGames()
+
+	assert.Equal(t, websocket.Aborted, (<-test.adminUiChannel).EventType)
+	assert.Equal(t, websocket.WatchdogAlert, (<-test.adminUiChannel).EventType)
+
+	game, _ := test.gameService.GetCurrentGame(testTable.Sender)
+
+	assert.Equal(t, repository.GameAborted, game.State)
+
+	events := make([]string, 0, len(game.History))
+	for _, transition := range game.History {
+		events = append(events, transition.Event)
+	}
+
+	assert.Equal(t, []string{AdminAnnounce, WatchdogTimeout, louie_kafka.AbortGame.String()}, events)
+}
+
+func Test_GameWatchdog_WatchesPausedGame(t *testing.T) {
+
+	test := initWatchdogTest(t, map[repository.GameState]StateTimeout{
+		repository.GamePaused: {Timeout: 30 * time.Minute, Action: WatchdogPrompt},
+	})
+
+	_, err := test.gameService.UpdateGameState(test.gameId, repository.GamePaused)
+
+	assert.NoError(t, err)
+
+	test.after(31 * time.Minute)
+	test.watchdogService.checkGames()
+
+	alert := <-test.adminUiChannel
+
+	assert.Equal(t, websocket.WatchdogAlert, alert.EventType)
+	assert.True(t, alert.Prompt)
+}
+
+func Test_LastChange_IncludesCoinDrops(t *testing.T) {
+
+	announcedAt := time.Date(2024, 1, 12, 10, 15, 0, 0, time.UTC)
+	game := &repository.GameEntity{
+		Id:      primitive.NewObjectIDFromTimestamp(announcedAt),
+		History: []repository.GameTransition{{Event: AdminAnnounce, CreatedAt: announcedAt}},
+	}
+
+	assert.Equal(t, announcedAt, lastChange(game))
+
+	game.LastActivityAt = announcedAt.Add(25 * time.Minute)
+
+	assert.Equal(t, announcedAt.Add(25*time.Minute), lastChange(game))
+}
+
+func Test_ParseWatchdogAction(t *testing.T) {
+
+	action, err := ParseWatchdogAction("resend_players_ready")
+
+	assert.NoError(t, err)
+	assert.Equal(t, WatchdogResendPlayersReady, action)
+
+	_, err = ParseWatchdogAction("restart")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,504 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/websocket"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MatchQueue announces the next queued match, after a game of the table is finished.
+type MatchQueue interface {
+	GameFinished(table string)
+}
+
+var (
+	// ErrInvalidMatch is wrapped with the reason, e.g. an unknown player.
+	ErrInvalidMatch   = errors.New("invalid match")
+	ErrMatchNotQueued = errors.New("the match is not queued")
+	ErrQueueEmpty     = errors.New("no match is queued")
+	// ErrPlayerBusy is wrapped with the player, which is active or plays at another table.
+	ErrPlayerBusy = errors.New("player is busy at another table")
+)
+
+type MatchEntry struct {
+	Id string
+	// Players are the display names in the order of their seats, separated by comma.
+	Players       string
+	Seats         int
+	StartingCoins int
+	WithoutKi     bool
+}
+
+// MatchQueueSer keeps the upcoming matches of every table. After a finished game, the next match is announced
+// automatically, when the cool-down is over.
+type MatchQueueSer struct {
+	MatchRepository repository.MatchRepository
+	UserRepository  repository.UserRepository
+	GameService     GameService
+	Tables          *TableSer
+	AdminUiSocket   *websocket.AdminUiWebsocket
+	// CoolDown is the time between the end of a game and the announcement of the next match. A negative cool-down
+	// disables the automatic announcement.
+	CoolDown time.Duration
+	schedule func(delay time.Duration, announce func())
+	// mutex guards tableLocks, a table lock serializes the announcements of the table, e.g. of the admin ui and
+	// of the cool-down.
+	mutex      sync.Mutex
+	tableLocks map[string]*sync.Mutex
+}
+
+func NewMatchQueueService(
+	matchRepository repository.MatchRepository,
+	userRepository repository.UserRepository,
+	gameService GameService,
+	tables *TableSer,
+	adminUiSocket *websocket.AdminUiWebsocket,
+	coolDown time.Duration,
+) *MatchQueueSer {
+	return &MatchQueueSer{
+		MatchRepository: matchRepository,
+		UserRepository:  userRepository,
+		GameService:     gameService,
+		Tables:          tables,
+		AdminUiSocket:   adminUiSocket,
+		CoolDown:        coolDown,
+		schedule: func(delay time.Duration, announce func()) {
+			time.AfterFunc(delay, announce)
+		},
+		tableLocks: make(map[string]*sync.Mutex),
+	}
+}
+
+// GetQueued returns the queued matches of the table, the next match first.
+func (m *MatchQueueSer) GetQueued(table string) ([]MatchEntry, error) {
+
+	matches, err := m.MatchRepository.GetQueued(table)
+
+	if err != nil {
+		log.Printf("get queued matches failed %s\n", err)
+		return nil, err
+	}
+
+	entries := make([]MatchEntry, 0, len(matches))
+
+	for _, match := range matches {
+		entries = append(entries, MatchEntry{
+			Id:            match.Id.Hex(),
+			Players:       strings.Join(match.Players, ", "),
+			Seats:         len(match.Players),
+			StartingCoins: match.StartingCoins,
+			WithoutKi:     match.WithoutKi,
+		})
+	}
+
+	return entries, nil
+}
+
+// Enqueue adds the match to the end of the queue of the table. Every player takes a seat in the given order.
+func (m *MatchQueueSer) Enqueue(table Table, players []string, settings repository.GameSettings) (*primitive.ObjectID, error) {
+
+	if err := m.validateMatch(table, players, settings); err != nil {
+		return nil, err
+	}
+
+	matches, err := m.MatchRepository.GetQueued(table.Sender)
+
+	if err != nil {
+		return nil, err
+	}
+
+	position := int64(0)
+
+	if len(matches) > 0 {
+		position = matches[len(matches)-1].Position + 1
+	}
+
+	return m.MatchRepository.Add(repository.NewMatchEntity(table.Sender, players, settings, position))
+}
+
+// Edit replaces the players and the settings of a queued match.
+func (m *MatchQueueSer) Edit(id string, players []string, settings repository.GameSettings) error {
+
+	match, err := m.getQueued(id)
+
+	if err != nil {
+		return err
+	}
+
+	table, err := m.Tables.Get(match.Table)
+
+	if err != nil {
+		return err
+	}
+
+	if err := m.validateMatch(table, players, settings); err != nil {
+		return err
+	}
+
+	match.Players = players
+	match.StartingCoins = settings.StartingCoins
+	match.WithoutKi = settings.WithoutKi
+
+	return m.MatchRepository.Update(*match)
+}
+
+// Move swaps the queued match with its neighbour, -1 moves the match to the front of the queue. A match at the
+// end of the queue stays in place.
+func (m *MatchQueueSer) Move(id string, offset int) error {
+
+	match, err := m.getQueued(id)
+
+	if err != nil {
+		return err
+	}
+
+	matches, err := m.MatchRepository.GetQueued(match.Table)
+
+	if err != nil {
+		return err
+	}
+
+	for i := range matches {
+		if matches[i].Id != match.Id {
+			continue
+		}
+
+		neighbour := i + offset
+
+		if neighbour < 0 || neighbour >= len(matches) {
+			return nil
+		}
+
+		matches[i].Position, matches[neighbour].Position = matches[neighbour].Position, matches[i].Position
+
+		if err := m.MatchRepository.Update(matches[i]); err != nil {
+			return err
+		}
+
+		return m.MatchRepository.Update(matches[neighbour])
+	}
+
+	return nil
+}
+
+// Skip removes the match from the queue. It is kept as skipped.
+func (m *MatchQueueSer) Skip(id string) error {
+
+	match, err := m.getQueued(id)
+
+	if err != nil {
+		return err
+	}
+
+	match.State = repository.MatchSkipped
+
+	return m.MatchRepository.Update(*match)
+}
+
+// AnnounceNext announces the first queued match of the table. The players of the match are activated at the
+// table with their seat as position, the other active players of the table have to wait. The users are only
+// changed, after the game is created.
+func (m *MatchQueueSer) AnnounceNext(table Table) (*primitive.ObjectID, error) {
+
+	tableLock := m.lockOf(table.Sender)
+	tableLock.Lock()
+	defer tableLock.Unlock()
+
+	matches, err := m.MatchRepository.GetQueued(table.Sender)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) == 0 {
+		return nil, ErrQueueEmpty
+	}
+
+	match := matches[0]
+
+	currentGame, err := m.GameService.GetCurrentGame(table.Sender)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if currentGame != nil && !currentGame.State.IsOver() {
+		return nil, ErrGameRunning
+	}
+
+	players, err := m.resolvePlayers(table, match)
+
+	if err != nil {
+		return nil, err
+	}
+
+	activeUsers, err := m.UserRepository.GetAllActive(table.Sender)
+
+	if err != nil {
+		return nil, err
+	}
+
+	gameId, err := m.GameService.CreateGame(table, match.Settings(), gameMembers(players, activeUsers))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.seatPlayers(table, match, players, activeUsers); err != nil {
+		return nil, err
+	}
+
+	match.State = repository.MatchAnnounced
+	match.GameId = gameId
+
+	if err := m.MatchRepository.Update(match); err != nil {
+		return nil, err
+	}
+
+	return gameId, nil
+}
+
+// GameFinished announces the next match of the table after the cool-down.
+func (m *MatchQueueSer) GameFinished(table string) {
+
+	if m.CoolDown < 0 {
+		return
+	}
+
+	m.schedule(m.CoolDown, func() {
+		m.announceNextAfterCoolDown(table)
+	})
+}
+
+// ResumeAfterRestart schedules the next match of every table, whose last game is finished and whose queue is not
+// empty. The cool-down is kept in memory only, so it continues from the end of the game.
+func (m *MatchQueueSer) ResumeAfterRestart() {
+
+	if m.CoolDown < 0 {
+		return
+	}
+
+	for _, table := range m.Tables.GetAll() {
+
+		game, err := m.GameService.GetCurrentGame(table.Sender)
+
+		if err != nil || game == nil || game.State != repository.GameFinished {
+			continue
+		}
+
+		matches, err := m.MatchRepository.GetQueued(table.Sender)
+
+		if err != nil || len(matches) == 0 {
+			continue
+		}
+
+		// the last change of a finished game is its end.
+		remaining := max(0, m.CoolDown-time.Since(game.LastChange))
+		sender := table.Sender
+
+		log.Printf("next match of table %s is announced in %s\n", sender, remaining)
+		m.schedule(remaining, func() {
+			m.announceNextAfterCoolDown(sender)
+		})
+	}
+}
+
+func (m *MatchQueueSer) announceNextAfterCoolDown(sender string) {
+
+	table, err := m.Tables.Get(sender)
+
+	if err != nil {
+		log.Printf("announcing the next match failed: %s\n", err)
+		return
+	}
+
+	gameId, err := m.AnnounceNext(table)
+
+	if errors.Is(err, ErrQueueEmpty) {
+		return
+	}
+
+	if err != nil {
+		log.Printf("announcing the next match of table %s failed: %s\n", table.Sender, err)
+		m.AdminUiSocket.SendToAdminUi(&websocket.AdminUiEvent{
+			EventType: websocket.MatchQueueAlert,
+			Table:     table.Sender,
+			Message:   fmt.Sprintf("%s: announcing the next match failed: %s", table.Name, err),
+		})
+		return
+	}
+
+	log.Printf("next match of table %s is announced as game %s\n", table.Sender, gameId.Hex())
+	m.AdminUiSocket.SendToAdminUi(&websocket.AdminUiEvent{
+		EventType: websocket.MatchAnnounced,
+		Table:     table.Sender,
+		Message:   fmt.Sprintf("%s: the next match is announced.", table.Name),
+		GameId:    gameId.Hex(),
+	})
+}
+
+func (m *MatchQueueSer) lockOf(table string) *sync.Mutex {
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.tableLocks == nil {
+		m.tableLocks = make(map[string]*sync.Mutex)
+	}
+
+	if _, ok := m.tableLocks[table]; !ok {
+		m.tableLocks[table] = &sync.Mutex{}
+	}
+
+	return m.tableLocks[table]
+}
+
+// gameMembers returns the players of the match seated in the given order together with the active ki user of
+// the table. No user is changed, so a rejected game leaves the users as they are.
+func gameMembers(players []repository.RegisteredUser, activeUsers []repository.RegisteredUser) []repository.RegisteredUser {
+
+	members := make([]repository.RegisteredUser, 0, len(players)+1)
+
+	for _, user := range activeUsers {
+		if user.IsKiUser {
+			members = append(members, user)
+		}
+	}
+
+	for i, user := range players {
+		user.Pos = strconv.Itoa(i + 1)
+		members = append(members, user)
+	}
+
+	return members
+}
+
+// seatPlayers activates the players of the match at the table with their seat as position. The other active
+// players of the table have to wait.
+func (m *MatchQueueSer) seatPlayers(
+	table Table,
+	match repository.MatchEntity,
+	players []repository.RegisteredUser,
+	activeUsers []repository.RegisteredUser,
+) error {
+
+	for _, user := range activeUsers {
+		if !user.IsKiUser && !containsPlayer(match.Players, user.DisplayName) {
+			if _, err := m.UserRepository.UpdateState(user.Id.Hex(), string(repository.UserWaiting)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, user := range players {
+
+		if _, err := m.UserRepository.UpdateTable(user.Id.Hex(), table.Sender); err != nil {
+			return err
+		}
+
+		if _, err := m.UserRepository.UpdatePosition(user.Id.Hex(), strconv.Itoa(i+1)); err != nil {
+			return err
+		}
+
+		if _, err := m.UserRepository.UpdateState(user.Id.Hex(), string(repository.UserActive)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolvePlayers returns the registered users of the match in the order of their seats. Players, which are active
+// or seated in the running game at another table, are rejected.
+func (m *MatchQueueSer) resolvePlayers(table Table, match repository.MatchEntity) ([]repository.RegisteredUser, error) {
+
+	players := make([]repository.RegisteredUser, 0, len(match.Players))
+
+	for _, player := range match.Players {
+
+		user, _ := m.UserRepository.GetByDisplayName(player)
+
+		if user == nil || user.IsKiUser {
+			return nil, fmt.Errorf("%w: %s is not registered", ErrInvalidMatch, player)
+		}
+
+		if user.Table != "" && user.Table != table.Sender {
+
+			if user.State == repository.UserActive {
+				return nil, fmt.Errorf("%w: %s is active at table %s", ErrPlayerBusy, player, user.Table)
+			}
+
+			otherGame, err := m.GameService.GetCurrentGame(user.Table)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if otherGame != nil && !otherGame.State.IsOver() && otherGame.HasSeat(player) {
+				return nil, fmt.Errorf("%w: %s plays at table %s", ErrPlayerBusy, player, user.Table)
+			}
+		}
+
+		players = append(players, *user)
+	}
+
+	return players, nil
+}
+
+func (m *MatchQueueSer) getQueued(id string) (*repository.MatchEntity, error) {
+
+	match, err := m.MatchRepository.Get(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if match.State != repository.MatchQueued {
+		return nil, ErrMatchNotQueued
+	}
+
+	return match, nil
+}
+
+// validateMatch checks the players and the settings like the announcement of the game does later.
+func (m *MatchQueueSer) validateMatch(table Table, players []string, settings repository.GameSettings) error {
+
+	if len(players) == 0 {
+		return fmt.Errorf("%w: the match has no players", ErrInvalidMatch)
+	}
+
+	for i, player := range players {
+
+		if containsPlayer(players[:i], player) {
+			return fmt.Errorf("%w: %s takes more than one seat", ErrInvalidMatch, player)
+		}
+
+		user, _ := m.UserRepository.GetByDisplayName(player)
+
+		if user == nil || user.IsKiUser {
+			return fmt.Errorf("%w: %s is not registered", ErrInvalidMatch, player)
+		}
+	}
+
+	settings.Seats = len(players)
+
+	return validateGameSettings(settings, table.KiName != "" && !settings.WithoutKi, nil)
+}
+
+func containsPlayer(players []string, player string) bool {
+
+	for _, existing := range players {
+		if strings.ToLower(existing) == strings.ToLower(player) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/websocket"
+	"time"
+)
+
+type OutboxService interface {
+	Enqueue(event louie_kafka.EventType, payload interface{}) error
+	Notify()
+	GetFailed() ([]OutboxEntry, error)
+	CountPending() int64
+	CountFailed() int64
+	Retry(id string) error
+	Discard(id string) error
+}
+
+// MessagePublisher writes messages to a topic of the message bus.
+type MessagePublisher interface {
+	Publish(ctx context.Context, topic string, messages ...louie_kafka.Message) error
+}
+
+type OutboxEntry struct {
+	Id        string
+	Event     string
+	Payload   string
+	State     repository.OutboxState
+	Attempts  int
+	LastError string
+	CreatedAt string
+}
+
+type OutboxSer struct {
+	OutboxRepository repository.OutboxRepository
+	MessageBus       MessagePublisher
+	Topics           louie_kafka.Topics
+	AdminUiSocket    *websocket.AdminUiWebsocket
+	MaxAttempts      int
+	// Sender identifies the administrator in the envelope of published events.
+	Sender string
+	// SendEnvelope publishes events in a versioned envelope instead of the legacy flat format.
+	SendEnvelope bool
+	// Recorder stores the published and finally failed events. Optional.
+	Recorder     louie_kafka.EventRecorder
+	relayTrigger chan struct{}
+	lastPending  int64
+	lastFailed   int64
+}
+
+func NewOutboxService(
+	outboxRepository repository.OutboxRepository,
+	messageBus MessagePublisher,
+	topics louie_kafka.Topics,
+	adminUiSocket *websocket.AdminUiWebsocket,
+	maxAttempts int,
+	sender string,
+	sendEnvelope bool,
+) *OutboxSer {
+	return &OutboxSer{
+		OutboxRepository: outboxRepository,
+		MessageBus:       messageBus,
+		Topics:           topics,
+		AdminUiSocket:    adminUiSocket,
+		MaxAttempts:      maxAttempts,
+		Sender:           sender,
+		SendEnvelope:     sendEnvelope,
+		relayTrigger:     make(chan struct{}, 1),
+		lastPending:      -1,
+		lastFailed:       -1,
+	}
+}
+
+func (o *OutboxSer) Enqueue(event louie_kafka.EventType, payload interface{}) error {
+
+	marshalledPayload, err := json.Marshal(payload)
+
+	if err != nil {
+		log.Printf("can not marshal %s outbox event: %s\n", event, err)
+		return err
+	}
+
+	_, err = o.OutboxRepository.Add(repository.NewOutboxEntity(event.String(), marshalledPayload))
+
+	if err != nil {
+		log.Printf("storing %s event in outbox failed: %s\n", event, err)
+		return err
+	}
+
+	o.Notify()
+
+	return nil
+}
+
+// Notify wakes up the relay, so new entries are published without waiting for the next interval.
+func (o *OutboxSer) Notify() {
+	select {
+	case o.relayTrigger <- struct{}{}:
+	default:
+	}
+}
+
+// RunOutboxRelay publishes pending outbox entries in creation order. Failed publishing attempts are
+// retried with every interval until MaxAttempts is reached.
+func (o *OutboxSer) RunOutboxRelay(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-o.relayTrigger:
+			}
+
+			o.relayPending(ctx)
+			o.sendStatusToAdminUi()
+		}
+	}()
+}
+
+func (o *OutboxSer) relayPending(ctx context.Context) {
+
+	pendingEntries, err := o.OutboxRepository.GetPending(100)
+
+	if err != nil {
+		log.Printf("can not read pending outbox entries: %s\n", err)
+		return
+	}
+
+	for _, entry := range pendingEntries {
+
+		value, err := o.messageValue(entry)
+		topic := o.Topics.PublishTopic(louie_kafka.EventType(entry.Event))
+
+		if err == nil {
+			err = o.MessageBus.Publish(ctx, topic, louie_kafka.Message{Value: value})
+		}
+
+		if err == nil {
+			if err := o.OutboxRepository.MarkSent(entry.Id); err != nil {
+				log.Printf("can not mark outbox entry %s as sent: %s\n", entry.Id.Hex(), err)
+			}
+			o.record(topic, value, louie_kafka.ProcessingResult{Outcome: louie_kafka.OutcomePublished})
+			continue
+		}
+
+		log.Printf("publishing outbox entry %s (%s) failed: %s\n", entry.Id.Hex(), entry.Event, err)
+
+		failedEntry, markErr := o.OutboxRepository.MarkAttemptFailed(entry.Id, err.Error(), o.MaxAttempts)
+
+		if markErr == nil && failedEntry.State == repository.OutboxFailed {
+			if value == nil {
+				value = []byte(entry.Payload)
+			}
+			o.record(topic, value, louie_kafka.ProcessingResult{Outcome: louie_kafka.OutcomeFailed, Detail: err.Error()})
+			o.AdminUiSocket.SendToAdminUi(&websocket.AdminUiEvent{
+				EventType: websocket.ProducerFailure,
+				Message:   fmt.Sprintf("sending %s to louie failed after %d attempts: %s", entry.Event, failedEntry.Attempts, err),
+			})
+		}
+
+		// keep the order of the events. The remaining entries are sent with the next attempt.
+		return
+	}
+}
+
+func (o *OutboxSer) record(topic string, value []byte, result louie_kafka.ProcessingResult) {
+	if o.Recorder != nil {
+		o.Recorder.Record(louie_kafka.RecordedEvent{
+			Direction: louie_kafka.DirectionOutbound,
+			Topic:     topic,
+			Value:     value,
+			Result:    result,
+		})
+	}
+}
+
+// messageValue returns the payload of the outbox entry as it is published. The id of the outbox entry is
+// used as event id, so the id stays the same for all publishing attempts. Legacy events get the sender
+// as top level field, so every consumer can filter the commands of the administrator.
+func (o *OutboxSer) messageValue(entry repository.OutboxEntity) ([]byte, error) {
+
+	if !o.SendEnvelope {
+		return withSender([]byte(entry.Payload), o.Sender)
+	}
+
+	return louie_kafka.NewEnvelopeMessage(
+		entry.Id.Hex(),
+		louie_kafka.EventType(entry.Event),
+		o.Sender,
+		entry.CreatedAt,
+		[]byte(entry.Payload),
+	)
+}
+
+func withSender(payload []byte, sender string) ([]byte, error) {
+
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+
+	if _, ok := fields["sender"]; ok || sender == "" {
+		return payload, nil
+	}
+
+	fields["sender"], _ = json.Marshal(sender)
+
+	return json.Marshal(fields)
+}
+
+func (o *OutboxSer) sendStatusToAdminUi() {
+
+	pending := o.CountPending()
+	failed := o.CountFailed()
+
+	if pending == o.lastPending && failed == o.lastFailed {
+		return
+	}
+
+	o.lastPending = pending
+	o.lastFailed = failed
+
+	o.AdminUiSocket.SendToAdminUi(&websocket.AdminUiEvent{
+		EventType:     websocket.OutboxStatus,
+		OutboxPending: pending,
+		OutboxFailed:  failed,
+	})
+}
+
+func (o *OutboxSer) GetFailed() ([]OutboxEntry, error) {
+
+	failedEntries, err := o.OutboxRepository.GetFailed(100)
+
+	if err != nil {
+		return nil, err
+	}
+
+	outboxEntries := make([]OutboxEntry, 0, len(failedEntries))
+
+	for _, entity := range failedEntries {
+		outboxEntries = append(outboxEntries, OutboxEntry{
+			Id:        entity.Id.Hex(),
+			Event:     entity.Event,
+			Payload:   entity.Payload,
+			State:     entity.State,
+			Attempts:  entity.Attempts,
+			LastError: entity.LastError,
+			CreatedAt: entity.CreatedAt.Local().Format(repository.GermanDateTimeFormat),
+		})
+	}
+
+	return outboxEntries, nil
+}
+
+func (o *OutboxSer) CountPending() int64 {
+	count, _ := o.OutboxRepository.CountByState(repository.OutboxPending)
+
+	return count
+}
+
+func (o *OutboxSer) CountFailed() int64 {
+	count, _ := o.OutboxRepository.CountByState(repository.OutboxFailed)
+
+	return count
+}
+
+func (o *OutboxSer) Retry(id string) error {
+
+	err := o.OutboxRepository.Retry(id)
+
+	if err == nil {
+		o.Notify()
+	}
+
+	return err
+}
+
+func (o *OutboxSer) Discard(id string) error {
+
+	err := o.OutboxRepository.Remove(id)
+
+	if err == nil {
+		o.Notify()
+	}
+
+	return err
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/websocket"
+	"testing"
+)
+
+type testPublisher struct {
+	mock.Mock
+}
+
+func (testPublisher *testPublisher) Publish(ctx context.Context, topic string, messages ...louie_kafka.Message) error {
+	args := testPublisher.Called(topic, messages)
+	return args.Error(0)
+}
+
+func Test_RelayPending_PublishesInOrder(t *testing.T) {
+
+	testOutboxRepository := new(repository.TestOutboxRepository)
+	publisher := new(testPublisher)
+	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
+
+	outboxService := NewOutboxService(testOutboxRepository, publisher, louie_kafka.Topics{Inbound: "LOUIE_EVENT", Outbound: "LOUIE_EVENT"}, websocket.InitAdminUiWebsocket(adminUiChannel), 3, "louie-web-administrator", false)
+
+	first := repository.NewOutboxEntity(louie_kafka.PlayersReady.String(), []byte(`{"event":"PLAYERS_READY"}`))
+	second := repository.NewOutboxEntity(louie_kafka.ConfirmedChangedSide.String(), []byte(`{"event":"CONFIRMED_CHANGE_SIDE"}`))
+
+	testOutboxRepository.On("GetPending", int64(100)).Return([]repository.OutboxEntity{first, second}, nil)
+	testOutboxRepository.On("MarkSent", first.Id).Return(nil)
+	testOutboxRepository.On("MarkSent", second.Id).Return(nil)
+	publisher.On("Publish", "LOUIE_EVENT", []louie_kafka.Message{{Value: []byte(`{"event":"PLAYERS_READY","sender":"louie-web-administrator"}`)}}).Return(nil)
+	publisher.On("Publish", "LOUIE_EVENT", []louie_kafka.Message{{Value: []byte(`{"event":"CONFIRMED_CHANGE_SIDE","sender":"louie-web-administrator"}`)}}).Return(nil)
+
+	outboxService.relayPending(context.Background())
+
+	testOutboxRepository.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+	assert.Empty(t, adminUiChannel)
+}
+
+func Test_RelayPending_PublishesToTopicOfDirection(t *testing.T) {
+
+	testOutboxRepository := new(repository.TestOutboxRepository)
+	publisher := new(testPublisher)
+	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
+	topics := louie_kafka.Topics{Inbound: "LOUIE_INBOUND", Outbound: "LOUIE_OUTBOUND", Technical: "LOUIE_TECHNICAL"}
+
+	outboxService := NewOutboxService(testOutboxRepository, publisher, topics, websocket.InitAdminUiWebsocket(adminUiChannel), 3, "louie-web-administrator", false)
+
+	playersReady := repository.NewOutboxEntity(louie_kafka.PlayersReady.String(), []byte(`{"event":"PLAYERS_READY","sender":"louie-web-administrator"}`))
+	confirmed := repository.NewOutboxEntity(louie_kafka.ConfirmedChangedSide.String(), []byte(`{"event":"CONFIRMED_CHANGE_SIDE"}`))
+
+	testOutboxRepository.On("GetPending", int64(100)).Return([]repository.OutboxEntity{playersReady, confirmed}, nil)
+	testOutboxRepository.On("MarkSent", playersReady.Id).Return(nil)
+	testOutboxRepository.On("MarkSent", confirmed.Id).Return(nil)
+	publisher.On("Publish", "LOUIE_OUTBOUND", mock.Anything).Return(nil)
+	publisher.On("Publish", "LOUIE_TECHNICAL", mock.Anything).Return(nil)
+
+	outboxService.relayPending(context.Background())
+
+	publisher.AssertExpectations(t)
+	assert.Equal(t, `{"event":"CONFIRMED_CHANGE_SIDE","sender":"louie-web-administrator"}`, string(publisher.Calls[1].Arguments.Get(1).([]louie_kafka.Message)[0].Value))
+}
+
+func Test_RelayPending_StopsAfterFailedAttempt(t *testing.T) {
+
+	testOutboxRepository := new(repository.TestOutboxRepository)
+	publisher := new(testPublisher)
+	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
+
+	outboxService := NewOutboxService(testOutboxRepository, publisher, louie_kafka.Topics{Inbound: "LOUIE_EVENT", Outbound: "LOUIE_EVENT"}, websocket.InitAdminUiWebsocket(adminUiChannel), 3, "louie-web-administrator", false)
+
+	first := repository.NewOutboxEntity(louie_kafka.PlayersReady.String(), []byte(`{"event":"PLAYERS_READY"}`))
+	second := repository.NewOutboxEntity(louie_kafka.ConfirmedChangedSide.String(), []byte(`{"event":"CONFIRMED_CHANGE_SIDE"}`))
+
+	testOutboxRepository.On("GetPending", int64(100)).Return([]repository.OutboxEntity{first, second}, nil)
+	testOutboxRepository.On("MarkAttemptFailed", first.Id, "broker not available", 3).Return(&repository.OutboxEntity{
+		Id:       first.Id,
+		State:    repository.OutboxPending,
+		Attempts: 1,
+	}, nil)
+	publisher.On("Publish", "LOUIE_EVENT", []louie_kafka.Message{{Value: []byte(`{"event":"PLAYERS_READY","sender":"louie-web-administrator"}`)}}).Return(errors.New("broker not available"))
+
+	outboxService.relayPending(context.Background())
+
+	testOutboxRepository.AssertExpectations(t)
+	publisher.AssertNumberOfCalls(t, "Publish", 1)
+	assert.Empty(t, adminUiChannel)
+}
+
+func Test_RelayPending_ReportsFailedEntryToAdminUi(t *testing.T) {
+
+	testOutboxRepository := new(repository.TestOutboxRepository)
+	publisher := new(testPublisher)
+	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
+
+	outboxService := NewOutboxService(testOutboxRepository, publisher, louie_kafka.Topics{Inbound: "LOUIE_EVENT", Outbound: "LOUIE_EVENT"}, websocket.InitAdminUiWebsocket(adminUiChannel), 3, "louie-web-administrator", false)
+
+	entry := repository.NewOutboxEntity(louie_kafka.PlayersReady.String(), []byte(`{"event":"PLAYERS_READY"}`))
+
+	testOutboxRepository.On("GetPending", int64(100)).Return([]repository.OutboxEntity{entry}, nil)
+	testOutboxRepository.On("MarkAttemptFailed", entry.Id, "broker not available", 3).Return(&repository.OutboxEntity{
+		Id:       entry.Id,
+		State:    repository.OutboxFailed,
+		Attempts: 3,
+	}, nil)
+	publisher.On("Publish", "LOUIE_EVENT", mock.Anything).Return(errors.New("broker not available"))
+
+	outboxService.relayPending(context.Background())
+
+	adminEvent := <-adminUiChannel
+
+	assert.Equal(t, websocket.ProducerFailure, adminEvent.EventType)
+	assert.Equal(t, "sending PLAYERS_READY to louie failed after 3 attempts: broker not available", adminEvent.Message)
+}
+
+func Test_RelayPending_PublishesEnvelope(t *testing.T) {
+
+	testOutboxRepository := new(repository.TestOutboxRepository)
+	publisher := new(testPublisher)
+	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
+
+	outboxService := NewOutboxService(testOutboxRepository, publisher, louie_kafka.Topics{Inbound: "LOUIE_EVENT", Outbound: "LOUIE_EVENT"}, websocket.InitAdminUiWebsocket(adminUiChannel), 3, "louie-web-administrator", true)
+
+	entry := repository.NewOutboxEntity(louie_kafka.ConfirmedChangedSide.String(), []byte(`{"event":"CONFIRMED_CHANGE_SIDE"}`))
+
+	testOutboxRepository.On("GetPending", int64(100)).Return([]repository.OutboxEntity{entry}, nil)
+	testOutboxRepository.On("MarkSent", entry.Id).Return(nil)
+	publisher.On("Publish", "LOUIE_EVENT", mock.Anything).Return(nil)
+
+	outboxService.relayPending(context.Background())
+
+	publishedMessages := publisher.Calls[0].Arguments.Get(1).([]louie_kafka.Message)
+	envelope, err := louie_kafka.DecodeEnvelope(publishedMessages[0].Value)
+
+	assert.Nil(t, err)
+	assert.Equal(t, entry.Id.Hex(), envelope.Id)
+	assert.Equal(t, louie_kafka.EnvelopeVersion, envelope.Version)
+	assert.Equal(t, louie_kafka.ConfirmedChangedSide, envelope.Event)
+	assert.Equal(t, "louie-web-administrator", envelope.Sender)
+	assert.Nil(t, louie_kafka.Validate(publishedMessages[0].Value))
+}
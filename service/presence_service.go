@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"github.com/thoas/go-funk"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/websocket"
+	"sync"
+	"time"
+)
+
+type PresenceState string
+
+const (
+	PresenceOnline  PresenceState = "online"
+	PresenceStale   PresenceState = "stale"
+	PresenceOffline PresenceState = "offline"
+	// PresenceUnknown is the state of a device without event since the start, until OfflineAfter is over.
+	PresenceUnknown PresenceState = "unknown"
+)
+
+func (c PresenceState) String() string {
+	return string(c)
+}
+
+// Device is a sender, which is shown with its presence in the admin ui.
+type Device struct {
+	Name   string
+	Sender string
+	// Table marks the louie tables. Games can only be created on tables, which are not offline.
+	Table bool
+}
+
+type DevicePresence struct {
+	Name     string
+	Sender   string
+	State    PresenceState
+	LastSeen string
+	Table    bool
+}
+
+// PresenceSer tracks the last time the louie tables and the ki sent an event (e.g. HEARTBEAT). The registry
+// is kept in memory, after a restart every device is unknown until its next event or until OfflineAfter is over.
+type PresenceSer struct {
+	Devices       []Device
+	StaleAfter    time.Duration
+	OfflineAfter  time.Duration
+	AdminUiSocket *websocket.AdminUiWebsocket
+	lastSeen      map[string]time.Time
+	lastStates    []PresenceState
+	startedAt     time.Time
+	mutex         sync.Mutex
+	now           func() time.Time
+}
+
+func NewPresenceService(
+	devices []Device,
+	staleAfter time.Duration,
+	offlineAfter time.Duration,
+	adminUiSocket *websocket.AdminUiWebsocket,
+) *PresenceSer {
+	return &PresenceSer{
+		Devices:       devices,
+		StaleAfter:    staleAfter,
+		OfflineAfter:  offlineAfter,
+		AdminUiSocket: adminUiSocket,
+		lastSeen:      make(map[string]time.Time),
+		startedAt:     time.Now(),
+		now:           time.Now,
+	}
+}
+
+// Seen records an event of the sender and updates the admin ui, if the presence changed. It is called by the
+// consumer, so the update never waits for the admin ui.
+func (p *PresenceSer) Seen(sender string) {
+
+	p.mutex.Lock()
+	p.lastSeen[sender] = p.now()
+	p.mutex.Unlock()
+
+	p.sendStatusToAdminUi()
+}
+
+func (p *PresenceSer) State(sender string) PresenceState {
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.state(sender)
+}
+
+func (p *PresenceSer) state(sender string) PresenceState {
+
+	lastSeen, ok := p.lastSeen[sender]
+
+	if !ok {
+		// the device may be online, but has not sent its heartbeat since the start.
+		if p.now().Sub(p.startedAt) < p.OfflineAfter {
+			return PresenceUnknown
+		}
+
+		return PresenceOffline
+	}
+
+	silence := p.now().Sub(lastSeen)
+
+	if silence >= p.OfflineAfter {
+		return PresenceOffline
+	} else if silence >= p.StaleAfter {
+		return PresenceStale
+	}
+
+	return PresenceOnline
+}
+
+// IsOffline reports whether the sender sent no event within OfflineAfter. A stale or unknown sender is not offline.
+func (p *PresenceSer) IsOffline(sender string) bool {
+	return p.State(sender) == PresenceOffline
+}
+
+func (p *PresenceSer) GetPresences() []DevicePresence {
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	presences := make([]DevicePresence, 0, len(p.Devices))
+
+	for _, device := range p.Devices {
+
+		lastSeen := "never"
+
+		if seen, ok := p.lastSeen[device.Sender]; ok {
+			lastSeen = seen.Local().Format(repository.GermanDateTimeFormat)
+		}
+
+		presences = append(presences, DevicePresence{
+			Name:     device.Name,
+			Sender:   device.Sender,
+			State:    p.state(device.Sender),
+			LastSeen: lastSeen,
+			Table:    device.Table,
+		})
+	}
+
+	return presences
+}
+
+// RunPresenceMonitor checks the presence with every interval, so devices become stale and offline without
+// receiving an event.
+func (p *PresenceSer) RunPresenceMonitor(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.sendStatusToAdminUi()
+			}
+		}
+	}()
+}
+
+func (p *PresenceSer) sendStatusToAdminUi() {
+
+	presences := p.GetPresences()
+	states := funk.Map(presences, func(presence DevicePresence) PresenceState {
+		return presence.State
+	}).([]PresenceState)
+
+	p.mutex.Lock()
+
+	if funk.Equal(states, p.lastStates) {
+		p.mutex.Unlock()
+		return
+	}
+
+	p.lastStates = states
+	p.mutex.Unlock()
+
+	p.AdminUiSocket.SendToAdminUi(toPresenceAdminUiEvent(presences))
+}
+
+func toPresenceAdminUiEvent(presences []DevicePresence) *websocket.AdminUiEvent {
+
+	devicePresences := make([]websocket.DevicePresence, 0, len(presences))
+
+	for _, presence := range presences {
+		devicePresences = append(devicePresences, websocket.DevicePresence{
+			Name:     presence.Name,
+			Sender:   presence.Sender,
+			State:    presence.State.String(),
+			LastSeen: presence.LastSeen,
+			Table:    presence.Table,
+		})
+	}
+
+	return &websocket.AdminUiEvent{
+		EventType: websocket.PresenceStatus,
+		Presences: devicePresences,
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"github.com/stretchr/testify/assert"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/websocket"
+	"testing"
+	"time"
+)
+
+func Test_Presence_OnlineStaleOffline(t *testing.T) {
+
+	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
+	now := time.Date(2024, 1, 12, 10, 15, 0, 0, time.UTC)
+
+	presenceService := NewPresenceService(
+		[]Device{{Name: "Louie", Sender: "c-library", Table: true}, {Name: "KI", Sender: "jan-ki-magic"}},
+		15*time.Second,
+		60*time.Second,
+		websocket.InitAdminUiWebsocket(adminUiChannel),
+	)
+	presenceService.now = func() time.Time { return now }
+	presenceService.startedAt = now.Add(-60 * time.Second)
+
+	assert.Equal(t, PresenceOffline, presenceService.State("c-library"))
+	assert.True(t, presenceService.IsOffline("c-library"))
+
+	presenceService.Seen("c-library")
+
+	adminUiEvent := <-adminUiChannel
+
+	assert.Equal(t, websocket.PresenceStatus, adminUiEvent.EventType)
+	assert.Equal(t, "online", adminUiEvent.Presences[0].State)
+	assert.True(t, adminUiEvent.Presences[0].Table)
+	assert.Equal(t, "offline", adminUiEvent.Presences[1].State)
+
+	now = now.Add(20 * time.Second)
+
+	assert.Equal(t, PresenceStale, presenceService.State("c-library"))
+	assert.False(t, presenceService.IsOffline("c-library"))
+
+	now = now.Add(40 * time.Second)
+
+	assert.Equal(t, PresenceOffline, presenceService.State("c-library"))
+	assert.True(t, presenceService.IsOffline("c-library"))
+}
+
+func Test_Presence_SendsOnlyChanges(t *testing.T) {
+
+	adminUiChannel := make(chan websocket.AdminUiEvent, 10)
+
+	presenceService := NewPresenceService(
+		[]Device{{Name: "Louie", Sender: "c-library", Table: true}},
+		15*time.Second,
+		60*time.Second,
+		websocket.InitAdminUiWebsocket(adminUiChannel),
+	)
+
+	presenceService.Seen("c-library")
+	presenceService.Seen("c-library")
+	presenceService.Seen("unknown-sender")
+
+	assert.Len(t, adminUiChannel, 1)
+}
+
+func Test_Presence_FlappingDeviceDoesNotBlockWithoutAdminUi(t *testing.T) {
+
+	now := time.Date(2024, 1, 12, 10, 15, 0, 0, time.UTC)
+
+	// nobody reads the admin ui events, like without a connected admin ui.
+	presenceService := NewPresenceService(
+		[]Device{{Name: "Louie", Sender: "c-library", Table: true}},
+		15*time.Second,
+		60*time.Second,
+		websocket.InitAdminUiWebsocket(make(chan websocket.AdminUiEvent, 1)),
+	)
+	presenceService.now = func() time.Time { return now }
+
+	seen := make(chan struct{})
+
+	go func() {
+		for i := 0; i < 20; i++ {
+			presenceService.Seen("c-library")
+			now = now.Add(61 * time.Second)
+			presenceService.sendStatusToAdminUi()
+		}
+		close(seen)
+	}()
+
+	select {
+	case <-seen:
+	case <-time.After(time.Second):
+		t.Fatal("presence changes blocked the consumer")
+	}
+}
+
+func Test_Presence_UnknownAfterStart(t *testing.T) {
+
+	now := time.Date(2024, 1, 12, 10, 15, 0, 0, time.UTC)
+
+	presenceService := NewPresenceService(
+		[]Device{{Name: "Louie", Sender: "c-library", Table: true}},
+		15*time.Second,
+		60*time.Second,
+		websocket.InitAdminUiWebsocket(make(chan websocket.AdminUiEvent, 10)),
+	)
+	presenceService.startedAt = now
+	presenceService.now = func() time.Time { return now }
+
+	assert.Equal(t, PresenceUnknown, presenceService.State("c-library"))
+	assert.False(t, presenceService.IsOffline("c-library"))
+
+	now = now.Add(60 * time.Second)
+
+	assert.Equal(t, PresenceOffline, presenceService.State("c-library"))
+	assert.True(t, presenceService.IsOffline("c-library"))
+}
+
+func Test_CreateGame_LouieOffline(t *testing.T) {
+
+	presenceService := NewPresenceService(
+		[]Device{{Name: "Louie", Sender: "c-library", Table: true}},
+		15*time.Second,
+		60*time.Second,
+		websocket.InitAdminUiWebsocket(make(chan websocket.AdminUiEvent, 10)),
+	)
+
+	presenceService.startedAt = time.Now().Add(-60 * time.Second)
+
+	gameService := GameSer{Presence: presenceService}
+
+	_, err := gameService.CreateGame(Table{Sender: "c-library", Name: "Louie", KiName: "Louki"}, repository.DefaultGameSettings(), nil)
+
+	assert.ErrorIs(t, err, ErrLouieOffline)
+}
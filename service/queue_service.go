@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"github.com/thoas/go-funk"
+	"log"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/websocket"
+	"sync"
+	"time"
+)
+
+// QueueSer publishes the metrics of the event queues between the message bus and the handlers.
+type QueueSer struct {
+	Queues        *louie_kafka.EventQueues
+	AdminUiSocket *websocket.AdminUiWebsocket
+	lastStats     []louie_kafka.QueueStats
+	mutex         sync.Mutex
+}
+
+func NewQueueService(queues *louie_kafka.EventQueues, adminUiSocket *websocket.AdminUiWebsocket) *QueueSer {
+	return &QueueSer{
+		Queues:        queues,
+		AdminUiSocket: adminUiSocket,
+	}
+}
+
+func (q *QueueSer) GetQueues() []louie_kafka.QueueStats {
+	return q.Queues.Stats()
+}
+
+// RunQueueMonitor sends the queue metrics to the admin ui with every interval, if they changed. A full queue is
+// logged, because the consumer stalls or drops events meanwhile.
+func (q *QueueSer) RunQueueMonitor(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				q.sendStatusToAdminUi()
+			}
+		}
+	}()
+}
+
+func (q *QueueSer) sendStatusToAdminUi() {
+
+	stats := q.GetQueues()
+
+	q.mutex.Lock()
+
+	if funk.Equal(stats, q.lastStats) {
+		q.mutex.Unlock()
+		return
+	}
+
+	q.lastStats = stats
+	q.mutex.Unlock()
+
+	for _, queue := range stats {
+		if queue.Depth >= queue.Capacity {
+			log.Printf("event queue %s is full (%d events, policy %s)\n", queue.Name, queue.Depth, queue.Policy)
+		}
+	}
+
+	q.AdminUiSocket.SendToAdminUi(toQueueAdminUiEvent(stats))
+}
+
+func toQueueAdminUiEvent(stats []louie_kafka.QueueStats) *websocket.AdminUiEvent {
+
+	queueDepths := make([]websocket.QueueDepth, 0, len(stats))
+
+	for _, queue := range stats {
+		queueDepths = append(queueDepths, websocket.QueueDepth{
+			Name:     queue.Name,
+			Depth:    queue.Depth,
+			Capacity: queue.Capacity,
+			Dropped:  queue.Dropped,
+			Rejected: queue.Rejected,
+		})
+	}
+
+	return &websocket.AdminUiEvent{
+		EventType: websocket.QueueStatus,
+		Queues:    queueDepths,
+	}
+}
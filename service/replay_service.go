@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"log"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/repository"
+	"louie-web-administrator/websocket"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Replayer re-runs the game state transitions of recorded louie events against the given repositories.
+// The repositories have to be empty (a fresh database or the in-memory repositories for a dry-run).
+type Replayer struct {
+	UserRepository repository.UserRepository
+	GameRepository repository.GameRepository
+	tableService   *TableSer
+	gameService    *GameSer
+	stateChecker   *GameStateChecker
+}
+
+type ReplayResult struct {
+	Processed int
+	Skipped   int
+}
+
+type StatisticDiff struct {
+	DisplayName          string
+	CurrentPlayedGames   int
+	ReplayedPlayedGames  int
+	CurrentGamesWon      int
+	ReplayedGamesWon     int
+	CurrentBestDuration  float64
+	ReplayedBestDuration float64
+}
+
+// NewReplayer wires the game state checker like main.go. Nothing is published, the outbox entries stay
+// in the outbox repository. Dashboard and admin ui events are discarded until the context is done.
+func NewReplayer(
+	ctx context.Context,
+	userRepository repository.UserRepository,
+	gameRepository repository.GameRepository,
+	outboxRepository repository.OutboxRepository,
+	tableService *TableSer,
+	sender string,
+) *Replayer {
+
+	dashboardChannel := make(chan *websocket.DashboardSignal, 100)
+	adminUiChannel := make(chan websocket.AdminUiEvent, 100)
+
+	go discardReplayedSignals(ctx, dashboardChannel, adminUiChannel)
+
+	dashboardChannels := make(map[string]chan *websocket.DashboardSignal)
+
+	for _, table := range tableService.GetAll() {
+		dashboardChannels[table.Sender] = dashboardChannel
+	}
+
+	adminUiWebsocket := websocket.InitAdminUiWebsocket(adminUiChannel)
+	outboxService := NewOutboxService(outboxRepository, louie_kafka.NewMemoryBus(), louie_kafka.Topics{}, adminUiWebsocket, 1, sender, false)
+
+	gameService := &GameSer{
+		UserRepository: userRepository,
+		GameRepository: gameRepository,
+		OutboxService:  outboxService,
+	}
+
+	stateChecker := &GameStateChecker{
+		UserService: &UserSer{UserRepository: userRepository},
+		GameService: gameService,
+		GameDashboardSocket: websocket.GameDashboardSocket{
+			GameDashboardChannels:    dashboardChannels,
+			DefaultTable:             tableService.Default().Sender,
+			GetCurrentDashboardState: gameService.GetCurrentDashboardState,
+		},
+		Tables:        tableService,
+		AdminUiSocket: *adminUiWebsocket,
+		DeadLetterService: &DeadLetterSer{
+			DeadLetterRepository: repository.NewMemoryDeadLetterRepo(),
+			AdminUiSocket:        adminUiWebsocket,
+		},
+	}
+
+	return &Replayer{
+		UserRepository: userRepository,
+		GameRepository: gameRepository,
+		tableService:   tableService,
+		gameService:    gameService,
+		stateChecker:   stateChecker,
+	}
+}
+
+func discardReplayedSignals(ctx context.Context, dashboardChannel chan *websocket.DashboardSignal, adminUiChannel chan websocket.AdminUiEvent) {
+	for {
+		select {
+		case <-dashboardChannel:
+		case <-adminUiChannel:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// SeedUsers registers the users again with initial statistic values. The ki users of the tables are
+// created anew.
+func (r *Replayer) SeedUsers(users []repository.RegisteredUser) error {
+
+	for _, user := range users {
+
+		if user.IsKiUser {
+			continue
+		}
+
+		if user.Table == "" {
+			user.Table = r.tableService.Default().Sender
+		}
+
+		if _, err := r.UserRepository.Create(user); err != nil {
+			return fmt.Errorf("seeding user %s failed: %w", user.DisplayName, err)
+		}
+	}
+
+	for _, table := range r.tableService.GetAll() {
+		if _, err := r.UserRepository.CreateKiUser(table.KiName, table.Sender); err != nil {
+			return fmt.Errorf("seeding ki user %s failed: %w", table.KiName, err)
+		}
+	}
+
+	return nil
+}
+
+// Replay processes the messages in the given order. The games are rebuilt from the PLAYERS_READY events
+// of the administrator, because only these contain the players of a game. The events of louie are
+// handed to the game state checker. Technical events and invalid events are skipped.
+func (r *Replayer) Replay(messages []louie_kafka.Message) ReplayResult {
+
+	var result ReplayResult
+
+	for _, message := range messages {
+
+		envelope, err := louie_kafka.DecodeEnvelope(message.Value)
+
+		if err != nil {
+			log.Printf("skip replayed message, which can not be parsed: %s\n", err)
+			result.Skipped++
+			continue
+		}
+
+		if err := louie_kafka.Validate(message.Value); err != nil {
+			log.Printf("skip invalid replayed event %s: %s\n", envelope.Event, err)
+			result.Skipped++
+			continue
+		}
+
+		processed := false
+
+		switch {
+		case envelope.Event == louie_kafka.PlayersReady:
+			processed = r.playersReady(message.Value)
+
+		case envelope.Event == louie_kafka.PlayersCanBeReceived:
+			// the game is switched to "ready" by the recorded PLAYERS_READY event.
+
+		case louie_kafka.IsInboundGameEvent(envelope.Event):
+			processed = r.stateChecker.checkAndUpdateGameState(message.Value)
+		}
+
+		if processed {
+			result.Processed++
+		} else {
+			result.Skipped++
+		}
+	}
+
+	return result
+}
+
+// playersReady inserts the game of the event in state "ready" and relates its players. A game, which is
+// announced again after a reset, is switched back to "ready". A running game of another id is aborted.
+func (r *Replayer) playersReady(message []byte) bool {
+
+	var playersReadyEvent louie_kafka.PlayersReadyEvent
+
+	if err := louie_kafka.UnmarshalPayload(message, &playersReadyEvent); err != nil {
+		log.Printf("failures during unmarshal players ready event: %s\n", err)
+		return false
+	}
+
+	// events of older versions have no table
+	table := r.tableService.ForSender(playersReadyEvent.Table)
+
+	currentGame, _ := r.gameService.GetCurrentGame(table.Sender)
+
+	if currentGame != nil && currentGame.Id == playersReadyEvent.GameId {
+		_, err := r.gameService.UpdateGameState(currentGame.Id, repository.GameReady)
+		return err == nil
+	}
+
+	if currentGame != nil && !currentGame.State.IsOver() {
+		// the game was left without result, e.g. it was removed in the admin ui. It must not stay the running
+		// game of the table.
+		if _, err := r.gameService.UpdateGameState(currentGame.Id, repository.GameAborted); err != nil {
+			return false
+		}
+	}
+
+	gameId, err := primitive.ObjectIDFromHex(playersReadyEvent.GameId)
+
+	if err != nil {
+		// events of older versions have no game id
+		gameId = primitive.NewObjectID()
+	}
+
+	game := repository.GameEntity{
+		Id:            gameId,
+		Table:         table.Sender,
+		State:         repository.GameReady,
+		Duration:      repository.InitialGameDuration,
+		Seats:         playersReadyEvent.Seats,
+		StartingCoins: playersReadyEvent.StartingCoins,
+		CreatedAt:     gameId.Timestamp(),
+	}
+
+	players := make([]string, 0, repository.MaxSeats)
+
+	if !playersReadyEvent.WithoutKi {
+		game.KiName, game.KiCoins = table.KiName, game.StartingCoinsOrDefault()
+		players = append(players, table.KiName)
+	}
+
+	for i, player := range playersReadyEvent.Players {
+		// events of older versions have no seat, the players are sent in the order of their seats.
+		seat := player.Seat
+
+		if seat == 0 {
+			seat = i + 1
+		}
+
+		game.TakeSeat(seat, player.DisplayName)
+		players = append(players, player.DisplayName)
+	}
+
+	if err := r.GameRepository.InsertGame(game); err != nil {
+		return false
+	}
+
+	for _, displayName := range players {
+
+		user, _ := r.UserRepository.GetByDisplayName(displayName)
+
+		if user == nil {
+			log.Printf("replayed player %s is not registered\n", displayName)
+			continue
+		}
+
+		if _, err := r.UserRepository.UpdateGameRelationship(&user.Id, &gameId); err != nil {
+			log.Printf("relating replayed player %s failed: %s\n", displayName, err)
+		}
+	}
+
+	return true
+}
+
+// DiffStatistics compares the statistic values of the users by display name. Only users with different
+// values are returned, sorted by display name.
+func DiffStatistics(current []repository.RegisteredUser, replayed []repository.RegisteredUser) []StatisticDiff {
+
+	diffs := make(map[string]*StatisticDiff)
+
+	diffOf := func(displayName string) *StatisticDiff {
+		key := strings.ToLower(displayName)
+		if _, ok := diffs[key]; !ok {
+			diffs[key] = &StatisticDiff{
+				DisplayName:          displayName,
+				CurrentBestDuration:  repository.InitialUserDuration,
+				ReplayedBestDuration: repository.InitialUserDuration,
+			}
+		}
+		return diffs[key]
+	}
+
+	for _, user := range current {
+		diff := diffOf(user.DisplayName)
+		diff.CurrentPlayedGames = user.PlayedGames
+		diff.CurrentGamesWon = user.GamesWon
+		diff.CurrentBestDuration = user.BestDuration
+	}
+
+	for _, user := range replayed {
+		diff := diffOf(user.DisplayName)
+		diff.ReplayedPlayedGames = user.PlayedGames
+		diff.ReplayedGamesWon = user.GamesWon
+		diff.ReplayedBestDuration = user.BestDuration
+	}
+
+	result := make([]StatisticDiff, 0)
+
+	for _, diff := range diffs {
+		if diff.CurrentPlayedGames != diff.ReplayedPlayedGames ||
+			diff.CurrentGamesWon != diff.ReplayedGamesWon ||
+			diff.CurrentBestDuration != diff.ReplayedBestDuration {
+			result = append(result, *diff)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i].DisplayName) < strings.ToLower(result[j].DisplayName)
+	})
+
+	return result
+}
+
+func PrintStatisticDiff(w io.Writer, diffs []StatisticDiff) error {
+
+	if len(diffs) == 0 {
+		_, err := fmt.Fprintln(w, "no differences of the user statistics")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "PLAYER\tPLAYED (CURRENT -> REPLAYED)\tWON (CURRENT -> REPLAYED)\tBEST DURATION (CURRENT -> REPLAYED)")
+
+	for _, diff := range diffs {
+		fmt.Fprintf(tw, "%s\t%d -> %d\t%d -> %d\t%.1f -> %.1f\n",
+			diff.DisplayName,
+			diff.CurrentPlayedGames, diff.ReplayedPlayedGames,
+			diff.CurrentGamesWon, diff.ReplayedGamesWon,
+			diff.CurrentBestDuration, diff.ReplayedBestDuration,
+		)
+	}
+
+	return tw.Flush()
+}
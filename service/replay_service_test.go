@@ -0,0 +1,92 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"louie-web-administrator/louie_kafka"
+	"louie-web-administrator/repository"
+	"strings"
+	"testing"
+)
+
+func Test_Replay_RebuildsUserStatistics(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gameId := primitive.NewObjectID().Hex()
+
+	archive := strings.Join([]string{
+		`{"event":"PLAYERS_CAN_BE_RECEIVED","sender":"c-library"}`,
+		`{"event":"PLAYERS_READY","sender":"louie-web-administrator","players":[{"display_name":"tobi"},{"display_name":"willi"}],"game_id":"` + gameId + `"}`,
+		`{"event":"PLAYERS_CONFIRM","sender":"c-library","game_id":"` + gameId + `"}`,
+		`{"event":"COIN_DROP","sender":"c-library","name":"tobi","coins":2,"game_id":"` + gameId + `"}`,
+		``,
+		`{"event":"GAME_DONE","sender":"c-library","duration":42.0,"winning_player":{"name":"willi"},"game_id":"` + gameId + `"}`,
+		`{"event":"GAME_DONE","sender":"c-library","duration":42.0,"winning_player":{"name":"willi"},"game_id":"` + gameId + `"}`,
+		`{"event":"HEARTBEAT","sender":"c-library"}`,
+	}, "\n")
+
+	messages, err := louie_kafka.ReadArchive(strings.NewReader(archive))
+
+	assert.Nil(t, err)
+	assert.Len(t, messages, 7)
+
+	userRepository := repository.NewMemoryUserRepo()
+	outboxRepository := repository.NewMemoryOutboxRepo()
+	gameRepository := repository.NewMemoryGameRepo(outboxRepository)
+
+	tableService := NewTableService([]Table{{Sender: "c-library", Name: "Louie", KiName: repository.KiName}})
+
+	replayer := NewReplayer(ctx, userRepository, gameRepository, outboxRepository, tableService, "louie-web-administrator")
+
+	currentUsers := []repository.RegisteredUser{
+		{DisplayName: "tobi", Email: "tobi@louie.de", PlayedGames: 1, BestDuration: 42},
+		{DisplayName: "willi", Email: "willi@louie.de", PlayedGames: 2, GamesWon: 2, BestDuration: 30},
+		{DisplayName: repository.KiName, IsKiUser: true},
+	}
+
+	assert.Nil(t, replayer.SeedUsers(currentUsers))
+
+	result := replayer.Replay(messages)
+
+	// the redelivered GAME_DONE is processed as known event, PLAYERS_CAN_BE_RECEIVED and HEARTBEAT are skipped
+	assert.Equal(t, 5, result.Processed)
+	assert.Equal(t, 2, result.Skipped)
+
+	game, _ := gameRepository.GetCurrent("c-library")
+
+	assert.Equal(t, gameId, game.Id.Hex())
+	assert.Equal(t, repository.GameFinished, game.State)
+	assert.Equal(t, 2, game.Player1Coins)
+
+	replayedUsers, _ := userRepository.GetAll()
+
+	diffs := DiffStatistics(currentUsers, replayedUsers)
+
+	assert.Equal(t, []StatisticDiff{
+		{
+			DisplayName:          repository.KiName,
+			CurrentPlayedGames:   0,
+			ReplayedPlayedGames:  1,
+			CurrentBestDuration:  0,
+			ReplayedBestDuration: 42,
+		},
+		{
+			DisplayName:          "willi",
+			CurrentPlayedGames:   2,
+			ReplayedPlayedGames:  1,
+			CurrentGamesWon:      2,
+			ReplayedGamesWon:     1,
+			CurrentBestDuration:  30,
+			ReplayedBestDuration: 42,
+		},
+	}, diffs)
+
+	var output bytes.Buffer
+
+	assert.Nil(t, PrintStatisticDiff(&output, diffs))
+	assert.Contains(t, output.String(), "2 -> 1")
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"louie-web-administrator/repository"
+	"regexp"
+	"strings"
+)
+
+var ErrUnknownTable = errors.New("unknown table")
+
+// tableSenderPattern restricts the sender, because it is part of the html element ids of the admin ui.
+var tableSenderPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Table is one louie machine. The events of a table are identified by their sender, so the sender is the
+// key of the table. Every table has its own ki player.
+type Table struct {
+	Sender string
+	Name   string
+	KiName string
+	// KiSender is the sender of the ki device of the table, e.g. for PLZ_CHANGE_SIDE. Empty without ki device.
+	KiSender string
+}
+
+// TableSer knows the configured tables. The first table is the default table, which gets the events
+// without sender (legacy events) and the games and users created before tables existed.
+type TableSer struct {
+	tables []Table
+}
+
+func NewTableService(tables []Table) *TableSer {
+	return &TableSer{tables: tables}
+}
+
+// ParseTables reads table definitions in the format "sender:name[:ki name[:ki sender]]". Without ki name, the
+// first table keeps the ki "Louki" and every further table gets "Louki <name>".
+func ParseTables(definitions []string) ([]Table, error) {
+
+	tables := make([]Table, 0, len(definitions))
+
+	for i, definition := range definitions {
+
+		parts := strings.Split(strings.TrimSpace(definition), ":")
+
+		if len(parts) < 2 || len(parts) > 4 || !tableSenderPattern.MatchString(parts[0]) || parts[1] == "" ||
+			(len(parts) == 4 && !tableSenderPattern.MatchString(parts[3])) {
+			return nil, fmt.Errorf("invalid table definition \"%s\", expected \"sender:name[:ki name[:ki sender]]\"", definition)
+		}
+
+		table := Table{Sender: parts[0], Name: parts[1], KiName: repository.KiName}
+
+		if len(parts) >= 3 && parts[2] != "" {
+			table.KiName = parts[2]
+		} else if i > 0 {
+			table.KiName = fmt.Sprintf("%s %s", repository.KiName, table.Name)
+		}
+
+		if len(parts) == 4 {
+			table.KiSender = parts[3]
+		}
+
+		if table.KiSender == table.Sender {
+			return nil, fmt.Errorf("table \"%s\" has the same sender for the table and its ki", table.Name)
+		}
+
+		for _, existingTable := range tables {
+			if existingTable.Sender == table.Sender || existingTable.KiName == table.KiName ||
+				(table.KiSender != "" && (existingTable.KiSender == table.KiSender || existingTable.Sender == table.KiSender)) ||
+				(existingTable.KiSender != "" && existingTable.KiSender == table.Sender) {
+				return nil, fmt.Errorf("table \"%s\" has the sender, ki name or ki sender of table \"%s\"", table.Name, existingTable.Name)
+			}
+		}
+
+		tables = append(tables, table)
+	}
+
+	if len(tables) == 0 {
+		return nil, errors.New("at least one table has to be defined")
+	}
+
+	return tables, nil
+}
+
+func (t *TableSer) GetAll() []Table {
+	return t.tables
+}
+
+func (t *TableSer) Default() Table {
+	return t.tables[0]
+}
+
+func (t *TableSer) Get(sender string) (Table, error) {
+
+	for _, table := range t.tables {
+		if table.Sender == sender {
+			return table, nil
+		}
+	}
+
+	return Table{}, fmt.Errorf("%w: %s", ErrUnknownTable, sender)
+}
+
+// ForSender returns the table of an event, the events of a ki belong to the table of the ki. Events without sender
+// belong to the default table, like the events of unknown senders with only one table.
+func (t *TableSer) ForSender(sender string) Table {
+
+	for _, table := range t.tables {
+		if table.Sender == sender || (table.KiSender != "" && table.KiSender == sender) {
+			return table
+		}
+	}
+
+	return t.Default()
+}
+
+// IsKnownSender accepts the tables, their ki and events without sender. With more than one table, the events of
+// unknown senders are rejected, because they can not be assigned to a table.
+func (t *TableSer) IsKnownSender(sender string) bool {
+
+	if sender == "" || len(t.tables) == 1 {
+		return true
+	}
+
+	for _, table := range t.tables {
+		if table.Sender == sender || (table.KiSender != "" && table.KiSender == sender) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AssignMissingTables moves the users and games, which were created before tables existed, to the
+// default table.
+func (t *TableSer) AssignMissingTables(userRepository repository.UserRepository, gameRepository repository.GameRepository) error {
+
+	if _, err := userRepository.AssignMissingTable(t.Default().Sender); err != nil {
+		return err
+	}
+
+	return gameRepository.AssignMissingTable(t.Default().Sender)
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"github.com/stretchr/testify/assert"
+	"louie-web-administrator/repository"
+	"testing"
+)
+
+func Test_ParseTables(t *testing.T) {
+
+	tables, err := ParseTables([]string{"c-library:Louie", "d-library:Louie 2", "e-library:Louie 3:Jan:e-ki"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Table{
+		{Sender: "c-library", Name: "Louie", KiName: repository.KiName},
+		{Sender: "d-library", Name: "Louie 2", KiName: "Louki Louie 2"},
+		{Sender: "e-library", Name: "Louie 3", KiName: "Jan", KiSender: "e-ki"},
+	}, tables)
+}
+
+func Test_ParseTables_Invalid(t *testing.T) {
+
+	for _, definitions := range [][]string{
+		{},
+		{"c-library"},
+		{"c library:Louie"},
+		{"c-library:Louie", "c-library:Louie 2"},
+		{"c-library:Louie:Jan", "d-library:Louie 2:Jan"},
+		{"c-library:Louie:Jan:ki", "d-library:Louie 2:Willi:ki"},
+		{"c-library:Louie:Jan:ki:more"},
+		{"c-library:Louie:Jan:c-library"},
+		{"c-library:Louie:Jan:d-library", "d-library:Louie 2"},
+	} {
+		_, err := ParseTables(definitions)
+
+		assert.NotNil(t, err, definitions)
+	}
+}
+
+func Test_ForSender_FallsBackToDefaultTable(t *testing.T) {
+
+	tableService := NewTableService([]Table{
+		{Sender: "c-library", Name: "Louie", KiName: repository.KiName, KiSender: "jan-ki-magic"},
+		{Sender: "d-library", Name: "Louie 2", KiName: "Louki Louie 2", KiSender: "d-ki"},
+	})
+
+	assert.Equal(t, "d-library", tableService.ForSender("d-library").Sender)
+	assert.Equal(t, "d-library", tableService.ForSender("d-ki").Sender)
+	assert.Equal(t, "c-library", tableService.ForSender("jan-ki-magic").Sender)
+	assert.Equal(t, "c-library", tableService.ForSender("").Sender)
+
+	_, err := tableService.Get("jan-ki-magic")
+
+	assert.ErrorIs(t, err, ErrUnknownTable)
+}
+
+func Test_IsKnownSender(t *testing.T) {
+
+	tableService := NewTableService([]Table{
+		{Sender: "c-library", Name: "Louie", KiName: repository.KiName, KiSender: "jan-ki-magic"},
+		{Sender: "d-library", Name: "Louie 2", KiName: "Louki Louie 2"},
+	})
+
+	assert.True(t, tableService.IsKnownSender(""))
+	assert.True(t, tableService.IsKnownSender("d-library"))
+	assert.True(t, tableService.IsKnownSender("jan-ki-magic"))
+	assert.False(t, tableService.IsKnownSender("e-library"))
+
+	singleTableService := NewTableService([]Table{{Sender: "c-library", Name: "Louie", KiName: repository.KiName}})
+
+	assert.True(t, singleTableService.IsKnownSender("e-library"))
+}
@@ -1,50 +1,43 @@
 package service
 
 import (
-	"context"
 	"encoding/json"
-	"github.com/segmentio/kafka-go"
 	"log"
 	"louie-web-administrator/louie_kafka"
 	"louie-web-administrator/websocket"
 )
 
 type TechnicalEventHandler struct {
-	KafkaProducer louie_kafka.KafkaProducer
+	OutboxService OutboxService
 }
 
-func (t *TechnicalEventHandler) SendConfirmedChangeSideEvent() {
+func (t *TechnicalEventHandler) SendConfirmedChangeSideEvent(table string) error {
 
-	confirmedChangeSideMessage, err := json.Marshal(louie_kafka.DefaultEvent{
+	err := t.OutboxService.Enqueue(louie_kafka.ConfirmedChangedSide, louie_kafka.ConfirmedChangeSideEvent{
 		Event: louie_kafka.ConfirmedChangedSide,
+		Table: table,
 	})
 
 	if err != nil {
-		log.Printf("can not marshal confirmed changed side kafka message: %s\n", err)
+		log.Printf("storing confirmed changed side message in outbox failed %s\n", err)
+		return err
 	}
 
-	messages := []kafka.Message{
-		{Value: confirmedChangeSideMessage},
-	}
-
-	if err != nil {
-		log.Printf("sending player can be received message to kafka failed %s\n", err)
-	}
-
-	t.KafkaProducer.WriteKafkaMessage(context.Background(), &messages)
+	return nil
 }
 
 func RunTechnicalEventHandler(
-	kafkaTechnicalEventChannel chan kafka.Message,
+	kafkaTechnicalEventChannel chan louie_kafka.ConsumedMessage,
 	adminUiChannel chan websocket.AdminUiEvent,
-	kafkaProducer louie_kafka.KafkaProducer,
+	outboxService OutboxService,
+	tableService *TableSer,
 ) *TechnicalEventHandler {
 
-	go handleTechnicalKafkaEvents(kafkaTechnicalEventChannel, adminUiChannel)
+	go handleTechnicalKafkaEvents(kafkaTechnicalEventChannel, websocket.InitAdminUiWebsocket(adminUiChannel), tableService)
 
-	return &TechnicalEventHandler{kafkaProducer}
+	return &TechnicalEventHandler{outboxService}
 }
-func handleTechnicalKafkaEvents(kafkaTechnicalEventChannel chan kafka.Message, adminUiChannel chan websocket.AdminUiEvent) {
+func handleTechnicalKafkaEvents(kafkaTechnicalEventChannel chan louie_kafka.ConsumedMessage, adminUiSocket *websocket.AdminUiWebsocket, tableService *TableSer) {
 	for message := range kafkaTechnicalEventChannel {
 		var tmpReceivedEvent louie_kafka.DefaultEvent
 
@@ -53,9 +46,21 @@ func handleTechnicalKafkaEvents(kafkaTechnicalEventChannel chan kafka.Message, a
 		switch tmpReceivedEvent.Event {
 
 		case louie_kafka.PleaseChangeSide:
-			adminUiChannel <- websocket.AdminUiEvent{
-				EventType: websocket.PlzChangeSide,
+			var sender string
+
+			if envelope, err := louie_kafka.DecodeEnvelope(message.Value); err == nil {
+				sender = envelope.Sender
 			}
+
+			adminUiSocket.SendToAdminUi(&websocket.AdminUiEvent{
+				EventType: websocket.PlzChangeSide,
+				Table:     tableService.ForSender(sender).Sender,
+			})
+
+		case louie_kafka.Heartbeat:
+			// the presence of the sender is already tracked by the router
 		}
+
+		message.Done()
 	}
 }
@@ -6,24 +6,27 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"log"
 	"louie-web-administrator/repository"
+	"strconv"
 )
 
 type UserService interface {
-	InitOrRefreshLouki()
+	InitOrRefreshKiUsers(tables []Table)
 	Create(dashboardUser *DashboardUser) (*mongo.InsertOneResult, error)
-	GetAllActive() ([]repository.RegisteredUser, error)
+	GetAllActive(table string) ([]repository.RegisteredUser, error)
 	GetUsers(page int64, nameFilter string) []UserEntry
 	GetByGameId(gameId primitive.ObjectID) ([]repository.RegisteredUser, error)
-	CountActiveUsersWithoutKiUser() int
+	CountActiveUsersWithoutKiUser(table string) int
 	CountAllWithoutKiUser(nameFilter string) int64
-	ShufflePositionsForActiveUsers()
-	UpdateStatistic(user repository.RegisteredUser) (*mongo.UpdateResult, error)
+	ShufflePositionsForActiveUsers(table string, seats int)
+	UpdateStatistic(user repository.RegisteredUser, gameId primitive.ObjectID) (*mongo.UpdateResult, error)
 	UpdateState(id string, state string) (int64, error)
 	UpdatePosition(id string, position string) (int64, error)
+	UpdateTable(id string, table string) (int64, error)
 	SetAllToWaiting() error
 	MapUserIdsToPositions(formParameters map[string][]string) ([]Tuple, error)
 	MapUserIdsToStates(formParameters map[string][]string) ([]Tuple, error)
-	FilterNewUserIdsForActivation(userIdsToStates []Tuple) []string
+	MapUserIdsToTables(formParameters map[string][]string) ([]Tuple, error)
+	FilterNewUserIdsForActivation(table string, userIdsToStates []Tuple) []string
 }
 
 type Ranking struct {
@@ -42,6 +45,7 @@ type UserEntry struct {
 	PlayedGames         int
 	Pos                 string
 	State               repository.UserState
+	Table               string
 }
 
 type DashboardUser struct {
@@ -51,28 +55,41 @@ type DashboardUser struct {
 	Email              string
 	FirstName          string
 	LastName           string
+	// Table is the sender of the louie table, the user is queued for.
+	Table string
 }
 
 type UserSer struct {
 	UserRepository repository.UserRepository
 }
 
-func (u *UserSer) InitOrRefreshLouki() {
+// InitOrRefreshKiUsers creates the missing ki user of every table. An existing ki user keeps its statistics of the
+// games it took part in. It is only moved back to its table and activated, so it can join the next game with ki.
+func (u *UserSer) InitOrRefreshKiUsers(tables []Table) {
 
-	kiUser, _ := u.UserRepository.GetByDisplayName(repository.KiName)
+	for _, table := range tables {
 
-	if kiUser != nil {
-		u.UserRepository.Remove(repository.KiName)
-	}
+		kiUser, _ := u.UserRepository.GetByDisplayName(table.KiName)
+
+		if kiUser != nil && kiUser.IsKiUser {
+			u.UserRepository.UpdateTable(kiUser.Id.Hex(), table.Sender)
+			u.UserRepository.UpdateState(kiUser.Id.Hex(), string(repository.UserActive))
+			continue
+		}
+
+		if kiUser != nil {
+			u.UserRepository.Remove(table.KiName)
+		}
 
-	u.UserRepository.CreateKiUser()
+		u.UserRepository.CreateKiUser(table.KiName, table.Sender)
+	}
 }
 
 func (u *UserSer) Create(dashboardUser *DashboardUser) (*mongo.InsertOneResult, error) {
 	return u.UserRepository.Create(*dashboardUser.toUserEntity())
 }
-func (u *UserSer) GetAllActive() ([]repository.RegisteredUser, error) {
-	return u.UserRepository.GetAllActive()
+func (u *UserSer) GetAllActive(table string) ([]repository.RegisteredUser, error) {
+	return u.UserRepository.GetAllActive(table)
 }
 
 func (u *UserSer) SetAllToWaiting() error {
@@ -98,13 +115,13 @@ func (u *UserSer) CountAllWithoutKiUser(nameFilter string) int64 {
 
 	return usersCount
 }
-func (u *UserSer) CountActiveUsersWithoutKiUser() int {
-	activeUsers, _ := u.GetAllActive()
+func (u *UserSer) CountActiveUsersWithoutKiUser(table string) int {
+	activeUsers, _ := u.GetAllActive(table)
 
 	return len(filterNonKiUsers(activeUsers))
 }
-func (u *UserSer) UpdateStatistic(user repository.RegisteredUser) (*mongo.UpdateResult, error) {
-	return u.UserRepository.UpdateGameStatisticValues(user)
+func (u *UserSer) UpdateStatistic(user repository.RegisteredUser, gameId primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return u.UserRepository.UpdateGameStatisticValues(user, gameId)
 }
 func (u *UserSer) UpdatePosition(id string, position string) (int64, error) {
 	mongoResult, err := u.UserRepository.UpdatePosition(id, position)
@@ -114,14 +131,22 @@ func (u *UserSer) UpdatePosition(id string, position string) (int64, error) {
 	return updateResult, err
 }
 
-func (u *UserSer) ShufflePositionsForActiveUsers() {
-	activeUsers, _ := u.UserRepository.GetAllActive()
-	shuffledPositions := funk.Shuffle([3]string{"1", "2", "3"}).([]string)
+// ShufflePositionsForActiveUsers seats the active users of the table in random order on the seats of the next
+// game. The positions of the users left over are cleared, so they are not seated with an old position.
+func (u *UserSer) ShufflePositionsForActiveUsers(table string, seats int) {
+	activeUsers, _ := u.UserRepository.GetAllActive(table)
+	shuffledUsers := funk.Shuffle(filterNonKiUsers(activeUsers)).([]repository.RegisteredUser)
+
+	seats = max(1, min(seats, repository.MaxSeats))
 
-	activeNonKiUsers := filterNonKiUsers(activeUsers)
+	for index, user := range shuffledUsers {
+		position := ""
 
-	for index, user := range activeNonKiUsers {
-		u.UpdatePosition(user.Id.Hex(), shuffledPositions[index])
+		if index < seats {
+			position = strconv.Itoa(index + 1)
+		}
+
+		u.UpdatePosition(user.Id.Hex(), position)
 	}
 }
 
@@ -132,6 +157,16 @@ func (u *UserSer) UpdateState(id string, state string) (int64, error) {
 
 	return updateResult, err
 }
+func (u *UserSer) UpdateTable(id string, table string) (int64, error) {
+	mongoResult, err := u.UserRepository.UpdateTable(id, table)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return mongoResult.ModifiedCount + mongoResult.MatchedCount, nil
+}
+
 func (u *UserSer) GetByGameId(gameId primitive.ObjectID) ([]repository.RegisteredUser, error) {
 	return u.UserRepository.GetByGameId(gameId)
 }
@@ -156,9 +191,19 @@ func (u *UserSer) MapUserIdsToStates(formParameters map[string][]string) ([]Tupl
 
 	return userIdsToStates, nil
 }
-func (u *UserSer) FilterNewUserIdsForActivation(userIdsToStates []Tuple) []string {
+func (u *UserSer) MapUserIdsToTables(formParameters map[string][]string) ([]Tuple, error) {
+	userIdsToTables, err := mapToTuples(formParameters, "id", "table")
+
+	if err != nil {
+		log.Printf("can not map form parameters to id:table tuples %s\n", err)
+		return nil, err
+	}
+
+	return userIdsToTables, nil
+}
+func (u *UserSer) FilterNewUserIdsForActivation(table string, userIdsToStates []Tuple) []string {
 
-	activeUsers, _ := u.GetAllActive()
+	activeUsers, _ := u.GetAllActive(table)
 	return filterNewActiveUsers(userIdsToStates, activeUsers)
 }
 func (u *UserSer) toUserEntry(userEntity *repository.RegisteredUser) *UserEntry {
@@ -171,6 +216,7 @@ func (u *UserSer) toUserEntry(userEntity *repository.RegisteredUser) *UserEntry
 		PlayedGames:         userEntity.PlayedGames,
 		Pos:                 userEntity.Pos,
 		State:               userEntity.State,
+		Table:               userEntity.Table,
 	}
 
 	if userEntity.LastTimePlayed != nil {
@@ -189,5 +235,6 @@ func (d *DashboardUser) toUserEntity() *repository.RegisteredUser {
 		Email:              d.Email,
 		FirstName:          d.FirstName,
 		LastName:           d.LastName,
+		Table:              d.Table,
 	}
 }
@@ -16,8 +16,8 @@ func (testUserService *TestUserService) Create(dashboardUser *DashboardUser) (*m
 	return args.Get(0).(*mongo.InsertOneResult), args.Error(1)
 }
 
-func (testUserService *TestUserService) GetAllActive() ([]repository.RegisteredUser, error) {
-	args := testUserService.Called()
+func (testUserService *TestUserService) GetAllActive(table string) ([]repository.RegisteredUser, error) {
+	args := testUserService.Called(table)
 	return args.Get(0).([]repository.RegisteredUser), args.Error(1)
 }
 
@@ -26,8 +26,8 @@ func (testUserService *TestUserService) GetUsers(page int64, nameFilter string)
 	return args.Get(0).([]UserEntry)
 }
 
-func (testUserService *TestUserService) CountActiveUsersWithoutKiUser() int {
-	args := testUserService.Called()
+func (testUserService *TestUserService) CountActiveUsersWithoutKiUser(table string) int {
+	args := testUserService.Called(table)
 	return args.Get(0).(int)
 }
 
@@ -36,8 +36,8 @@ func (testUserService *TestUserService) CountAllWithoutKiUser(nameFilter string)
 	return args.Get(0).(int64)
 }
 
-func (testUserService *TestUserService) UpdateStatistic(user repository.RegisteredUser) (*mongo.UpdateResult, error) {
-	args := testUserService.Called(user)
+func (testUserService *TestUserService) UpdateStatistic(user repository.RegisteredUser, gameId primitive.ObjectID) (*mongo.UpdateResult, error) {
+	args := testUserService.Called(user, gameId)
 	return args.Get(0).(*mongo.UpdateResult), args.Error(1)
 }
 
@@ -66,13 +66,13 @@ func (testUserService *TestUserService) MapUserIdsToStates(formParameters map[st
 	return args.Get(0).([]Tuple), args.Error(1)
 }
 
-func (testUserService *TestUserService) FilterNewUserIdsForActivation(userIdsToStates []Tuple) []string {
-	args := testUserService.Called(userIdsToStates)
+func (testUserService *TestUserService) FilterNewUserIdsForActivation(table string, userIdsToStates []Tuple) []string {
+	args := testUserService.Called(table, userIdsToStates)
 	return args.Get(0).([]string)
 }
 
-func (testUserService *TestUserService) InitOrRefreshLouki() {
-	testUserService.Called()
+func (testUserService *TestUserService) InitOrRefreshKiUsers(tables []Table) {
+	testUserService.Called(tables)
 	return
 }
 
@@ -81,7 +81,17 @@ func (testUserService *TestUserService) SetAllToWaiting() error {
 	return args.Error(0)
 }
 
-func (testUserService *TestUserService) ShufflePositionsForActiveUsers() {
-	testUserService.Called()
+func (testUserService *TestUserService) ShufflePositionsForActiveUsers(table string, seats int) {
+	testUserService.Called(table, seats)
 	return
 }
+
+func (testUserService *TestUserService) UpdateTable(id string, table string) (int64, error) {
+	args := testUserService.Called(id, table)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (testUserService *TestUserService) MapUserIdsToTables(formParameters map[string][]string) ([]Tuple, error) {
+	args := testUserService.Called(formParameters)
+	return args.Get(0).([]Tuple), args.Error(1)
+}
@@ -21,7 +21,7 @@ func Test_ShufflePositionsForActiveUsers(t *testing.T) {
 	homerId := primitive.NewObjectID()
 	kiId := primitive.NewObjectID()
 
-	testUserRepository.On("GetAllActive").Return([]repository.RegisteredUser{
+	testUserRepository.On("GetAllActive", "c-library").Return([]repository.RegisteredUser{
 		{
 			Id:          maxId,
 			DisplayName: "max",
@@ -63,7 +63,7 @@ func Test_ShufflePositionsForActiveUsers(t *testing.T) {
 		UpsertedID:    homerId,
 	}, nil)
 
-	userService.ShufflePositionsForActiveUsers()
+	userService.ShufflePositionsForActiveUsers("c-library", 2)
 
 	testUserRepository.AssertExpectations(t)
 
@@ -77,5 +77,6 @@ func Test_ShufflePositionsForActiveUsers(t *testing.T) {
 
 	slices.Sort(positions)
 
-	assert.Equal(t, []string{"1", "2", "3"}, positions)
+	// the user left over has no seat in the game with two seats.
+	assert.Equal(t, []string{"", "1", "2"}, positions)
 }
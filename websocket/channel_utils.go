@@ -0,0 +1,24 @@
+package websocket
+
+// sendDroppingOldest sends the value without blocking. If the channel is full, the oldest value is dropped, so the
+// newest state still reaches the ui. It returns false, if a value was dropped.
+func sendDroppingOldest[T any](channel chan T, value T) bool {
+
+	select {
+	case channel <- value:
+		return true
+	default:
+	}
+
+	select {
+	case <-channel:
+	default:
+	}
+
+	select {
+	case channel <- value:
+	default:
+	}
+
+	return false
+}
@@ -0,0 +1,30 @@
+package websocket
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_SendToAdminUi_DropsOldestWithoutAdminUi(t *testing.T) {
+
+	adminUiChannel := make(chan AdminUiEvent, 2)
+	adminUiWebsocket := InitAdminUiWebsocket(adminUiChannel)
+
+	adminUiWebsocket.SendToAdminUi(&AdminUiEvent{EventType: Ready})
+	adminUiWebsocket.SendToAdminUi(&AdminUiEvent{EventType: Paused})
+	adminUiWebsocket.SendToAdminUi(&AdminUiEvent{EventType: Finished})
+
+	assert.Equal(t, Paused, (<-adminUiChannel).EventType)
+	assert.Equal(t, Finished, (<-adminUiChannel).EventType)
+}
+
+func Test_SendToDashboard_DropsOldestWithoutDashboard(t *testing.T) {
+
+	dashboardChannel := make(chan *DashboardSignal, 1)
+	dashboardSocket := &GameDashboardSocket{GameDashboardChannels: map[string]chan *DashboardSignal{"c-library": dashboardChannel}}
+
+	dashboardSocket.SendToDashboard(&DashboardSignal{Table: "c-library", DashboardGame: &DashboardGame{Player1Coins: 3}})
+	dashboardSocket.SendToDashboard(&DashboardSignal{Table: "c-library", DashboardGame: &DashboardGame{Player1Coins: 2}})
+
+	assert.Equal(t, 2, (<-dashboardChannel).DashboardGame.Player1Coins)
+}
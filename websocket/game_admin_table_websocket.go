@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"github.com/gorilla/websocket"
 	_ "github.com/gorilla/websocket"
+	"html"
 	"log"
 	"net/http"
+	"strings"
 )
 
 type AdminUiEventType string
@@ -15,7 +17,18 @@ const (
 	Ready                     AdminUiEventType = "ready"
 	Active                    AdminUiEventType = "active"
 	Finished                  AdminUiEventType = "finished"
+	Aborted                   AdminUiEventType = "aborted"
+	Paused                    AdminUiEventType = "paused"
 	PlzChangeSide             AdminUiEventType = "plz_change_side"
+	ProducerFailure           AdminUiEventType = "producer_failure"
+	OutboxStatus              AdminUiEventType = "outbox_status"
+	DeadLetterStatus          AdminUiEventType = "dead_letter_status"
+	PresenceStatus            AdminUiEventType = "presence_status"
+	ConnectionStatus          AdminUiEventType = "connection_status"
+	QueueStatus               AdminUiEventType = "queue_status"
+	WatchdogAlert             AdminUiEventType = "watchdog_alert"
+	MatchAnnounced            AdminUiEventType = "match_announced"
+	MatchQueueAlert           AdminUiEventType = "match_queue_alert"
 	ActivateGameStartButton                    = "activate_game_start"
 	DeactivateGameStartButton                  = "deactivate_game_start"
 )
@@ -33,6 +46,10 @@ func ValueOf(value string) (AdminUiEventType, error) {
 		return Active, nil
 	} else if value == Finished.String() {
 		return Finished, nil
+	} else if value == Aborted.String() {
+		return Aborted, nil
+	} else if value == Paused.String() {
+		return Paused, nil
 	} else if value == PlzChangeSide.String() {
 		return PlzChangeSide, nil
 	} else {
@@ -42,11 +59,51 @@ func ValueOf(value string) (AdminUiEventType, error) {
 }
 
 type AdminUiEvent struct {
-	EventType    AdminUiEventType
-	KiCoins      int
-	Player1Coins int
-	Player2Coins int
-	Player3Coins int
+	EventType AdminUiEventType
+	// Table is the sender of the louie table, the game and side change events belong to.
+	Table         string
+	KiCoins       int
+	Player1Coins  int
+	Player2Coins  int
+	Player3Coins  int
+	Player4Coins  int
+	Message       string
+	OutboxPending int64
+	OutboxFailed  int64
+	DeadLetters   int64
+	Presences     []DevicePresence
+	Connections   []BrokerConnection
+	Queues        []QueueDepth
+	// GameId and Prompt belong to a watchdog alert. A prompt asks the operator to reset the game.
+	GameId string
+	Prompt bool
+}
+
+// DevicePresence is the online state of a louie table or the ki.
+type DevicePresence struct {
+	Name     string
+	Sender   string
+	State    string
+	LastSeen string
+	// Table marks the louie tables. Games can only be created on tables, which are not offline.
+	Table bool
+}
+
+// BrokerConnection is the state of a connection of the message bus, e.g. the consumer of a kafka topic.
+type BrokerConnection struct {
+	Name  string
+	State string
+	Error string
+	Since string
+}
+
+// QueueDepth is the number of events waiting in an event queue for their handler.
+type QueueDepth struct {
+	Name     string
+	Depth    int
+	Capacity int
+	Dropped  int64
+	Rejected int64
 }
 
 type AdminUiWebsocket struct {
@@ -58,8 +115,12 @@ var AdminUiWebsocketUpgrader = websocket.Upgrader{
 	WriteBufferSize: 1024,
 }
 
+// SendToAdminUi never blocks, the consumer and the event handlers must not wait for the admin ui. Without a
+// connected admin ui the channel fills up, then the oldest event is dropped.
 func (a *AdminUiWebsocket) SendToAdminUi(adminUiEvent *AdminUiEvent) {
-	a.adminUiChannel <- *adminUiEvent
+	if !sendDroppingOldest(a.adminUiChannel, *adminUiEvent) {
+		log.Printf("admin ui channel is full, dropped %s event\n", adminUiEvent.EventType)
+	}
 }
 
 func InitAdminUiWebsocket(adminUiChannel chan AdminUiEvent) *AdminUiWebsocket {
@@ -102,30 +163,220 @@ func adminUiWriter(conn *websocket.Conn, done chan struct{}, adminUiChannel chan
 func createGameStateHtmlSnippet(adminUiSignal AdminUiEvent) string {
 	var renderedMessage string
 
+	table := adminUiSignal.Table
+
 	switch adminUiSignal.EventType {
 	case PlzChangeSide:
-		renderedMessage = fmt.Sprintf("<div hx-swap-oob=\"replace:#confirm-change-side\">" +
-			"<button class=\"btn btn-secondary\" hx-post=\"/confirm\">Confirm side change</button>" +
-			"</div>")
+		renderedMessage = fmt.Sprintf("<div hx-swap-oob=\"replace:#confirm-change-side-%s\">"+
+			"<button class=\"btn btn-secondary\" hx-post=\"/confirm\" hx-vals='{\"table\": \"%s\"}'>Confirm side change</button>"+
+			"</div>", table, table)
 	case Announced:
-		renderedMessage = fmt.Sprintf("<div hx-swap-oob=\"replace:#game-state\"><p class=\"state-announced\">%s</p></div>", adminUiSignal.EventType)
+		renderedMessage = fmt.Sprintf(""+
+			"<div hx-swap-oob=\"replace:#game-state-%s\"><p class=\"state-announced\">!!!! %s !!!!</p></div>"+
+			createCoinsHtmlSnippet(adminUiSignal), table, adminUiSignal.EventType) + createWatchdogClearHtmlSnippet(table)
 	case Ready:
-		renderedMessage = fmt.Sprintf("<div hx-swap-oob=\"replace:#game-state\"><p class=\"state-ready\">!!!! %s !!!!</p></div>", adminUiSignal.EventType)
+		renderedMessage = fmt.Sprintf("<div hx-swap-oob=\"replace:#game-state-%s\"><p class=\"state-ready\">!!!! %s !!!!</p></div>", table, adminUiSignal.EventType) +
+			createWatchdogClearHtmlSnippet(table)
 	case Active:
 		renderedMessage = fmt.Sprintf(""+
-			"<div hx-swap-oob=\"replace:#game-state\"><p class=\"state-active\">!!!! %s !!!!</p></div>"+
-			"<div hx-swap-oob=\"replace:#ki-coins\"><p>%d</p></div>"+
-			"<div hx-swap-oob=\"replace:#player1-coins\"><p>%d</p></div>"+
-			"<div hx-swap-oob=\"replace:#player2-coins\"><p>%d</p></div>"+
-			"<div hx-swap-oob=\"replace:#player3-coins\"><p>%d</p></div>"+
-			"", adminUiSignal.EventType, adminUiSignal.KiCoins, adminUiSignal.Player1Coins, adminUiSignal.Player2Coins, adminUiSignal.Player3Coins)
+			"<div hx-swap-oob=\"replace:#game-state-%s\"><p class=\"state-active\">!!!! %s !!!!</p></div>"+
+			createCoinsHtmlSnippet(adminUiSignal), table, adminUiSignal.EventType) + createWatchdogClearHtmlSnippet(table)
 	case Finished:
-		renderedMessage = fmt.Sprintf("<div hx-swap-oob=\"replace:#game-state\"><p class=\"state-finished\">!!!! %s !!!!</p></div>", adminUiSignal.EventType)
+		renderedMessage = fmt.Sprintf("<div hx-swap-oob=\"replace:#game-state-%s\"><p class=\"state-finished\">!!!! %s !!!!</p></div>", table, adminUiSignal.EventType) +
+			createWatchdogClearHtmlSnippet(table)
+	case Paused:
+		renderedMessage = fmt.Sprintf("<div hx-swap-oob=\"replace:#game-state-%s\"><p class=\"state-paused\">!!!! %s !!!!</p></div>", table, adminUiSignal.EventType) +
+			createWatchdogClearHtmlSnippet(table)
+	case Aborted:
+		renderedMessage = fmt.Sprintf("<div hx-swap-oob=\"replace:#game-state-%s\"><p class=\"state-aborted\">!!!! %s !!!!</p><p>%s</p></div>",
+			table, adminUiSignal.EventType, html.EscapeString(adminUiSignal.Message)) + createWatchdogClearHtmlSnippet(table)
+	case WatchdogAlert:
+		renderedMessage = createWatchdogAlertHtmlSnippet(adminUiSignal)
+	case MatchAnnounced:
+		renderedMessage = createMatchAnnouncedHtmlSnippet(adminUiSignal)
+	case MatchQueueAlert:
+		renderedMessage = fmt.Sprintf("<div hx-swap-oob=\"replace:#match-queue-alert-%s\">"+
+			"<p class=\"alert alert-warning\">%s</p>"+
+			"</div>", table, html.EscapeString(adminUiSignal.Message))
+	case ProducerFailure:
+		renderedMessage = fmt.Sprintf("<div hx-swap-oob=\"replace:#producer-failure\">"+
+			"<p class=\"alert alert-danger\">%s</p>"+
+			"</div>", html.EscapeString(adminUiSignal.Message))
+	case OutboxStatus:
+		renderedMessage = fmt.Sprintf("<div hx-swap-oob=\"replace:#outbox-status\">"+
+			"<span class=\"badge bg-secondary\">pending: %d</span> "+
+			"<span class=\"badge %s\">failed: %d</span>"+
+			"</div>", adminUiSignal.OutboxPending, outboxFailedBadgeClass(adminUiSignal.OutboxFailed), adminUiSignal.OutboxFailed)
+	case DeadLetterStatus:
+		renderedMessage = fmt.Sprintf("<div hx-swap-oob=\"replace:#dead-letter-status\">"+
+			"<span class=\"badge %s\">open: %d</span> "+
+			"<button class=\"btn btn-secondary btn-sm\" hx-get=\"/dead-letter\" hx-target=\"#dead-letter-content\">Refresh</button>"+
+			"</div>", outboxFailedBadgeClass(adminUiSignal.DeadLetters), adminUiSignal.DeadLetters)
+	case PresenceStatus:
+		renderedMessage = createPresenceHtmlSnippet(adminUiSignal)
+	case ConnectionStatus:
+		renderedMessage = createConnectionHtmlSnippet(adminUiSignal)
+	case QueueStatus:
+		renderedMessage = createQueueHtmlSnippet(adminUiSignal)
 	}
 
 	return renderedMessage
 }
 
+func createCoinsHtmlSnippet(adminUiSignal AdminUiEvent) string {
+
+	table := adminUiSignal.Table
+
+	return fmt.Sprintf(""+
+		"<div hx-swap-oob=\"replace:#ki-coins-%s\"><p>%d</p></div>"+
+		"<div hx-swap-oob=\"replace:#player1-coins-%s\"><p>%d</p></div>"+
+		"<div hx-swap-oob=\"replace:#player2-coins-%s\"><p>%d</p></div>"+
+		"<div hx-swap-oob=\"replace:#player3-coins-%s\"><p>%d</p></div>"+
+		"<div hx-swap-oob=\"replace:#player4-coins-%s\"><p>%d</p></div>"+
+		"", table, adminUiSignal.KiCoins, table, adminUiSignal.Player1Coins, table, adminUiSignal.Player2Coins, table, adminUiSignal.Player3Coins,
+		table, adminUiSignal.Player4Coins)
+}
+
+// createWatchdogAlertHtmlSnippet shows the alert of the watchdog above the game of the table. A prompt offers the
+// reset of the game like the reset button of the games-table-content template.
+func createWatchdogAlertHtmlSnippet(adminUiSignal AdminUiEvent) string {
+
+	table := adminUiSignal.Table
+	resetButton := ""
+
+	if adminUiSignal.Prompt {
+		resetButton = fmt.Sprintf(" <button id=\"reset-%s\" class=\"btn btn-secondary btn-sm\" hx-put=\"/game/reset\" "+
+			"hx-vals='{\"table\": \"%s\"}' hx-target=\"#games-content-%s\" "+
+			"hx-confirm=\"Reset the game? Louie starts the round again.\">Reset</button>", adminUiSignal.GameId, table, table)
+	}
+
+	return fmt.Sprintf("<div hx-swap-oob=\"replace:#watchdog-alert-%s\">"+
+		"<p class=\"alert alert-warning\">%s%s</p>"+
+		"</div>", table, html.EscapeString(adminUiSignal.Message), resetButton)
+}
+
+// createMatchAnnouncedHtmlSnippet shows the automatically announced match and reloads the game of the table, which
+// contains the new players and the remaining match queue.
+func createMatchAnnouncedHtmlSnippet(adminUiSignal AdminUiEvent) string {
+
+	table := adminUiSignal.Table
+
+	return fmt.Sprintf("<div hx-swap-oob=\"replace:#match-queue-alert-%s\">"+
+		"<p class=\"alert alert-info\">%s</p>"+
+		"<div hx-get=\"/game\" hx-vals='{\"table\": \"%s\"}' hx-trigger=\"load\" hx-target=\"#games-content-%s\"></div>"+
+		"</div>", table, html.EscapeString(adminUiSignal.Message), table, table)
+}
+
+// createWatchdogClearHtmlSnippet removes the alert of the watchdog, because the game moved on.
+func createWatchdogClearHtmlSnippet(table string) string {
+	return fmt.Sprintf("<div hx-swap-oob=\"replace:#watchdog-alert-%s\"></div>", table)
+}
+
+func createPresenceHtmlSnippet(adminUiSignal AdminUiEvent) string {
+
+	var presenceBadges strings.Builder
+	var gameStartButtons strings.Builder
+
+	for _, presence := range adminUiSignal.Presences {
+		presenceBadges.WriteString(fmt.Sprintf("<span class=\"badge %s\" title=\"last seen: %s\">%s: %s</span> ",
+			presenceBadgeClass(presence.State), html.EscapeString(presence.LastSeen), html.EscapeString(presence.Name), presence.State))
+
+		if presence.Table {
+			gameStartButtons.WriteString(createGameStartButtonHtmlSnippet(presence))
+		}
+	}
+
+	return fmt.Sprintf("<div hx-swap-oob=\"replace:#presence-status\">%s</div>%s", presenceBadges.String(), gameStartButtons.String())
+}
+
+func createConnectionHtmlSnippet(adminUiSignal AdminUiEvent) string {
+
+	var connectionBadges strings.Builder
+
+	for _, connection := range adminUiSignal.Connections {
+		connectionBadges.WriteString(fmt.Sprintf("<span class=\"badge %s\" title=\"since: %s %s\">%s: %s</span> ",
+			connectionBadgeClass(connection.State), html.EscapeString(connection.Since), html.EscapeString(connection.Error),
+			html.EscapeString(connection.Name), connection.State))
+	}
+
+	return fmt.Sprintf("<div hx-swap-oob=\"replace:#connection-status\">%s</div>", connectionBadges.String())
+}
+
+func createQueueHtmlSnippet(adminUiSignal AdminUiEvent) string {
+
+	var queueBadges strings.Builder
+
+	for _, queue := range adminUiSignal.Queues {
+		queueBadges.WriteString(fmt.Sprintf("<span class=\"badge %s\" title=\"dropped: %d, rejected: %d\">queue %s: %d/%d</span> ",
+			queueBadgeClass(queue.Depth, queue.Capacity), queue.Dropped, queue.Rejected, html.EscapeString(queue.Name), queue.Depth, queue.Capacity))
+	}
+
+	return fmt.Sprintf("<div hx-swap-oob=\"replace:#queue-status\">%s</div>", queueBadges.String())
+}
+
+// createGameStartButtonHtmlSnippet must match the game start button of the games-table-content template.
+func createGameStartButtonHtmlSnippet(presence DevicePresence) string {
+
+	offline := presence.State == "offline"
+	disabled := ""
+	offlineHint := ""
+
+	if offline {
+		disabled = " disabled"
+		offlineHint = "<p class=\"text-danger\">Louie is offline</p>"
+	}
+
+	return fmt.Sprintf("<div hx-swap-oob=\"replace:#game-start-button-%s\">"+
+		"<button class=\"btn btn-secondary\" hx-post=\"/game\" hx-include=\"#game-settings-%s\" hx-vals='{\"table\": \"%s\"}'%s>"+
+		"Create game with active users</button>%s"+
+		"</div>", presence.Sender, presence.Sender, presence.Sender, disabled, offlineHint)
+}
+
+// presenceBadgeClass must match the badge classes of the presence-status template.
+func presenceBadgeClass(state string) string {
+	switch state {
+	case "online":
+		return "bg-success"
+	case "stale":
+		return "bg-warning"
+	case "unknown":
+		return "bg-secondary"
+	}
+
+	return "bg-danger"
+}
+
+// connectionBadgeClass must match the badge classes of the connection-status template.
+func connectionBadgeClass(state string) string {
+	switch state {
+	case "connected":
+		return "bg-success"
+	case "connecting", "reconnecting":
+		return "bg-warning"
+	}
+
+	return "bg-danger"
+}
+
+// queueBadgeClass must match the badge classes of the queue-status template.
+func queueBadgeClass(depth int, capacity int) string {
+	if depth >= capacity {
+		return "bg-danger"
+	} else if depth > 0 {
+		return "bg-warning"
+	}
+
+	return "bg-secondary"
+}
+
+func outboxFailedBadgeClass(failed int64) string {
+	if failed > 0 {
+		return "bg-danger"
+	}
+
+	return "bg-secondary"
+}
+
 func adminUiReader(conn *websocket.Conn, done chan struct{}) {
 	defer conn.Close()
 	defer close(done)
@@ -2,19 +2,25 @@ package websocket
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/gorilla/websocket"
 	"log"
 	"net/http"
 )
 
 type DashboardSignal struct {
+	// Table is the sender of the louie table, the signal belongs to.
+	Table            string             `json:"table"`
 	DashboardGame    *DashboardGame     `json:"dashboardGame"`
 	DashboardRanking []DashboardRanking `json:"dashboardRanking"`
 }
 
+// GameDashboardSocket sends the signals of every table to the dashboards of this table. A dashboard selects
+// its table with the query parameter "table", without parameter it shows the default table.
 type GameDashboardSocket struct {
-	GameDashboardChannel     chan *DashboardSignal
-	GetCurrentDashboardState func() (*DashboardSignal, error)
+	GameDashboardChannels    map[string]chan *DashboardSignal
+	DefaultTable             string
+	GetCurrentDashboardState func(table string) (*DashboardSignal, error)
 }
 
 type DashboardRanking struct {
@@ -28,6 +34,8 @@ type DashboardGame struct {
 	DocId    string `json:"docId"`
 	Duration int    `json:"duration"`
 
+	// WithKi is false, if the ki sits out. The ki name is empty and the ki coins are 0 then.
+	WithKi  bool   `json:"withKi"`
 	KiName  string `json:"kiName"`
 	KiCoins int    `json:"kiCoins"`
 
@@ -40,7 +48,19 @@ type DashboardGame struct {
 	Player3      string `json:"player3"`
 	Player3Coins int    `json:"player3Coins"`
 
+	Player4      string `json:"player4"`
+	Player4Coins int    `json:"player4Coins"`
+
+	// Seats is the number of human players, StartingCoins are the coins of every participant at the start.
+	Seats         int `json:"seats"`
+	StartingCoins int `json:"startingCoins"`
+
 	State string `json:"state"`
+	// Paused stops the game clock of the dashboard. PlayingTime are the seconds the game was active so far.
+	Paused      bool `json:"paused"`
+	PlayingTime int  `json:"playingTime"`
+	// AbortReason is set for the state "aborted", so the dashboard can tell the players, why the game ended.
+	AbortReason string `json:"abortReason,omitempty"`
 }
 
 var (
@@ -53,31 +73,53 @@ var (
 	}
 )
 
+// SendToDashboard never blocks, so a slow or missing dashboard does not stall the game handler. A signal contains
+// the whole game, so the oldest signal is dropped, if the channel is full.
 func (g *GameDashboardSocket) SendToDashboard(dashboardSignal *DashboardSignal) {
-	g.GameDashboardChannel <- dashboardSignal
+
+	dashboardChannel, ok := g.GameDashboardChannels[dashboardSignal.Table]
+
+	if !ok {
+		log.Printf("no dashboard for table \"%s\". skip signal", dashboardSignal.Table)
+		return
+	}
+
+	if !sendDroppingOldest(dashboardChannel, dashboardSignal) {
+		log.Printf("dashboard channel of table \"%s\" is full, dropped the oldest signal\n", dashboardSignal.Table)
+	}
 }
 
-func (g *GameDashboardSocket) RemoveGameFromDashboard() {
+func (g *GameDashboardSocket) RemoveGameFromDashboard(table string) {
+
+	state, err := g.GetCurrentDashboardState(table)
+
+	if err != nil || state == nil {
+		state = &DashboardSignal{Table: table}
+	}
 
-	state, _ := g.GetCurrentDashboardState()
 	state.DashboardGame = nil
 
-	g.GameDashboardChannel <- state
+	g.SendToDashboard(state)
 }
 
 func InitGameDashboardSocket(
-	dashboardSocketChannel chan *DashboardSignal,
-	getCurrentDashboardState func() (*DashboardSignal, error),
+	dashboardSocketChannels map[string]chan *DashboardSignal,
+	defaultTable string,
+	getCurrentDashboardState func(table string) (*DashboardSignal, error),
 ) *GameDashboardSocket {
 
-	currentGame, err := getCurrentDashboardState()
+	for table, dashboardSocketChannel := range dashboardSocketChannels {
 
-	if currentGame != nil && err != nil {
-		dashboardSocketChannel <- currentGame
+		currentGame, err := getCurrentDashboardState(table)
+
+		if currentGame != nil && err != nil {
+			dashboardSocketChannel <- currentGame
+		}
 	}
 
 	return &GameDashboardSocket{
-		GameDashboardChannel:     dashboardSocketChannel,
+		GameDashboardChannels:    dashboardSocketChannels,
+		DefaultTable:             defaultTable,
 		GetCurrentDashboardState: getCurrentDashboardState,
 	}
 }
@@ -85,8 +127,21 @@ func InitGameDashboardSocket(
 func (g *GameDashboardSocket) GameDashboardWebsocketEndpoint() func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		log.Printf("start to initialize websocket to dashboard. Try to send current game to dashboard")
-		currentGame, err := g.GetCurrentDashboardState()
+		table := r.URL.Query().Get("table")
+
+		if table == "" {
+			table = g.DefaultTable
+		}
+
+		dashboardChannel, ok := g.GameDashboardChannels[table]
+
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown table \"%s\"", table), http.StatusNotFound)
+			return
+		}
+
+		log.Printf("start to initialize websocket to dashboard of table %s. Try to send current game to dashboard", table)
+		currentGame, err := g.GetCurrentDashboardState(table)
 
 		if currentGame != nil && err == nil {
 			g.SendToDashboard(currentGame)
@@ -99,7 +154,7 @@ func (g *GameDashboardSocket) GameDashboardWebsocketEndpoint() func(w http.Respo
 
 		done := make(chan struct{})
 
-		go gameDashboardWriter(ws, done, g.GameDashboardChannel)
+		go gameDashboardWriter(ws, done, dashboardChannel)
 		go gameDashboardReader(ws, done)
 	}
 }